@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+// BloomFilter 判断键是否可能存在。MayContain 返回 false 时键一定不存在，返回 true 时键可能存在
+type BloomFilter interface {
+	Add(key string)
+	MayContain(key string) bool
+}
+
+var _ BloomFilter = &MemoryBloomFilter{}
+
+// MemoryBloomFilter 进程内的布隆过滤器，多个实例之间不共享
+type MemoryBloomFilter struct {
+	mutex sync.RWMutex
+	bits  []uint64
+	// m 位数组的长度，k 哈希函数的个数
+	m uint64
+	k uint64
+}
+
+// NewMemoryBloomFilter n 为预计的键的个数，falsePositive 为期望的误判率，e.g. 0.01
+func NewMemoryBloomFilter(n uint64, falsePositive float64) *MemoryBloomFilter {
+	if n == 0 {
+		n = 1
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(falsePositive) / (math.Ln2 * math.Ln2)))
+	if m == 0 {
+		m = 1
+	}
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k == 0 {
+		k = 1
+	}
+	return &MemoryBloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+func (b *MemoryBloomFilter) Add(key string) {
+	h1, h2 := doubleHash(key)
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for i := uint64(0); i < b.k; i++ {
+		idx := (h1 + i*h2) % b.m
+		b.bits[idx/64] |= 1 << (idx % 64)
+	}
+}
+
+func (b *MemoryBloomFilter) MayContain(key string) bool {
+	h1, h2 := doubleHash(key)
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	for i := uint64(0); i < b.k; i++ {
+		idx := (h1 + i*h2) % b.m
+		if b.bits[idx/64]&(1<<(idx%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// doubleHash 使用一次 64 位的哈希模拟 k 个哈希函数：g_i(x) = h1(x) + i*h2(x)，
+// 布隆过滤器与 count-min sketch 共用
+func doubleHash(key string) (uint64, uint64) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	sum := h.Sum64()
+	// h2 为奇数，避免所有的位置都相同
+	return sum & math.MaxUint32, sum>>32 | 1
+}
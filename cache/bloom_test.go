@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryBloomFilter(t *testing.T) {
+	const n = 1000
+	f := NewMemoryBloomFilter(n, 0.01)
+	for i := 0; i < n; i++ {
+		f.Add("key:" + strconv.Itoa(i))
+	}
+	for i := 0; i < n; i++ {
+		assert.True(t, f.MayContain("key:"+strconv.Itoa(i)))
+	}
+
+	falsePositives := 0
+	for i := n; i < 2*n; i++ {
+		if f.MayContain("key:" + strconv.Itoa(i)) {
+			falsePositives++
+		}
+	}
+	// 期望误判率为 1%，留出足够的余量
+	assert.Less(t, falsePositives, n/20)
+}
@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// InvalidateMessage 通知其它节点删除本地缓存中的键
+type InvalidateMessage struct {
+	// Node 发送消息的节点，节点忽略自己发送的消息
+	Node string `json:"node"`
+	Key  string `json:"key"`
+}
+
+// Broadcaster 在节点之间广播缓存失效的消息
+type Broadcaster interface {
+	Publish(ctx context.Context, msg InvalidateMessage) error
+	// Subscribe 立即返回，之后收到的消息交给 handler 处理，ctx 被取消之后停止订阅
+	Subscribe(ctx context.Context, handler func(msg InvalidateMessage)) error
+}
+
+var _ Broadcaster = &RedisBroadcaster{}
+
+// RedisBroadcaster 基于 redis 的 pub/sub，消息不会持久化，
+// 订阅断开期间的消息会丢失，因此本地缓存需要设置较短的过期时间兜底
+type RedisBroadcaster struct {
+	client  redis.UniversalClient
+	channel string
+}
+
+func NewRedisBroadcaster(client redis.UniversalClient, channel string) *RedisBroadcaster {
+	return &RedisBroadcaster{
+		client:  client,
+		channel: channel,
+	}
+}
+
+func (r *RedisBroadcaster) Publish(ctx context.Context, msg InvalidateMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return r.client.Publish(ctx, r.channel, data).Err()
+}
+
+func (r *RedisBroadcaster) Subscribe(ctx context.Context, handler func(msg InvalidateMessage)) error {
+	sub := r.client.Subscribe(ctx, r.channel)
+	// 等待订阅成功，避免返回之后、订阅生效之前的消息丢失
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return err
+	}
+	ch := sub.Channel()
+	go func() {
+		defer func() { _ = sub.Close() }()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case m, ok := <-ch:
+				if !ok {
+					return
+				}
+				var msg InvalidateMessage
+				if err := json.Unmarshal([]byte(m.Payload), &msg); err != nil {
+					log.Println("cache: 无法解析缓存失效消息", m.Payload, err)
+					continue
+				}
+				handler(msg)
+			}
+		}
+	}()
+	return nil
+}
+
+var _ Broadcaster = &MemoryBroadcaster{}
+
+// MemoryBroadcaster 进程内的 Broadcaster，同步地将消息发送给所有的订阅者，
+// 用于测试或者单进程中的多个缓存实例
+type MemoryBroadcaster struct {
+	mutex    sync.RWMutex
+	id       int
+	handlers map[int]func(msg InvalidateMessage)
+}
+
+func NewMemoryBroadcaster() *MemoryBroadcaster {
+	return &MemoryBroadcaster{
+		handlers: map[int]func(msg InvalidateMessage){},
+	}
+}
+
+func (m *MemoryBroadcaster) Publish(ctx context.Context, msg InvalidateMessage) error {
+	m.mutex.RLock()
+	handlers := make([]func(msg InvalidateMessage), 0, len(m.handlers))
+	for _, handler := range m.handlers {
+		handlers = append(handlers, handler)
+	}
+	m.mutex.RUnlock()
+	for _, handler := range handlers {
+		handler(msg)
+	}
+	return nil
+}
+
+func (m *MemoryBroadcaster) Subscribe(ctx context.Context, handler func(msg InvalidateMessage)) error {
+	m.mutex.Lock()
+	id := m.id
+	m.id++
+	m.handlers[id] = handler
+	m.mutex.Unlock()
+	go func() {
+		<-ctx.Done()
+		m.mutex.Lock()
+		delete(m.handlers, id)
+		m.mutex.Unlock()
+	}()
+	return nil
+}
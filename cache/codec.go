@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec 负责 TypedCache 中的值与缓存中保存的字节之间的转换
+type Codec interface {
+	Marshal(val any) ([]byte, error)
+	// Unmarshal val 为指向目标值的指针
+	Unmarshal(data []byte, val any) error
+}
+
+// JSONCodec 通用性最好，其它语言的服务也可以读取
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(val any) ([]byte, error) {
+	return json.Marshal(val)
+}
+
+func (JSONCodec) Unmarshal(data []byte, val any) error {
+	return json.Unmarshal(data, val)
+}
+
+// GobCodec 只适合 Go 服务之间共享缓存，接口类型的值需要提前 gob.Register
+type GobCodec struct{}
+
+func (GobCodec) Marshal(val any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(val); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte, val any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(val)
+}
+
+// ProtoCodec 值必须是 proto.Message，e.g. TypedCache[*pb.User]
+type ProtoCodec struct{}
+
+func (ProtoCodec) Marshal(val any) ([]byte, error) {
+	msg, ok := val.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("cache: %T 没有实现 proto.Message", val)
+	}
+	return proto.Marshal(msg)
+}
+
+func (ProtoCodec) Unmarshal(data []byte, val any) error {
+	msg, ok := val.(proto.Message)
+	if !ok {
+		// TypedCache[*pb.User] 传入的是 **pb.User，需要先分配消息
+		rv := reflect.ValueOf(val)
+		if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Ptr {
+			return fmt.Errorf("cache: %T 没有实现 proto.Message", val)
+		}
+		if rv.Elem().IsNil() {
+			rv.Elem().Set(reflect.New(rv.Elem().Type().Elem()))
+		}
+		if msg, ok = rv.Elem().Interface().(proto.Message); !ok {
+			return fmt.Errorf("cache: %T 没有实现 proto.Message", val)
+		}
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+// MsgpackCodec 使用 MessagePack 编码，比 JSON 紧凑，支持字符串、切片、map 等变长的值，
+// 结构体按照字段名编码，其它语言的服务也可以读取
+type MsgpackCodec struct{}
+
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+func (MsgpackCodec) Marshal(val any) ([]byte, error) {
+	var data []byte
+	if err := codec.NewEncoderBytes(&data, msgpackHandle).Encode(val); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (MsgpackCodec) Unmarshal(data []byte, val any) error {
+	return codec.NewDecoderBytes(data, msgpackHandle).Decode(val)
+}
+
+// FixedSizeCodec 按照小端序直接写入内存布局，只支持定长的值，e.g. int64、float64
+// 以及只包含定长字段的结构体，包含 string、切片、map 的值在 Marshal 时返回错误。
+// 实现了 encoding.BinaryMarshaler 的类型使用自身的编码。变长的值使用 MsgpackCodec
+type FixedSizeCodec struct{}
+
+func (FixedSizeCodec) Marshal(val any) ([]byte, error) {
+	if m, ok := val.(encoding.BinaryMarshaler); ok {
+		return m.MarshalBinary()
+	}
+	if binary.Size(val) < 0 {
+		return nil, fmt.Errorf("cache: FixedSizeCodec 不支持变长的类型 %T", val)
+	}
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, val); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (FixedSizeCodec) Unmarshal(data []byte, val any) error {
+	if u, ok := val.(encoding.BinaryUnmarshaler); ok {
+		return u.UnmarshalBinary(data)
+	}
+	if binary.Size(val) < 0 {
+		return fmt.Errorf("cache: FixedSizeCodec 不支持变长的类型 %T", val)
+	}
+	return binary.Read(bytes.NewReader(data), binary.LittleEndian, val)
+}
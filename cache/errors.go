@@ -0,0 +1,6 @@
+package cache
+
+import "Soil/cache/internal/errs"
+
+// ErrKeyNotFound 键不存在或者已经过期，使用 errors.Is 判断
+var ErrKeyNotFound = errs.ErrKeyNotFound
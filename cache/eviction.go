@@ -0,0 +1,64 @@
+package cache
+
+import "container/list"
+
+// EvictionPolicy BuildInMapCache 的淘汰策略，所有的方法都在 BuildInMapCache 持有写锁时调用，
+// 因此实现不需要考虑并发。size 为键值对占用的字节数，与 BuildInMapCache 的容量单位相同
+type EvictionPolicy interface {
+	// Add 写入新的键
+	Add(key string, size uint32)
+	// Update 覆盖写入已经存在的键，视为一次访问
+	Update(key string, size uint32)
+	// Access 读取命中
+	Access(key string)
+	// Remove 键被删除、过期或者淘汰
+	Remove(key string)
+	// Victim 容量不足时返回应该淘汰的键，BuildInMapCache 随后调用 Remove 删除这个键
+	Victim() (string, bool)
+}
+
+var _ EvictionPolicy = &lruPolicy{}
+
+// lruPolicy 淘汰最久没有访问的键
+type lruPolicy struct {
+	// list 从前往后为最近访问到最久没有访问，元素为键
+	list     *list.List
+	elements map[string]*list.Element
+}
+
+// NewLRUPolicy BuildInMapCache 默认的淘汰策略，实现简单，但是一次扫描大量冷数据就会把热点数据全部淘汰
+func NewLRUPolicy(capacity uint32) EvictionPolicy {
+	return &lruPolicy{
+		list:     list.New(),
+		elements: map[string]*list.Element{},
+	}
+}
+
+func (l *lruPolicy) Add(key string, size uint32) {
+	l.elements[key] = l.list.PushFront(key)
+}
+
+func (l *lruPolicy) Update(key string, size uint32) {
+	l.Access(key)
+}
+
+func (l *lruPolicy) Access(key string) {
+	if e, ok := l.elements[key]; ok {
+		l.list.MoveToFront(e)
+	}
+}
+
+func (l *lruPolicy) Remove(key string) {
+	if e, ok := l.elements[key]; ok {
+		l.list.Remove(e)
+		delete(l.elements, key)
+	}
+}
+
+func (l *lruPolicy) Victim() (string, bool) {
+	e := l.list.Back()
+	if e == nil {
+		return "", false
+	}
+	return e.Value.(string), true
+}
@@ -0,0 +1,143 @@
+package cache
+
+import "container/list"
+
+var _ EvictionPolicy = &arcPolicy{}
+
+const (
+	arcT1 = iota
+	arcT2
+	arcB1
+	arcB2
+)
+
+// arcPolicy Adaptive Replacement Cache。t1 保存只访问过一次的键，t2 保存访问过多次的键，
+// b1、b2 分别记录最近从 t1、t2 淘汰的键（只记录键，不占用缓存）。
+// 被淘汰的键再次写入时命中 b1 说明 t1 太小，命中 b2 说明 t2 太小，以此调整 t1 的目标大小 p。
+// 与原始的 ARC 不同，这里的大小按照字节计算
+type arcPolicy struct {
+	capacity uint64
+	// p t1 的目标大小
+	p     uint64
+	lists [4]*list.List
+	sizes [4]uint64
+	// entries 包括 b1、b2 中的键
+	entries map[string]*arcEntry
+}
+
+type arcEntry struct {
+	key  string
+	size uint64
+	list int
+	elem *list.Element
+}
+
+// NewARCPolicy 根据访问模式在 LRU 与 LFU 之间自动调整，能够抵抗一次性的扫描
+func NewARCPolicy(capacity uint32) EvictionPolicy {
+	return &arcPolicy{
+		capacity: uint64(capacity),
+		lists:    [4]*list.List{list.New(), list.New(), list.New(), list.New()},
+		entries:  map[string]*arcEntry{},
+	}
+}
+
+func (a *arcPolicy) Add(key string, size uint32) {
+	sz := uint64(size)
+	entry, ok := a.entries[key]
+	if !ok {
+		a.push(&arcEntry{key: key, size: sz}, arcT1)
+		a.trimGhosts()
+		return
+	}
+	// 命中 b1 增大 p，命中 b2 减小 p，调整的幅度与另一个幽灵列表的相对大小成正比
+	switch entry.list {
+	case arcB1:
+		delta := sz
+		if a.sizes[arcB1] > 0 && a.sizes[arcB2] > a.sizes[arcB1] {
+			delta = sz * a.sizes[arcB2] / a.sizes[arcB1]
+		}
+		a.p = min(a.capacity, a.p+delta)
+	case arcB2:
+		delta := sz
+		if a.sizes[arcB2] > 0 && a.sizes[arcB1] > a.sizes[arcB2] {
+			delta = sz * a.sizes[arcB1] / a.sizes[arcB2]
+		}
+		a.p -= min(a.p, delta)
+	}
+	a.unlink(entry)
+	entry.size = sz
+	a.push(entry, arcT2)
+	a.trimGhosts()
+}
+
+func (a *arcPolicy) Update(key string, size uint32) {
+	entry, ok := a.entries[key]
+	if !ok || entry.list > arcT2 {
+		return
+	}
+	a.sizes[entry.list] = a.sizes[entry.list] - entry.size + uint64(size)
+	entry.size = uint64(size)
+	a.Access(key)
+}
+
+func (a *arcPolicy) Access(key string) {
+	entry, ok := a.entries[key]
+	if !ok || entry.list > arcT2 {
+		return
+	}
+	a.unlink(entry)
+	a.push(entry, arcT2)
+}
+
+// Remove 被 Victim 选中的键已经移动到了幽灵列表，其它的键直接删除
+func (a *arcPolicy) Remove(key string) {
+	entry, ok := a.entries[key]
+	if !ok || entry.list > arcT2 {
+		return
+	}
+	a.unlink(entry)
+	delete(a.entries, key)
+}
+
+func (a *arcPolicy) Victim() (string, bool) {
+	from, ghost := arcT2, arcB2
+	if a.lists[arcT1].Len() > 0 && (a.sizes[arcT1] > a.p || a.lists[arcT2].Len() == 0) {
+		from, ghost = arcT1, arcB1
+	}
+	e := a.lists[from].Back()
+	if e == nil {
+		return "", false
+	}
+	entry := e.Value.(*arcEntry)
+	a.unlink(entry)
+	a.push(entry, ghost)
+	return entry.key, true
+}
+
+// trimGhosts 保证 t1+b1 不超过容量，四个列表之和不超过两倍的容量
+func (a *arcPolicy) trimGhosts() {
+	for a.sizes[arcT1]+a.sizes[arcB1] > a.capacity && a.lists[arcB1].Len() > 0 {
+		a.dropGhost(arcB1)
+	}
+	for a.sizes[arcT1]+a.sizes[arcT2]+a.sizes[arcB1]+a.sizes[arcB2] > 2*a.capacity && a.lists[arcB2].Len() > 0 {
+		a.dropGhost(arcB2)
+	}
+}
+
+func (a *arcPolicy) dropGhost(l int) {
+	entry := a.lists[l].Back().Value.(*arcEntry)
+	a.unlink(entry)
+	delete(a.entries, entry.key)
+}
+
+func (a *arcPolicy) push(entry *arcEntry, l int) {
+	entry.list = l
+	entry.elem = a.lists[l].PushFront(entry)
+	a.sizes[l] += entry.size
+	a.entries[entry.key] = entry
+}
+
+func (a *arcPolicy) unlink(entry *arcEntry) {
+	a.lists[entry.list].Remove(entry.elem)
+	a.sizes[entry.list] -= entry.size
+}
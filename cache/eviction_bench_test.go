@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 运行方式：
+//
+//	go test -run ^$ -bench BenchmarkEvictionPolicy ./cache
+//
+// 结果中的 hit% 为命中率。
+//
+// zipf、zipf-scan、loop 是生成的访问序列，它们的结果只说明各个淘汰策略在这几种分布上的差异。
+// 记录下来的访问日志（每行一个键）放在 testdata/*.trace，或者通过环境变量 CACHE_TRACE 指定，
+// 它们会作为额外的访问序列参与比较，缓存容量为日志中不同键个数的 1/10。
+// testdata 中日志的来源见 testdata/README.md，它们不是业务流量，不能代替业务流量上的比较
+
+const benchCapacity = 1000 * 32
+
+var benchPolicies = []struct {
+	name      string
+	newPolicy func(capacity uint32) EvictionPolicy
+}{
+	{name: "lru", newPolicy: NewLRUPolicy},
+	{name: "lfu", newPolicy: NewLFUPolicy},
+	{name: "arc", newPolicy: NewARCPolicy},
+	{name: "w-tinylfu", newPolicy: NewWTinyLFUPolicy},
+}
+
+type benchTrace struct {
+	name     string
+	keys     []string
+	capacity uint32
+}
+
+func benchTraces(b *testing.B) []benchTrace {
+	r := rand.New(rand.NewSource(1))
+	traces := []benchTrace{
+		{name: "zipf", keys: zipfTrace(r, 100000, 0), capacity: benchCapacity},
+		// 热点数据中夹杂一次性的扫描
+		{name: "zipf-scan", keys: zipfTrace(r, 100000, 2000), capacity: benchCapacity},
+		{name: "loop", keys: loopTrace(100000, 1200), capacity: benchCapacity},
+	}
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.trace"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	if path := os.Getenv("CACHE_TRACE"); path != "" {
+		paths = append(paths, path)
+	}
+	for _, path := range paths {
+		keys, err := readTrace(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		traces = append(traces, benchTrace{name: "recorded-" + name, keys: keys, capacity: traceCapacity(keys)})
+	}
+	return traces
+}
+
+// zipfTrace 每访问 10000 次插入 scan 个只访问一次的键
+func zipfTrace(r *rand.Rand, n int, scan int) []string {
+	zipf := rand.NewZipf(r, 1.1, 1, 100000)
+	keys := make([]string, 0, n)
+	scanned := 0
+	for i := 0; i < n; i++ {
+		keys = append(keys, "k"+strconv.FormatUint(zipf.Uint64(), 10))
+		if scan > 0 && i%10000 == 0 {
+			for j := 0; j < scan; j++ {
+				keys = append(keys, "s"+strconv.Itoa(scanned))
+				scanned++
+			}
+		}
+	}
+	return keys
+}
+
+// loopTrace 循环访问略多于缓存容量的键，LRU 在这种情况下一次都不会命中
+func loopTrace(n int, distinct int) []string {
+	keys := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		keys = append(keys, "k"+strconv.Itoa(i%distinct))
+	}
+	return keys
+}
+
+func readTrace(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	var keys []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if key := scanner.Text(); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys, scanner.Err()
+}
+
+// traceCapacity 记录的日志大小不一，容量能放下所有的键时命中率与淘汰策略无关，
+// 因此按照不同键的个数的 1/10 设置容量，每个键值对按 32 字节估算
+func traceCapacity(keys []string) uint32 {
+	distinct := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		distinct[key] = struct{}{}
+	}
+	return uint32(max(len(distinct)/10, 1) * 32)
+}
+
+// replay 未命中时写入缓存，返回命中率
+func replay(keys []string, capacity uint32, newPolicy func(capacity uint32) EvictionPolicy) float64 {
+	ctx := context.Background()
+	cache := NewBuildInMapCache(time.Hour, capacity, BuildInMapCacheWithEvictionPolicy(newPolicy))
+	defer func() { _ = cache.Close() }()
+	for _, key := range keys {
+		if _, err := cache.Get(ctx, key); err != nil {
+			_ = cache.Set(ctx, key, 1, 0)
+		}
+	}
+	return cache.Stats().HitRatio()
+}
+
+func BenchmarkEvictionPolicy_HitRatio(b *testing.B) {
+	for _, trace := range benchTraces(b) {
+		for _, policy := range benchPolicies {
+			b.Run(trace.name+"/"+policy.name, func(b *testing.B) {
+				var ratio float64
+				for i := 0; i < b.N; i++ {
+					ratio = replay(trace.keys, trace.capacity, policy.newPolicy)
+				}
+				b.ReportMetric(ratio*100, "hit%")
+			})
+		}
+	}
+}
+
+func BenchmarkEvictionPolicy_GetSet(b *testing.B) {
+	keys := zipfTrace(rand.New(rand.NewSource(1)), 100000, 0)
+	for _, policy := range benchPolicies {
+		b.Run(policy.name, func(b *testing.B) {
+			ctx := context.Background()
+			cache := NewBuildInMapCache(time.Hour, benchCapacity, BuildInMapCacheWithEvictionPolicy(policy.newPolicy))
+			defer func() { _ = cache.Close() }()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				key := keys[i%len(keys)]
+				if _, err := cache.Get(ctx, key); err != nil {
+					_ = cache.Set(ctx, key, 1, 0)
+				}
+			}
+		})
+	}
+}
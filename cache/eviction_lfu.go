@@ -0,0 +1,86 @@
+package cache
+
+import "container/list"
+
+var _ EvictionPolicy = &lfuPolicy{}
+
+// lfuPolicy 淘汰访问次数最少的键，次数相同时淘汰最久没有访问的键。
+// 所有访问次数相同的键放在同一个桶中，桶按照访问次数从小到大排列，所有的操作都是 O(1)
+type lfuPolicy struct {
+	// buckets 元素为 *lfuBucket
+	buckets *list.List
+	entries map[string]*lfuEntry
+}
+
+type lfuBucket struct {
+	freq uint64
+	// keys 从前往后为最近访问到最久没有访问，元素为键
+	keys *list.List
+}
+
+type lfuEntry struct {
+	bucket *list.Element
+	elem   *list.Element
+}
+
+// NewLFUPolicy 适合热点集中且稳定的场景。曾经很热的键即使不再访问也很难被淘汰
+func NewLFUPolicy(capacity uint32) EvictionPolicy {
+	return &lfuPolicy{
+		buckets: list.New(),
+		entries: map[string]*lfuEntry{},
+	}
+}
+
+func (l *lfuPolicy) Add(key string, size uint32) {
+	front := l.buckets.Front()
+	if front == nil || front.Value.(*lfuBucket).freq != 1 {
+		front = l.buckets.PushFront(&lfuBucket{freq: 1, keys: list.New()})
+	}
+	l.entries[key] = &lfuEntry{
+		bucket: front,
+		elem:   front.Value.(*lfuBucket).keys.PushFront(key),
+	}
+}
+
+func (l *lfuPolicy) Update(key string, size uint32) {
+	l.Access(key)
+}
+
+func (l *lfuPolicy) Access(key string) {
+	entry, ok := l.entries[key]
+	if !ok {
+		return
+	}
+	cur := entry.bucket.Value.(*lfuBucket)
+	next := entry.bucket.Next()
+	if next == nil || next.Value.(*lfuBucket).freq != cur.freq+1 {
+		next = l.buckets.InsertAfter(&lfuBucket{freq: cur.freq + 1, keys: list.New()}, entry.bucket)
+	}
+	l.removeFromBucket(entry)
+	entry.bucket = next
+	entry.elem = next.Value.(*lfuBucket).keys.PushFront(key)
+}
+
+func (l *lfuPolicy) Remove(key string) {
+	if entry, ok := l.entries[key]; ok {
+		l.removeFromBucket(entry)
+		delete(l.entries, key)
+	}
+}
+
+func (l *lfuPolicy) Victim() (string, bool) {
+	front := l.buckets.Front()
+	if front == nil {
+		return "", false
+	}
+	return front.Value.(*lfuBucket).keys.Back().Value.(string), true
+}
+
+// removeFromBucket 桶为空时删除桶
+func (l *lfuPolicy) removeFromBucket(entry *lfuEntry) {
+	bucket := entry.bucket.Value.(*lfuBucket)
+	bucket.keys.Remove(entry.elem)
+	if bucket.keys.Len() == 0 {
+		l.buckets.Remove(entry.bucket)
+	}
+}
@@ -0,0 +1,211 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUPolicy(t *testing.T) {
+	p := NewLRUPolicy(3)
+	for _, key := range []string{"a", "b", "c"} {
+		p.Add(key, 1)
+	}
+	p.Access("a")
+	victim, ok := p.Victim()
+	assert.True(t, ok)
+	assert.Equal(t, "b", victim)
+
+	p.Remove("b")
+	p.Update("c", 1)
+	victim, _ = p.Victim()
+	assert.Equal(t, "a", victim)
+}
+
+func TestLFUPolicy(t *testing.T) {
+	p := NewLFUPolicy(3)
+	for _, key := range []string{"a", "b", "c"} {
+		p.Add(key, 1)
+	}
+	p.Access("a")
+	p.Access("a")
+	p.Access("c")
+	victim, ok := p.Victim()
+	assert.True(t, ok)
+	assert.Equal(t, "b", victim)
+
+	p.Remove("b")
+	victim, _ = p.Victim()
+	assert.Equal(t, "c", victim)
+
+	// 访问次数相同时淘汰最久没有访问的键
+	p.Access("c")
+	victim, _ = p.Victim()
+	assert.Equal(t, "a", victim)
+
+	p.Remove("a")
+	p.Remove("c")
+	_, ok = p.Victim()
+	assert.False(t, ok)
+}
+
+func TestARCPolicy(t *testing.T) {
+	p := NewARCPolicy(4).(*arcPolicy)
+	for _, key := range []string{"a", "b", "c", "d"} {
+		p.Add(key, 1)
+	}
+	p.Access("a")
+	p.Access("b")
+
+	// 只访问过一次的键优先被淘汰，被淘汰的键进入 b1
+	victim, ok := p.Victim()
+	assert.True(t, ok)
+	assert.Equal(t, "c", victim)
+	p.Remove("c")
+	assert.Equal(t, arcB1, p.entries["c"].list)
+
+	// 命中 b1 说明 t1 太小，增大 p 并且直接进入 t2
+	p.Add("c", 1)
+	assert.Equal(t, uint64(1), p.p)
+	assert.Equal(t, arcT2, p.entries["c"].list)
+
+	// 直接删除的键不进入幽灵列表
+	p.Remove("d")
+	_, ok = p.entries["d"]
+	assert.False(t, ok)
+}
+
+func TestWTinyLFUPolicy(t *testing.T) {
+	p := NewWTinyLFUPolicy(100).(*wTinyLFUPolicy)
+	p.Add("hot", 1)
+	for i := 0; i < 5; i++ {
+		p.Access("hot")
+	}
+	// hot 被挤出 window 之后成为候选者
+	p.Add("cold1", 1)
+	assert.Equal(t, tinyLFUProbation, p.entries["hot"].list)
+	assert.Equal(t, p.entries["hot"], p.candidate)
+	// 再次访问进入 protected
+	p.Access("hot")
+	assert.Equal(t, tinyLFUProtected, p.entries["hot"].list)
+	assert.Nil(t, p.candidate)
+
+	// 频率不高于 probation 中的 victim 的候选者被拒绝
+	p.Add("cold2", 1)
+	p.Access("cold1")
+	p.Access("cold1")
+	p.Add("cold3", 1)
+	p.Add("cold4", 1)
+	victim, ok := p.Victim()
+	assert.True(t, ok)
+	assert.Equal(t, "cold3", victim)
+}
+
+func TestCountMinSketch(t *testing.T) {
+	s := newCountMinSketch(16)
+	for i := 0; i < 20; i++ {
+		s.increment("hot")
+	}
+	s.increment("cold")
+	// 计数器最大为 15
+	assert.Equal(t, uint8(15), s.estimate("hot"))
+	assert.True(t, s.estimate("cold") >= 1)
+	assert.Equal(t, uint8(0), s.estimate("never"))
+
+	// 累计增加 160 次之后计数器减半
+	for i := 0; i < 139; i++ {
+		s.increment(fmt.Sprintf("key%d", i%4))
+	}
+	assert.True(t, s.estimate("hot") <= 8, "estimate: %d", s.estimate("hot"))
+}
+
+func TestBuildInMapCache_EvictionPolicy(t *testing.T) {
+	testCases := []struct {
+		name      string
+		newPolicy func(capacity uint32) EvictionPolicy
+		// wantHotKeys 一次扫描之后热点数据是否保留
+		wantHotKeys bool
+	}{
+		{
+			name:      "lru",
+			newPolicy: NewLRUPolicy,
+		},
+		{
+			name:        "lfu",
+			newPolicy:   NewLFUPolicy,
+			wantHotKeys: true,
+		},
+		{
+			name:        "arc",
+			newPolicy:   NewARCPolicy,
+			wantHotKeys: true,
+		},
+		{
+			name:        "w-tinylfu",
+			newPolicy:   NewWTinyLFUPolicy,
+			wantHotKeys: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			// 大约可以放下 10 个键值对
+			cache := NewBuildInMapCache(time.Minute, 300, BuildInMapCacheWithEvictionPolicy(tc.newPolicy))
+			defer func() { _ = cache.Close() }()
+
+			for i := 0; i < 5; i++ {
+				require.NoError(t, cache.Set(ctx, fmt.Sprintf("hot%d", i), i, 0))
+			}
+			for j := 0; j < 3; j++ {
+				for i := 0; i < 5; i++ {
+					_, err := cache.Get(ctx, fmt.Sprintf("hot%d", i))
+					require.NoError(t, err)
+				}
+			}
+			// 扫描大量只访问一次的冷数据
+			for i := 0; i < 30; i++ {
+				require.NoError(t, cache.Set(ctx, fmt.Sprintf("cold%02d", i), i, 0))
+			}
+			assert.True(t, cache.size <= cache.capacity)
+
+			for i := 0; i < 5; i++ {
+				_, err := cache.Get(ctx, fmt.Sprintf("hot%d", i))
+				assert.Equal(t, tc.wantHotKeys, err == nil, "hot%d err: %v", i, err)
+			}
+		})
+	}
+}
+
+func TestBuildInMapCache_Stats(t *testing.T) {
+	ctx := context.Background()
+	var evicted []string
+	cache := NewBuildInMapCache(time.Minute, 60, BuildInMapCacheWithEvictedCallback(func(k string, v any) {
+		evicted = append(evicted, k)
+	}))
+	defer func() { _ = cache.Close() }()
+	assert.Equal(t, float64(0), cache.Stats().HitRatio())
+
+	// 每个键值对占用 26 个字节，只能放下两个
+	for _, key := range []string{"k1", "k2", "k3"} {
+		require.NoError(t, cache.Set(ctx, key, 1, 0))
+	}
+	_, err := cache.Get(ctx, "k1")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+	_, err = cache.Get(ctx, "k3")
+	require.NoError(t, err)
+	_, err = cache.MGet(ctx, "k2", "k4")
+	require.NoError(t, err)
+	require.NoError(t, cache.Delete(ctx, "k2"))
+
+	stats := cache.Stats()
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 2, Evictions: 1}, stats)
+	assert.Equal(t, 0.5, stats.HitRatio())
+	// 淘汰和删除都会调用 onEvicted
+	assert.Equal(t, []string{"k1", "k2"}, evicted)
+}
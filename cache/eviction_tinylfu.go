@@ -0,0 +1,193 @@
+package cache
+
+import (
+	"container/list"
+	"math/bits"
+)
+
+var _ EvictionPolicy = &wTinyLFUPolicy{}
+
+const (
+	tinyLFUWindow = iota
+	tinyLFUProbation
+	tinyLFUProtected
+)
+
+// wTinyLFUPolicy W-TinyLFU。新写入的键先进入占容量 1% 的 window（LRU），
+// 从 window 淘汰的键成为候选者进入 main 的 probation 段，
+// 之后需要淘汰时，候选者与 probation 中最久没有访问的键比较 count-min sketch 估计的访问频率，
+// 频率低的被淘汰，因此一次性扫描的冷数据很难挤掉热点数据。
+// probation 中的键再次被访问时进入占 main 80% 的 protected 段
+type wTinyLFUPolicy struct {
+	windowCapacity    uint64
+	protectedCapacity uint64
+	lists             [3]*list.List
+	sizes             [3]uint64
+	entries           map[string]*tinyLFUEntry
+	// candidate 最近从 window 进入 probation、还没有经过比较的键
+	candidate *tinyLFUEntry
+	sketch    *countMinSketch
+}
+
+type tinyLFUEntry struct {
+	key  string
+	size uint64
+	list int
+	elem *list.Element
+}
+
+// NewWTinyLFUPolicy 适合热点集中同时夹杂大量扫描的场景，命中率一般高于 LRU、LFU 和 ARC
+func NewWTinyLFUPolicy(capacity uint32) EvictionPolicy {
+	c := uint64(capacity)
+	window := max(c/100, 1)
+	return &wTinyLFUPolicy{
+		windowCapacity:    window,
+		protectedCapacity: (c - min(window, c)) * 8 / 10,
+		lists:             [3]*list.List{list.New(), list.New(), list.New()},
+		entries:           map[string]*tinyLFUEntry{},
+		// 假设平均每个键值对占用 32 个字节
+		sketch: newCountMinSketch(c / 32),
+	}
+}
+
+func (w *wTinyLFUPolicy) Add(key string, size uint32) {
+	w.sketch.increment(key)
+	w.push(&tinyLFUEntry{key: key, size: uint64(size)}, tinyLFUWindow)
+	// window 超出容量时，最久没有访问的键进入 probation 成为候选者
+	for w.sizes[tinyLFUWindow] > w.windowCapacity && w.lists[tinyLFUWindow].Len() > 1 {
+		entry := w.lists[tinyLFUWindow].Back().Value.(*tinyLFUEntry)
+		w.move(entry, tinyLFUProbation)
+		w.candidate = entry
+	}
+}
+
+func (w *wTinyLFUPolicy) Update(key string, size uint32) {
+	entry, ok := w.entries[key]
+	if !ok {
+		return
+	}
+	w.sizes[entry.list] = w.sizes[entry.list] - entry.size + uint64(size)
+	entry.size = uint64(size)
+	w.Access(key)
+}
+
+func (w *wTinyLFUPolicy) Access(key string) {
+	w.sketch.increment(key)
+	entry, ok := w.entries[key]
+	if !ok {
+		return
+	}
+	switch entry.list {
+	case tinyLFUWindow, tinyLFUProtected:
+		w.lists[entry.list].MoveToFront(entry.elem)
+	case tinyLFUProbation:
+		if w.candidate == entry {
+			w.candidate = nil
+		}
+		w.move(entry, tinyLFUProtected)
+		// protected 超出容量时，最久没有访问的键降级到 probation
+		for w.sizes[tinyLFUProtected] > w.protectedCapacity && w.lists[tinyLFUProtected].Len() > 1 {
+			w.move(w.lists[tinyLFUProtected].Back().Value.(*tinyLFUEntry), tinyLFUProbation)
+		}
+	}
+}
+
+func (w *wTinyLFUPolicy) Remove(key string) {
+	entry, ok := w.entries[key]
+	if !ok {
+		return
+	}
+	if w.candidate == entry {
+		w.candidate = nil
+	}
+	w.lists[entry.list].Remove(entry.elem)
+	w.sizes[entry.list] -= entry.size
+	delete(w.entries, key)
+}
+
+func (w *wTinyLFUPolicy) Victim() (string, bool) {
+	var victim *tinyLFUEntry
+	for _, l := range []int{tinyLFUProbation, tinyLFUProtected, tinyLFUWindow} {
+		if e := w.lists[l].Back(); e != nil {
+			victim = e.Value.(*tinyLFUEntry)
+			break
+		}
+	}
+	if victim == nil {
+		return "", false
+	}
+	// 候选者的频率不高于 victim 时拒绝候选者
+	if candidate := w.candidate; candidate != nil && candidate != victim &&
+		w.sketch.estimate(candidate.key) <= w.sketch.estimate(victim.key) {
+		return candidate.key, true
+	}
+	return victim.key, true
+}
+
+func (w *wTinyLFUPolicy) push(entry *tinyLFUEntry, l int) {
+	entry.list = l
+	entry.elem = w.lists[l].PushFront(entry)
+	w.sizes[l] += entry.size
+	w.entries[entry.key] = entry
+}
+
+func (w *wTinyLFUPolicy) move(entry *tinyLFUEntry, l int) {
+	w.lists[entry.list].Remove(entry.elem)
+	w.sizes[entry.list] -= entry.size
+	w.push(entry, l)
+}
+
+// countMinSketch 使用 4 行计数器估计键的访问频率，估计值只会偏大不会偏小。
+// 计数器最大为 15，累计增加 10 倍宽度次之后所有计数器减半，使得过去的热点数据逐渐冷却
+type countMinSketch struct {
+	rows [4][]uint8
+	mask uint64
+	// additions 上一次减半之后增加的次数
+	additions  uint64
+	sampleSize uint64
+}
+
+func newCountMinSketch(width uint64) *countMinSketch {
+	// 宽度取 2 的幂，便于使用位运算取模
+	width = 1 << bits.Len64(min(max(width, 16), 1<<22)-1)
+	s := &countMinSketch{
+		mask:       width - 1,
+		sampleSize: 10 * width,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+func (s *countMinSketch) increment(key string) {
+	h1, h2 := doubleHash(key)
+	for i := range s.rows {
+		idx := (h1 + uint64(i)*h2) & s.mask
+		if s.rows[i][idx] < 15 {
+			s.rows[i][idx]++
+		}
+	}
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.reset()
+	}
+}
+
+func (s *countMinSketch) estimate(key string) uint8 {
+	h1, h2 := doubleHash(key)
+	res := uint8(15)
+	for i := range s.rows {
+		res = min(res, s.rows[i][(h1+uint64(i)*h2)&s.mask])
+	}
+	return res
+}
+
+func (s *countMinSketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
@@ -3,7 +3,10 @@ package cache
 import (
 	"Soil/cache/internal/errs"
 	"context"
+	"fmt"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,34 +15,31 @@ type item struct {
 	value    any
 	deadline time.Time
 	size     uint32
-	prev     *item
-	next     *item
 }
 
 func (i *item) deadlineBefore(t time.Time) bool {
 	return !i.deadline.IsZero() && i.deadline.Before(t)
 }
 
-func initItem(key string, value any) *item {
-	return &item{
-		key:   key,
-		value: value,
-	}
-}
-
 type BuildInMapCache struct {
 	data     map[string]*item
 	rwMutex  sync.RWMutex
 	close    chan struct{}
-	head     *item
-	tail     *item
 	size     uint32
 	capacity uint32
+	// policy 容量不足时决定淘汰哪个键，默认为 LRU
+	policy EvictionPolicy
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
 
 	// onEvicted 实现CDC(change data capture), 将数据的修改结果捕获
 	onEvicted func(k string, v any)
 }
 
+var _ BatchCache = &BuildInMapCache{}
+
 type BuildInMapCacheOption func(cache *BuildInMapCache)
 
 // NewBuildInMapCache capacity指的是设置的内存大小，单位是字节
@@ -48,15 +48,10 @@ func NewBuildInMapCache(interval time.Duration, capacity uint32, ops ...BuildInM
 		data:      make(map[string]*item, 100),
 		close:     make(chan struct{}),
 		capacity:  capacity,
-		head:      initItem("head", nil),
-		tail:      initItem("tail", nil),
+		policy:    NewLRUPolicy(capacity),
 		onEvicted: func(k string, v any) {},
 	}
 
-	// 初始化双向链表
-	res.head.next = res.tail
-	res.tail.prev = res.head
-
 	for _, op := range ops {
 		op(res)
 	}
@@ -91,12 +86,25 @@ func NewBuildInMapCache(interval time.Duration, capacity uint32, ops ...BuildInM
 	return res
 }
 
+// BuildInMapCacheWithEvictionPolicy newPolicy 为淘汰策略的构造函数，e.g.
+//
+//	NewBuildInMapCache(time.Minute, 1<<20, BuildInMapCacheWithEvictionPolicy(NewWTinyLFUPolicy))
+func BuildInMapCacheWithEvictionPolicy(newPolicy func(capacity uint32) EvictionPolicy) BuildInMapCacheOption {
+	return func(cache *BuildInMapCache) {
+		cache.policy = newPolicy(cache.capacity)
+	}
+}
+
 // Set expiration如果为0表示不设置超时时间
 func (b *BuildInMapCache) Set(ctx context.Context, key string, val any,
 	expiration time.Duration) error {
 	b.rwMutex.Lock()
 	defer b.rwMutex.Unlock()
+	return b.setLocked(key, val, expiration)
+}
 
+// setLocked 调用方需要持有写锁
+func (b *BuildInMapCache) setLocked(key string, val any, expiration time.Duration) error {
 	keySize, err := Of(key)
 	if err != nil {
 		return err
@@ -108,99 +116,61 @@ func (b *BuildInMapCache) Set(ctx context.Context, key string, val any,
 	}
 
 	pairSize := keySize + valSize
-	// 先判断缓冲中有没有node
+	var dl time.Time
+	if expiration > 0 {
+		dl = time.Now().Add(expiration)
+	}
+
+	// 缓存中已经有node，覆盖写入视为一次访问
 	node, ok := b.data[key]
 	if ok {
-		// 缓存已经有node，只需要移动node双向链表最前面
-		node.value = val
-		b.moveToHead(node)
-		err := b.set(key, val, expiration, pairSize)
-		if err != nil {
-			return err
+		b.size -= node.size
+	}
+	// 容量不足时按照淘汰策略删除缓存，直到能够放下新的键值对
+	for b.size+pairSize > b.capacity {
+		victim, ok := b.policy.Victim()
+		if !ok {
+			break
 		}
-	} else {
-		// 判断缓存满没满
-		if b.size+uint32(pairSize) > b.capacity {
-			// 满了就删除缓存
-			margin := b.capacity - b.size
-			// 从LRU队列从后往前遍历知道margin > pairSize
-			for p := b.tail.prev; margin < uint32(pairSize); p = p.prev {
-				margin += p.size
-				n := b.removeTail()
-				delete(b.data, n.key)
-				b.size -= p.size
-			}
-		}
-		// 没满就直接加入
-		err = b.set(key, val, expiration, pairSize)
-		if err != nil {
-			return err
+		if victim == key {
+			// 被覆盖的键本身被选中时，相当于先删除再写入
+			b.size += node.size
+			b.evict(victim)
+			node = nil
+			continue
 		}
+		b.evict(victim)
 	}
 
-	return nil
-}
-
-func (b *BuildInMapCache) set(key string, val any, expiration time.Duration, pairSize uint32) error {
-	var dl time.Time
-	if expiration > 0 {
-		dl = time.Now().Add(expiration)
+	if node != nil {
+		node.value, node.deadline, node.size = val, dl, pairSize
+		b.size += pairSize
+		b.policy.Update(key, pairSize)
+		return nil
 	}
-
-	node := &item{
+	b.data[key] = &item{
 		key:      key,
 		value:    val,
 		deadline: dl,
 		size:     pairSize,
 	}
-	b.data[key] = node
-	b.addToHead(b.data[key])
 	b.size += pairSize
+	b.policy.Add(key, pairSize)
 	return nil
 }
 
-// Get 在get数据时，如果数据过期会删除数据
+// Get 在get数据时，如果数据过期会删除数据。
+// 命中时淘汰策略需要调整键的顺序或者频率，因此使用写锁
 func (b *BuildInMapCache) Get(ctx context.Context, key string) (any, error) {
-	/*b.rwMutex.RLock()
-	value, ok := b.data[key]
-	b.rwMutex.RUnlock()
-	// 这个方案不行，因为本来缓存过期了但是从这里到加写锁之间用户给重新set了expiration
-	// 那么就会删除这个缓存
-	if !ok {
-		return nil, errs.NewErrKeyNotFound(key)
-	}
-
 	b.rwMutex.Lock()
 	defer b.rwMutex.Unlock()
-	if !value.deadline.IsZero() && value.deadline.Before(time.Now()) {
-		delete(b.data, key)
-		return nil, errs.NewErrKeyNotFound(key)
-	}
-
-	return value.value, nil*/
-	b.rwMutex.RLock()
-	node, ok := b.data[key]
-	b.rwMutex.RUnlock()
+	node, ok := b.getLocked(key, time.Now())
 	if !ok {
+		b.misses.Add(1)
 		return nil, errs.NewErrKeyNotFound(key)
 	}
-	//这里采用double-check防止注释方案里的问题
-	now := time.Now()
-	if node.deadlineBefore(now) {
-		b.rwMutex.Lock()
-		defer b.rwMutex.Unlock()
-		node, ok = b.data[key]
-		if !ok {
-			return nil, errs.NewErrKeyNotFound(key)
-		}
-		if node.deadlineBefore(now) {
-			b.delete(key)
-			return nil, errs.NewErrKeyNotFound(key)
-		}
-		// 调整缓存顺序
-		b.moveToHead(node)
-	}
-
+	b.hits.Add(1)
+	b.policy.Access(key)
 	return node.value, nil
 }
 
@@ -222,9 +192,42 @@ func (b *BuildInMapCache) delete(key string) {
 		return
 	}
 	delete(b.data, key)
+	b.policy.Remove(key)
+	b.size -= i.size
 	b.onEvicted(key, i.value)
 }
 
+// evict 因为容量不足删除键
+func (b *BuildInMapCache) evict(key string) {
+	b.evictions.Add(1)
+	b.delete(key)
+}
+
+// CacheStats 从创建缓存开始的统计数据
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	// Evictions 因为容量不足被淘汰的键的个数，不包括过期和删除的键
+	Evictions uint64
+}
+
+// HitRatio 没有任何读取时返回 0
+func (s CacheStats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+func (b *BuildInMapCache) Stats() CacheStats {
+	return CacheStats{
+		Hits:      b.hits.Load(),
+		Misses:    b.misses.Load(),
+		Evictions: b.evictions.Load(),
+	}
+}
+
 func (b *BuildInMapCache) Close() error {
 	select {
 	case b.close <- struct{}{}:
@@ -241,24 +244,183 @@ func BuildInMapCacheWithEvictedCallback(onEvicted func(k string, v any)) BuildIn
 	}
 }
 
-func (b *BuildInMapCache) addToHead(node *item) {
-	node.next = b.head.next
-	node.prev = b.head
-	b.head.next.prev = node
-	b.head.next = node
+// chainEvictedCallback 通过 BuildInMapCacheWithEvictedCallback 注册回调，先调用已有的回调再调用 onEvicted，
+// 用于在创建之后的 BuildInMapCache 上追加回调，e.g. MultiLevelCache 的 L1
+func (b *BuildInMapCache) chainEvictedCallback(onEvicted func(k string, v any)) {
+	b.rwMutex.Lock()
+	defer b.rwMutex.Unlock()
+	prev := b.onEvicted
+	BuildInMapCacheWithEvictedCallback(func(k string, v any) {
+		prev(k, v)
+		onEvicted(k, v)
+	})(b)
+}
+
+// getLocked 调用方需要持有写锁，过期的缓存会被删除
+func (b *BuildInMapCache) getLocked(key string, now time.Time) (*item, bool) {
+	node, ok := b.data[key]
+	if !ok {
+		return nil, false
+	}
+	if node.deadlineBefore(now) {
+		b.delete(key)
+		return nil, false
+	}
+	return node, true
+}
+
+func (b *BuildInMapCache) MGet(ctx context.Context, keys ...string) (map[string]any, error) {
+	b.rwMutex.Lock()
+	defer b.rwMutex.Unlock()
+	now := time.Now()
+	res := make(map[string]any, len(keys))
+	for _, key := range keys {
+		node, ok := b.getLocked(key, now)
+		if !ok {
+			b.misses.Add(1)
+			continue
+		}
+		b.hits.Add(1)
+		b.policy.Access(key)
+		res[key] = node.value
+	}
+	return res, nil
 }
 
-func (b *BuildInMapCache) removeNode(node *item) {
-	node.prev.next = node.next
-	node.next.prev = node.prev
+func (b *BuildInMapCache) MSet(ctx context.Context, vals map[string]any, expiration time.Duration) error {
+	b.rwMutex.Lock()
+	defer b.rwMutex.Unlock()
+	for key, val := range vals {
+		if err := b.setLocked(key, val, expiration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *BuildInMapCache) MDelete(ctx context.Context, keys ...string) (int64, error) {
+	b.rwMutex.Lock()
+	defer b.rwMutex.Unlock()
+	now := time.Now()
+	var cnt int64
+	for _, key := range keys {
+		if _, ok := b.getLocked(key, now); ok {
+			b.delete(key)
+			cnt++
+		}
+	}
+	return cnt, nil
 }
 
-func (b *BuildInMapCache) moveToHead(node *item) {
-	b.removeNode(node)
-	b.addToHead(node)
+func (b *BuildInMapCache) SetNX(ctx context.Context, key string, val any, expiration time.Duration) (bool, error) {
+	b.rwMutex.Lock()
+	defer b.rwMutex.Unlock()
+	if _, ok := b.getLocked(key, time.Now()); ok {
+		return false, nil
+	}
+	if err := b.setLocked(key, val, expiration); err != nil {
+		return false, err
+	}
+	return true, nil
 }
-func (b *BuildInMapCache) removeTail() *item {
-	node := b.tail.prev
-	b.removeNode(node)
-	return node
+
+func (b *BuildInMapCache) GetSet(ctx context.Context, key string, val any) (any, error) {
+	b.rwMutex.Lock()
+	defer b.rwMutex.Unlock()
+	var old any
+	node, ok := b.getLocked(key, time.Now())
+	if ok {
+		// setLocked 会直接修改 node
+		old = node.value
+	}
+	if err := b.setLocked(key, val, 0); err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errs.NewErrKeyNotFound(key)
+	}
+	return old, nil
+}
+
+func (b *BuildInMapCache) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	b.rwMutex.Lock()
+	defer b.rwMutex.Unlock()
+	node, ok := b.getLocked(key, time.Now())
+	if !ok {
+		return delta, b.setLocked(key, delta, 0)
+	}
+	n, err := toInt64(node.value)
+	if err != nil {
+		return 0, errs.NewErrValueNotInteger(key)
+	}
+	n += delta
+	// 保留原有的过期时间
+	deadline := node.deadline
+	if err = b.setLocked(key, n, 0); err != nil {
+		return 0, err
+	}
+	b.data[key].deadline = deadline
+	return n, nil
+}
+
+func (b *BuildInMapCache) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return b.Incr(ctx, key, -delta)
+}
+
+func (b *BuildInMapCache) Expire(ctx context.Context, key string, expiration time.Duration) (bool, error) {
+	b.rwMutex.Lock()
+	defer b.rwMutex.Unlock()
+	now := time.Now()
+	node, ok := b.getLocked(key, now)
+	if !ok {
+		return false, nil
+	}
+	if expiration > 0 {
+		node.deadline = now.Add(expiration)
+	} else {
+		node.deadline = time.Time{}
+	}
+	return true, nil
+}
+
+func (b *BuildInMapCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	b.rwMutex.Lock()
+	defer b.rwMutex.Unlock()
+	now := time.Now()
+	node, ok := b.getLocked(key, now)
+	if !ok {
+		return 0, errs.NewErrKeyNotFound(key)
+	}
+	if node.deadline.IsZero() {
+		return NoExpiration, nil
+	}
+	return node.deadline.Sub(now), nil
+}
+
+// toInt64 与 redis 一样，整数形式的字符串也可以自增
+func toInt64(val any) (int64, error) {
+	switch v := val.(type) {
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	case []byte:
+		return strconv.ParseInt(string(v), 10, 64)
+	default:
+		return 0, fmt.Errorf("cache: %T 不是整数", val)
+	}
 }
@@ -2,18 +2,22 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"testing"
 	"time"
 )
 
 func traverse(cache *BuildInMapCache) {
-	for p := cache.head; p != cache.tail.next; p = p.next {
-		if p != cache.head {
+	lru := cache.policy.(*lruPolicy)
+	for e := lru.list.Front(); e != nil; e = e.Next() {
+		if e != lru.list.Front() {
 			fmt.Print(", ")
 		}
-		fmt.Print("<", p.key, ", ", p.value, ">")
+		key := e.Value.(string)
+		fmt.Print("<", key, ", ", cache.data[key].value, ">")
 	}
 	fmt.Println()
 }
@@ -38,3 +42,85 @@ func TestBuildInMapCache_Set(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, res, 2)
 }
+
+func TestBuildInMapCache_Batch(t *testing.T) {
+	ctx := context.Background()
+	cache := NewBuildInMapCache(time.Minute, 1024)
+	defer func() { _ = cache.Close() }()
+
+	require.NoError(t, cache.MSet(ctx, map[string]any{"k1": "v1", "k2": "v2", "k3": "v3"}, 0))
+	vals, err := cache.MGet(ctx, "k1", "k2", "k4")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"k1": "v1", "k2": "v2"}, vals)
+
+	n, err := cache.MDelete(ctx, "k1", "k4")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	_, err = cache.Get(ctx, "k1")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+
+	ok, err := cache.SetNX(ctx, "k2", "v", 0)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = cache.SetNX(ctx, "k5", "v5", 0)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	old, err := cache.GetSet(ctx, "k2", "new")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", old)
+	_, err = cache.GetSet(ctx, "k6", "v6")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+	val, err := cache.Get(ctx, "k6")
+	require.NoError(t, err)
+	assert.Equal(t, "v6", val)
+
+	// 数据量没有超过容量时不会淘汰，重复写入同一个键不会重复计算大小
+	assert.Equal(t, 4, len(cache.data))
+}
+
+func TestBuildInMapCache_Atomic(t *testing.T) {
+	ctx := context.Background()
+	cache := NewBuildInMapCache(time.Minute, 1024)
+	defer func() { _ = cache.Close() }()
+
+	n, err := cache.Incr(ctx, "cnt", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	require.NoError(t, cache.Set(ctx, "str", "10", time.Minute))
+	n, err = cache.Decr(ctx, "str", 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), n)
+	_, err = cache.Incr(ctx, "k", 1)
+	require.NoError(t, err)
+	require.NoError(t, cache.Set(ctx, "nan", "abc", 0))
+	_, err = cache.Incr(ctx, "nan", 1)
+	assert.Error(t, err)
+
+	// Incr 保留原有的过期时间
+	ttl, err := cache.TTL(ctx, "str")
+	require.NoError(t, err)
+	assert.True(t, ttl > 0 && ttl <= time.Minute, "ttl: %v", ttl)
+	ttl, err = cache.TTL(ctx, "cnt")
+	require.NoError(t, err)
+	assert.Equal(t, NoExpiration, ttl)
+	_, err = cache.TTL(ctx, "missing")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+
+	ok, err := cache.Expire(ctx, "str", 0)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ttl, err = cache.TTL(ctx, "str")
+	require.NoError(t, err)
+	assert.Equal(t, NoExpiration, ttl)
+	ok, err = cache.Expire(ctx, "missing", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = cache.Expire(ctx, "cnt", time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	time.Sleep(5 * time.Millisecond)
+	_, err = cache.Get(ctx, "cnt")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+}
@@ -0,0 +1,10 @@
+-- ARGV[1] 为过期时间（毫秒），0 表示不过期，KEYS[i] 的值为 ARGV[i + 1]
+local expiration = tonumber(ARGV[1])
+for i = 1, #KEYS do
+    if expiration > 0 then
+        redis.call("SET", KEYS[i], ARGV[i + 1], "PX", expiration)
+    else
+        redis.call("SET", KEYS[i], ARGV[i + 1])
+    end
+end
+return "OK"
@@ -0,0 +1,177 @@
+package cache
+
+import (
+	"Soil/cache/internal/errs"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var _ Cache = &MultiLevelCache{}
+
+// MultiLevelCache 二级缓存，L1 为进程内的 BuildInMapCache，L2 一般为 RedisCache。
+// 读取时依次查询 L1、L2 和 LoadFunc，写入和删除时同时更新两级缓存，
+// 并通过 Broadcaster 通知其它节点删除 L1 中的键。
+//
+// NewMultiLevelCache 通过 BuildInMapCacheWithEvictedCallback 在 L1 上注册回调，
+// 其它节点的失效消息、本节点的 Delete、容量淘汰和过期从 L1 中删除键时都会经过该回调，
+// 回调中依次调用 L1 上原有的回调和 MultiLevelCacheWithEvictedCallback 设置的回调。
+//
+// L1 中保存的是 L2 返回的值，RedisCache 返回的是 string，
+// 需要保存结构体时在 MultiLevelCache 上使用 TypedCache，两级缓存中保存的都是编码之后的数据
+type MultiLevelCache struct {
+	l1 *BuildInMapCache
+	l2 Cache
+	// LoadFunc 两级缓存都没有命中时从数据库中加载，数据不存在时返回 ErrKeyNotFound。
+	// 为 nil 时直接返回 ErrKeyNotFound
+	LoadFunc func(ctx context.Context, key string) (any, error)
+
+	broadcaster Broadcaster
+	node        string
+	// l1Expiration L1 中缓存的过期时间，失效消息丢失时兜底
+	l1Expiration time.Duration
+	// expiration LoadFunc 加载的数据写入 L2 的过期时间
+	expiration time.Duration
+	// onEvicted L1 中的键被删除时调用
+	onEvicted func(key string, val any)
+	cancel    context.CancelFunc
+}
+
+type MultiLevelCacheOption func(c *MultiLevelCache)
+
+// NewMultiLevelCache 订阅 Broadcaster 中的失效消息，不再使用时需要调用 Close 取消订阅
+func NewMultiLevelCache(l1 *BuildInMapCache, l2 Cache, broadcaster Broadcaster,
+	opts ...MultiLevelCacheOption) (*MultiLevelCache, error) {
+	res := &MultiLevelCache{
+		l1:           l1,
+		l2:           l2,
+		broadcaster:  broadcaster,
+		node:         uuid.NewString(),
+		l1Expiration: time.Minute,
+		expiration:   10 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(res)
+	}
+	l1.chainEvictedCallback(res.onL1Evicted)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := broadcaster.Subscribe(ctx, res.onInvalidate); err != nil {
+		cancel()
+		return nil, err
+	}
+	res.cancel = cancel
+	return res, nil
+}
+
+// MultiLevelCacheWithL1Expiration 默认为一分钟，写入 L1 的过期时间不会超过写入 L2 的过期时间
+func MultiLevelCacheWithL1Expiration(expiration time.Duration) MultiLevelCacheOption {
+	return func(c *MultiLevelCache) {
+		c.l1Expiration = expiration
+	}
+}
+
+// MultiLevelCacheWithEvictedCallback L1 中的键被删除时调用，包括其它节点的失效消息删除的键
+func MultiLevelCacheWithEvictedCallback(onEvicted func(key string, val any)) MultiLevelCacheOption {
+	return func(c *MultiLevelCache) {
+		c.onEvicted = onEvicted
+	}
+}
+
+// MultiLevelCacheWithLoadFunc expiration 为加载的数据写入 L2 的过期时间
+func MultiLevelCacheWithLoadFunc(loadFunc func(ctx context.Context, key string) (any, error),
+	expiration time.Duration) MultiLevelCacheOption {
+	return func(c *MultiLevelCache) {
+		c.LoadFunc = loadFunc
+		c.expiration = expiration
+	}
+}
+
+func (m *MultiLevelCache) Get(ctx context.Context, key string) (any, error) {
+	val, err := m.l1.Get(ctx, key)
+	if !errors.Is(err, errs.ErrKeyNotFound) {
+		return val, err
+	}
+	val, err = m.l2.Get(ctx, key)
+	if err == nil {
+		return val, m.setL1(ctx, key, val, m.l1Expiration)
+	}
+	if !errors.Is(err, errs.ErrKeyNotFound) || m.LoadFunc == nil {
+		return nil, err
+	}
+	val, err = m.LoadFunc(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if err = m.l2.Set(ctx, key, val, m.expiration); err != nil {
+		return val, fmt.Errorf("%w, 原因：%s", errs.ErrFailedToRefreshCache, err.Error())
+	}
+	return val, m.setL1(ctx, key, val, m.expiration)
+}
+
+// Set 先写入 L2 再写入 L1，之后通知其它节点删除 L1 中的旧值
+func (m *MultiLevelCache) Set(ctx context.Context, key string, val any, expiration time.Duration) error {
+	if err := m.l2.Set(ctx, key, val, expiration); err != nil {
+		return err
+	}
+	if err := m.setL1(ctx, key, val, expiration); err != nil {
+		return err
+	}
+	return m.publish(ctx, key)
+}
+
+// Delete 键在两级缓存中都不存在时返回 ErrKeyNotFound
+func (m *MultiLevelCache) Delete(ctx context.Context, key string) error {
+	l2Err := m.l2.Delete(ctx, key)
+	if l2Err != nil && !errors.Is(l2Err, errs.ErrKeyNotFound) {
+		return l2Err
+	}
+	l1Err := m.l1.Delete(ctx, key)
+	if err := m.publish(ctx, key); err != nil {
+		return err
+	}
+	if l2Err != nil && l1Err != nil {
+		return errs.NewErrKeyNotFound(key)
+	}
+	return nil
+}
+
+// Close 取消订阅，不会关闭 L1 和 L2
+func (m *MultiLevelCache) Close() error {
+	m.cancel()
+	return nil
+}
+
+// setL1 expiration 为写入 L2 的过期时间，L1 的过期时间不会超过 L2
+func (m *MultiLevelCache) setL1(ctx context.Context, key string, val any, expiration time.Duration) error {
+	if expiration <= 0 || (m.l1Expiration > 0 && m.l1Expiration < expiration) {
+		expiration = m.l1Expiration
+	}
+	return m.l1.Set(ctx, key, val, expiration)
+}
+
+func (m *MultiLevelCache) publish(ctx context.Context, key string) error {
+	return m.broadcaster.Publish(ctx, InvalidateMessage{Node: m.node, Key: key})
+}
+
+// onL1Evicted 注册在 L1 上的回调，调用时持有 L1 的锁，不能再访问 L1
+func (m *MultiLevelCache) onL1Evicted(key string, val any) {
+	if m.onEvicted != nil {
+		m.onEvicted(key, val)
+	}
+}
+
+// onInvalidate 从 L1 中删除其它节点修改的键，删除时会调用 onL1Evicted
+func (m *MultiLevelCache) onInvalidate(msg InvalidateMessage) {
+	if msg.Node == m.node {
+		return
+	}
+	err := m.l1.Delete(context.Background(), msg.Key)
+	if err != nil && !errors.Is(err, errs.ErrKeyNotFound) {
+		log.Println("cache: 删除本地缓存失败", msg.Key, err)
+	}
+}
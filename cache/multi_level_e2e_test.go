@@ -0,0 +1,38 @@
+//go:build e2e
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisBroadcaster_e2e(t *testing.T) {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     "127.0.0.1:6379",
+		Password: "",
+		DB:       0,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, rdb.Ping(ctx).Err())
+
+	b := NewRedisBroadcaster(rdb, "cache:invalidate:e2e")
+	received := make(chan InvalidateMessage, 1)
+	require.NoError(t, b.Subscribe(ctx, func(msg InvalidateMessage) {
+		received <- msg
+	}))
+	want := InvalidateMessage{Node: "node1", Key: "key1"}
+	require.NoError(t, b.Publish(ctx, want))
+	select {
+	case msg := <-received:
+		assert.Equal(t, want, msg)
+	case <-time.After(time.Second):
+		t.Fatal("没有收到失效消息")
+	}
+}
@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type evictedKeys struct {
+	mutex sync.Mutex
+	keys  []string
+}
+
+func (e *evictedKeys) add(k string, v any) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.keys = append(e.keys, k)
+}
+
+func (e *evictedKeys) get() []string {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.keys
+}
+
+func TestMultiLevelCache(t *testing.T) {
+	ctx := context.Background()
+	l2 := NewBuildInMapCache(time.Minute, 4096)
+	defer func() { _ = l2.Close() }()
+	broadcaster := NewMemoryBroadcaster()
+
+	// 两个节点共享 L2 和 Broadcaster
+	l1A := NewBuildInMapCache(time.Minute, 1024)
+	defer func() { _ = l1A.Close() }()
+	nodeA, err := NewMultiLevelCache(l1A, l2, broadcaster)
+	require.NoError(t, err)
+	defer func() { _ = nodeA.Close() }()
+
+	// L1 上原有的回调和 MultiLevelCache 上设置的回调都会被调用
+	evictedB, nodeEvictedB := &evictedKeys{}, &evictedKeys{}
+	l1B := NewBuildInMapCache(time.Minute, 1024, BuildInMapCacheWithEvictedCallback(evictedB.add))
+	defer func() { _ = l1B.Close() }()
+	nodeB, err := NewMultiLevelCache(l1B, l2, broadcaster, MultiLevelCacheWithEvictedCallback(nodeEvictedB.add))
+	require.NoError(t, err)
+	defer func() { _ = nodeB.Close() }()
+
+	require.NoError(t, nodeA.Set(ctx, "key1", "v1", time.Hour))
+	// B 从 L2 读取之后写入 B 的 L1
+	val, err := nodeB.Get(ctx, "key1")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", val)
+	val, err = l1B.Get(ctx, "key1")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", val)
+	// L1 的过期时间不超过默认的一分钟
+	ttl, err := l1B.TTL(ctx, "key1")
+	require.NoError(t, err)
+	assert.True(t, ttl <= time.Minute, "ttl: %v", ttl)
+
+	// A 更新之后 B 的 L1 被删除，自己的 L1 保留新值。删除 B 的 L1 时经过了 L1 上的回调
+	require.NoError(t, nodeA.Set(ctx, "key1", "v2", time.Hour))
+	assert.Equal(t, []string{"key1"}, evictedB.get())
+	assert.Equal(t, []string{"key1"}, nodeEvictedB.get())
+	_, err = l1B.Get(ctx, "key1")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+	val, err = l1A.Get(ctx, "key1")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", val)
+	val, err = nodeB.Get(ctx, "key1")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", val)
+
+	require.NoError(t, nodeA.Delete(ctx, "key1"))
+	_, err = nodeB.Get(ctx, "key1")
+	assert.True(t, errors.Is(err, ErrKeyNotFound), "err: %v", err)
+	err = nodeA.Delete(ctx, "key1")
+	assert.True(t, errors.Is(err, ErrKeyNotFound), "err: %v", err)
+
+	// Close 之后不再接收失效消息
+	require.NoError(t, nodeB.Set(ctx, "key2", "v1", 0))
+	require.NoError(t, nodeB.Close())
+	assert.Eventually(t, func() bool {
+		broadcaster.mutex.RLock()
+		defer broadcaster.mutex.RUnlock()
+		return len(broadcaster.handlers) == 1
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, nodeA.Set(ctx, "key2", "v2", 0))
+	val, err = l1B.Get(ctx, "key2")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", val)
+}
+
+func TestMultiLevelCache_LoadFunc(t *testing.T) {
+	ctx := context.Background()
+	l1 := NewBuildInMapCache(time.Minute, 1024)
+	defer func() { _ = l1.Close() }()
+	l2 := NewBuildInMapCache(time.Minute, 1024)
+	defer func() { _ = l2.Close() }()
+
+	loads := 0
+	c, err := NewMultiLevelCache(l1, l2, NewMemoryBroadcaster(),
+		MultiLevelCacheWithL1Expiration(time.Second),
+		MultiLevelCacheWithLoadFunc(func(ctx context.Context, key string) (any, error) {
+			loads++
+			if key == "db" {
+				return "db value", nil
+			}
+			return nil, errors.New("db error")
+		}, time.Minute))
+	require.NoError(t, err)
+	defer func() { _ = c.Close() }()
+
+	for i := 0; i < 2; i++ {
+		val, err := c.Get(ctx, "db")
+		require.NoError(t, err)
+		assert.Equal(t, "db value", val)
+	}
+	assert.Equal(t, 1, loads)
+	val, err := l2.Get(ctx, "db")
+	require.NoError(t, err)
+	assert.Equal(t, "db value", val)
+	ttl, err := l1.TTL(ctx, "db")
+	require.NoError(t, err)
+	assert.True(t, ttl <= time.Second, "ttl: %v", ttl)
+
+	_, err = c.Get(ctx, "fail")
+	assert.Equal(t, errors.New("db error"), err)
+}
+
+func TestMultiLevelCache_EvictedCallback(t *testing.T) {
+	ctx := context.Background()
+	l2 := NewBuildInMapCache(time.Minute, 4096)
+	defer func() { _ = l2.Close() }()
+	// 每个键值对占用 26 个字节，L1 只能放下两个
+	l1 := NewBuildInMapCache(time.Minute, 60)
+	defer func() { _ = l1.Close() }()
+	evicted := &evictedKeys{}
+	c, err := NewMultiLevelCache(l1, l2, NewMemoryBroadcaster(), MultiLevelCacheWithEvictedCallback(evicted.add))
+	require.NoError(t, err)
+	defer func() { _ = c.Close() }()
+
+	// 容量淘汰和本节点的删除同样经过 L1 上的回调
+	for _, key := range []string{"k1", "k2", "k3"} {
+		require.NoError(t, c.Set(ctx, key, 1, 0))
+	}
+	require.NoError(t, c.Delete(ctx, "k3"))
+	assert.Equal(t, []string{"k1", "k3"}, evicted.get())
+}
@@ -5,69 +5,288 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"golang.org/x/sync/singleflight"
 	"log"
+	"math/rand/v2"
 	"time"
 )
 
+// notFoundPlaceholder 空值缓存中保存的值，表示数据库中不存在这个键
+const notFoundPlaceholder = "\x00soil:cache:not_found"
+
 // ReadThroughCache 所谓ReadThrough就是用户只与缓存模块交互，不在管与数据库交互
 // 更新数据库的操作由缓存自己代理
 type ReadThroughCache struct {
 	Cache
-	LoadFunc   func(ctx context.Context, key string) (any, error) // 用户自行编写数据库取数据逻辑
-	expiration time.Duration
+	// LoadFunc 用户自行编写数据库取数据逻辑，数据不存在时返回 ErrKeyNotFound
+	LoadFunc func(ctx context.Context, key string) (any, error)
+	// BatchLoadFunc 批量从数据库取数据，返回的 map 中只需要包含存在的键。
+	// 为 nil 时 MGet 逐个键调用 LoadFunc
+	BatchLoadFunc func(ctx context.Context, keys []string) (map[string]any, error)
+	expiration    time.Duration
+
+	singleflight bool
+	group        singleflight.Group
+	// negativeExpiration 大于 0 时缓存数据库中不存在的键
+	negativeExpiration time.Duration
+	bloomFilter        BloomFilter
+	// jitter 过期时间随机增加的比例
+	jitter float64
+}
+
+type ReadThroughCacheOption func(r *ReadThroughCache)
+
+func NewReadThroughCache(c Cache, loadFunc func(ctx context.Context, key string) (any, error),
+	expiration time.Duration, opts ...ReadThroughCacheOption) *ReadThroughCache {
+	res := &ReadThroughCache{
+		Cache:      c,
+		LoadFunc:   loadFunc,
+		expiration: expiration,
+	}
+	for _, opt := range opts {
+		opt(res)
+	}
+	return res
+}
+
+// ReadThroughCacheWithBatchLoadFunc MGet 使用 loadFunc 一次加载所有缺失的键，
+// 返回的 map 中只需要包含存在的键。不设置时 MGet 逐个键调用 LoadFunc
+func ReadThroughCacheWithBatchLoadFunc(loadFunc func(ctx context.Context, keys []string) (map[string]any, error)) ReadThroughCacheOption {
+	return func(r *ReadThroughCache) {
+		r.BatchLoadFunc = loadFunc
+	}
+}
+
+// ReadThroughCacheWithSingleflight 同一个键并发的缓存未命中只调用一次 LoadFunc，
+// 防止热点键过期的时候大量请求同时打到数据库上。BatchLoadFunc 不受影响
+func ReadThroughCacheWithSingleflight() ReadThroughCacheOption {
+	return func(r *ReadThroughCache) {
+		r.singleflight = true
+	}
+}
+
+// ReadThroughCacheWithNegativeCache LoadFunc 返回 ErrKeyNotFound 时缓存一个空值，
+// 在 expiration 内查询这个键直接返回 ErrKeyNotFound，防止缓存穿透。expiration 应该比较短，
+// 否则新写入数据库的数据要等空值过期之后才能读到
+func ReadThroughCacheWithNegativeCache(expiration time.Duration) ReadThroughCacheOption {
+	return func(r *ReadThroughCache) {
+		r.negativeExpiration = expiration
+	}
+}
+
+// ReadThroughCacheWithBloomFilter 布隆过滤器判断不存在的键直接返回 ErrKeyNotFound，不查询数据库。
+// 写入数据库的时候需要同时将键加入到布隆过滤器中
+func ReadThroughCacheWithBloomFilter(f BloomFilter) ReadThroughCacheOption {
+	return func(r *ReadThroughCache) {
+		r.bloomFilter = f
+	}
+}
+
+// ReadThroughCacheWithJitter 写入缓存的过期时间随机增加 [0, ratio*expiration]，
+// 防止同一批写入的缓存同时过期引起缓存雪崩
+func ReadThroughCacheWithJitter(ratio float64) ReadThroughCacheOption {
+	return func(r *ReadThroughCache) {
+		r.jitter = ratio
+	}
 }
 
 // Get 同步Get
 func (r *ReadThroughCache) Get(ctx context.Context, key string) (any, error) {
-	value, err := r.Get(ctx, key)
-	if errors.Is(err, errs.NewErrKeyNotFound(key)) {
-		// 在缓存中没有找到数据，去数据库中取数据
-		value, err = r.LoadFunc(ctx, key)
-		if err == nil {
-			// 数据库有数据，更新缓存
-			err = r.Cache.Set(ctx, key, value, r.expiration)
-			if err != nil {
-				return value, fmt.Errorf("%w, 原因：%s", errs.ErrFailedToRefreshCache, err.Error())
-			}
-		}
+	value, hit, err := r.getCache(ctx, key)
+	if hit {
+		return value, err
+	}
+	// 在缓存中没有找到数据，去数据库中取数据
+	value, err = r.loadFromDB(ctx, key)
+	if refreshErr := r.refresh(ctx, key, value, err); refreshErr != nil && err == nil {
+		return value, refreshErr
 	}
 	return value, err
 }
 
 // AsyncGet Cache直接返回响应，而后异步从DB读取数据刷新缓存
 func (r *ReadThroughCache) AsyncGet(ctx context.Context, key string) (any, error) {
-	value, err := r.Get(ctx, key)
-	if errors.Is(err, errs.NewErrKeyNotFound(key)) {
+	value, hit, err := r.getCache(ctx, key)
+	if !hit {
+		// 请求结束之后 ctx 可能已经被取消
+		ctx = context.WithoutCancel(ctx)
 		go func() {
 			// 在缓存中没有找到数据，去数据库中取数据
-			value, err = r.LoadFunc(ctx, key)
-			if err == nil {
-				// 数据库有数据，更新缓存
-				err = r.Cache.Set(ctx, key, value, r.expiration)
-				if err != nil {
-					log.Fatalln(value, fmt.Errorf("%w, 原因：%s", errs.ErrFailedToRefreshCache, err.Error()))
-				}
+			val, err := r.loadFromDB(ctx, key)
+			// 刷新失败只影响之后的命中率，记录日志之后丢弃
+			if err = r.refresh(ctx, key, val, err); err != nil {
+				log.Println("cache: 异步刷新缓存失败", key, err)
 			}
 		}()
+		return nil, errs.NewErrKeyNotFound(key)
 	}
 	return value, err
 }
 
 // SemiAsyncGet Cache从缓存读取数据是同步的，但是将返回值是异步刷新到缓存的
 func (r *ReadThroughCache) SemiAsyncGet(ctx context.Context, key string) (any, error) {
-	value, err := r.Get(ctx, key)
-	if errors.Is(err, errs.NewErrKeyNotFound(key)) {
-		// 在缓存中没有找到数据，去数据库中取数据
-		value, err = r.LoadFunc(ctx, key)
-		if err == nil {
-			go func() {
-				// 数据库有数据，更新缓存
-				err = r.Cache.Set(ctx, key, value, r.expiration)
-				if err != nil {
-					log.Fatalln(value, fmt.Errorf("%w, 原因：%s", errs.ErrFailedToRefreshCache, err.Error()))
-				}
-			}()
-		}
+	value, hit, err := r.getCache(ctx, key)
+	if hit {
+		return value, err
 	}
+	// 在缓存中没有找到数据，去数据库中取数据
+	value, err = r.loadFromDB(ctx, key)
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		if err := r.refresh(ctx, key, value, err); err != nil {
+			log.Println("cache: 异步刷新缓存失败", key, err)
+		}
+	}()
 	return value, err
 }
+
+// getCache hit 为 false 表示需要从数据库加载，命中空值缓存时 hit 为 true 并返回 ErrKeyNotFound
+func (r *ReadThroughCache) getCache(ctx context.Context, key string) (value any, hit bool, err error) {
+	value, err = r.Cache.Get(ctx, key)
+	if errors.Is(err, errs.ErrKeyNotFound) {
+		return nil, false, nil
+	}
+	if err == nil && value == notFoundPlaceholder {
+		return nil, true, errs.NewErrKeyNotFound(key)
+	}
+	return value, true, err
+}
+
+func (r *ReadThroughCache) loadFromDB(ctx context.Context, key string) (any, error) {
+	if r.bloomFilter != nil && !r.bloomFilter.MayContain(key) {
+		return nil, errs.NewErrKeyNotFound(key)
+	}
+	if !r.singleflight {
+		return r.LoadFunc(ctx, key)
+	}
+	val, err, _ := r.group.Do(key, func() (any, error) {
+		// 共享结果的其它调用方不应该因为第一个调用方的 ctx 被取消而失败
+		return r.LoadFunc(context.WithoutCancel(ctx), key)
+	})
+	return val, err
+}
+
+// refresh 根据从数据库加载的结果刷新缓存，err 为加载数据时返回的 error
+func (r *ReadThroughCache) refresh(ctx context.Context, key string, val any, err error) error {
+	switch {
+	case err == nil:
+		err = r.Cache.Set(ctx, key, val, r.jitterExpiration())
+	case errors.Is(err, errs.ErrKeyNotFound) && r.negativeExpiration > 0:
+		err = r.Cache.Set(ctx, key, notFoundPlaceholder, r.negativeExpiration)
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w, 原因：%s", errs.ErrFailedToRefreshCache, err.Error())
+	}
+	return nil
+}
+
+func (r *ReadThroughCache) jitterExpiration() time.Duration {
+	if r.expiration <= 0 || r.jitter <= 0 {
+		return r.expiration
+	}
+	return r.expiration + time.Duration(rand.Int64N(int64(float64(r.expiration)*r.jitter)+1))
+}
+
+// MGet 只从数据库加载缓存中缺失的键，并将加载到的数据写回缓存。
+// Cache 没有实现 BatchCache 时逐个键读写缓存
+func (r *ReadThroughCache) MGet(ctx context.Context, keys ...string) (map[string]any, error) {
+	cached, err := mGet(ctx, r.Cache, keys)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]any, len(keys))
+	missing := make([]string, 0, len(keys)-len(cached))
+	for _, key := range keys {
+		val, ok := cached[key]
+		switch {
+		case !ok:
+			if r.bloomFilter == nil || r.bloomFilter.MayContain(key) {
+				missing = append(missing, key)
+			}
+		case val != notFoundPlaceholder:
+			res[key] = val
+		}
+	}
+	if len(missing) == 0 {
+		return res, nil
+	}
+	loaded, err := r.batchLoad(ctx, missing)
+	if err != nil {
+		return res, err
+	}
+	for key, val := range loaded {
+		res[key] = val
+	}
+	// 同一批数据使用相同的过期时间
+	if err = mSet(ctx, r.Cache, loaded, r.jitterExpiration()); err != nil {
+		return res, fmt.Errorf("%w, 原因：%s", errs.ErrFailedToRefreshCache, err.Error())
+	}
+	if r.negativeExpiration <= 0 {
+		return res, nil
+	}
+	notFound := make(map[string]any, len(missing)-len(loaded))
+	for _, key := range missing {
+		if _, ok := loaded[key]; !ok {
+			notFound[key] = notFoundPlaceholder
+		}
+	}
+	if err = mSet(ctx, r.Cache, notFound, r.negativeExpiration); err != nil {
+		return res, fmt.Errorf("%w, 原因：%s", errs.ErrFailedToRefreshCache, err.Error())
+	}
+	return res, nil
+}
+
+// batchLoad 没有设置 BatchLoadFunc 时逐个键调用 LoadFunc，
+// LoadFunc 返回 ErrKeyNotFound 的键视为数据库中不存在
+func (r *ReadThroughCache) batchLoad(ctx context.Context, keys []string) (map[string]any, error) {
+	if r.BatchLoadFunc != nil {
+		return r.BatchLoadFunc(ctx, keys)
+	}
+	res := make(map[string]any, len(keys))
+	for _, key := range keys {
+		val, err := r.LoadFunc(ctx, key)
+		if errors.Is(err, errs.ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		res[key] = val
+	}
+	return res, nil
+}
+
+func mGet(ctx context.Context, c Cache, keys []string) (map[string]any, error) {
+	if bc, ok := c.(BatchCache); ok {
+		return bc.MGet(ctx, keys...)
+	}
+	res := make(map[string]any, len(keys))
+	for _, key := range keys {
+		val, err := c.Get(ctx, key)
+		if errors.Is(err, errs.ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		res[key] = val
+	}
+	return res, nil
+}
+
+func mSet(ctx context.Context, c Cache, vals map[string]any, expiration time.Duration) error {
+	if len(vals) == 0 {
+		return nil
+	}
+	if bc, ok := c.(BatchCache); ok {
+		return bc.MSet(ctx, vals, expiration)
+	}
+	for key, val := range vals {
+		if err := c.Set(ctx, key, val, expiration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package cache
+
+import (
+	"Soil/cache/internal/errs"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadThroughCache_MGet(t *testing.T) {
+	ctx := context.Background()
+	local := NewBuildInMapCache(time.Minute, 1024)
+	defer func() { _ = local.Close() }()
+	require.NoError(t, local.Set(ctx, "k1", "cached", 0))
+
+	var loadedKeys []string
+	c := &ReadThroughCache{
+		Cache: local,
+		BatchLoadFunc: func(ctx context.Context, keys []string) (map[string]any, error) {
+			loadedKeys = keys
+			// k3 在数据库中也不存在
+			return map[string]any{"k2": "loaded"}, nil
+		},
+	}
+	vals, err := c.MGet(ctx, "k1", "k2", "k3")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"k1": "cached", "k2": "loaded"}, vals)
+	assert.Equal(t, []string{"k2", "k3"}, loadedKeys)
+
+	// 加载到的数据写回了缓存
+	val, err := local.Get(ctx, "k2")
+	require.NoError(t, err)
+	assert.Equal(t, "loaded", val)
+
+	c.BatchLoadFunc = func(ctx context.Context, keys []string) (map[string]any, error) {
+		return nil, errors.New("db error")
+	}
+	vals, err = c.MGet(ctx, "k1", "k4")
+	assert.Equal(t, errors.New("db error"), err)
+	assert.Equal(t, map[string]any{"k1": "cached"}, vals)
+}
+
+func TestReadThroughCache_MGetWithoutBatchLoadFunc(t *testing.T) {
+	ctx := context.Background()
+	local := NewBuildInMapCache(time.Minute, 1024)
+	defer func() { _ = local.Close() }()
+	require.NoError(t, local.Set(ctx, "k1", "cached", 0))
+
+	var loadedKeys []string
+	loadErr := errors.New("db error")
+	// 没有设置 BatchLoadFunc，逐个键调用 LoadFunc
+	c := NewReadThroughCache(local, func(ctx context.Context, key string) (any, error) {
+		loadedKeys = append(loadedKeys, key)
+		switch key {
+		case "k2":
+			return "loaded", nil
+		case "fail":
+			return nil, loadErr
+		default:
+			return nil, errs.NewErrKeyNotFound(key)
+		}
+	}, time.Minute)
+	vals, err := c.MGet(ctx, "k1", "k2", "k3")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"k1": "cached", "k2": "loaded"}, vals)
+	assert.Equal(t, []string{"k2", "k3"}, loadedKeys)
+
+	val, err := local.Get(ctx, "k2")
+	require.NoError(t, err)
+	assert.Equal(t, "loaded", val)
+
+	vals, err = c.MGet(ctx, "k1", "fail")
+	assert.Equal(t, loadErr, err)
+	assert.Equal(t, map[string]any{"k1": "cached"}, vals)
+}
+
+func TestReadThroughCache_Get(t *testing.T) {
+	ctx := context.Background()
+	bloom := NewMemoryBloomFilter(100, 0.01)
+	for _, key := range []string{"cached", "db", "missing", "fail"} {
+		bloom.Add(key)
+	}
+	testCases := []struct {
+		name    string
+		opts    []ReadThroughCacheOption
+		key     string
+		wantVal any
+		wantErr error
+		// wantLoads 连续 Get 两次调用 LoadFunc 的次数
+		wantLoads int64
+	}{
+		{
+			name:    "cache hit",
+			key:     "cached",
+			wantVal: "cached value",
+		},
+		{
+			name:      "load from db",
+			key:       "db",
+			wantVal:   "db value",
+			wantLoads: 1,
+		},
+		{
+			name:      "not found",
+			key:       "missing",
+			wantErr:   ErrKeyNotFound,
+			wantLoads: 2,
+		},
+		{
+			name:      "negative cache",
+			opts:      []ReadThroughCacheOption{ReadThroughCacheWithNegativeCache(time.Minute)},
+			key:       "missing",
+			wantErr:   ErrKeyNotFound,
+			wantLoads: 1,
+		},
+		{
+			name:    "bloom filter",
+			opts:    []ReadThroughCacheOption{ReadThroughCacheWithBloomFilter(bloom)},
+			key:     "never",
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name:      "load error",
+			opts:      []ReadThroughCacheOption{ReadThroughCacheWithNegativeCache(time.Minute)},
+			key:       "fail",
+			wantErr:   errors.New("db error"),
+			wantLoads: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			local := NewBuildInMapCache(time.Minute, 1024)
+			defer func() { _ = local.Close() }()
+			require.NoError(t, local.Set(ctx, "cached", "cached value", 0))
+			var loads atomic.Int64
+			c := NewReadThroughCache(local, func(ctx context.Context, key string) (any, error) {
+				loads.Add(1)
+				switch key {
+				case "db":
+					return "db value", nil
+				case "fail":
+					return nil, errors.New("db error")
+				}
+				return nil, errs.NewErrKeyNotFound(key)
+			}, time.Minute, tc.opts...)
+
+			for i := 0; i < 2; i++ {
+				val, err := c.Get(ctx, tc.key)
+				if tc.wantErr == ErrKeyNotFound {
+					assert.True(t, errors.Is(err, ErrKeyNotFound), "err: %v", err)
+				} else {
+					assert.Equal(t, tc.wantErr, err)
+				}
+				assert.Equal(t, tc.wantVal, val)
+			}
+			assert.Equal(t, tc.wantLoads, loads.Load())
+		})
+	}
+}
+
+func TestReadThroughCache_Singleflight(t *testing.T) {
+	ctx := context.Background()
+	local := NewBuildInMapCache(time.Minute, 1024)
+	defer func() { _ = local.Close() }()
+
+	var loads atomic.Int64
+	start := make(chan struct{})
+	c := NewReadThroughCache(local, func(ctx context.Context, key string) (any, error) {
+		loads.Add(1)
+		// 等所有的调用方都进入 singleflight 之后再返回
+		<-start
+		return "value", nil
+	}, time.Minute, ReadThroughCacheWithSingleflight())
+
+	const n = 10
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			val, err := c.Get(ctx, "hot")
+			assert.NoError(t, err)
+			assert.Equal(t, "value", val)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(start)
+	wg.Wait()
+	assert.Equal(t, int64(1), loads.Load())
+}
+
+func TestReadThroughCache_Jitter(t *testing.T) {
+	c := NewReadThroughCache(nil, nil, time.Minute, ReadThroughCacheWithJitter(0.1))
+	for i := 0; i < 100; i++ {
+		exp := c.jitterExpiration()
+		assert.True(t, exp >= time.Minute && exp <= time.Minute+6*time.Second, "expiration: %v", exp)
+	}
+	assert.Equal(t, time.Minute, NewReadThroughCache(nil, nil, time.Minute).jitterExpiration())
+}
+
+func TestReadThroughCache_MGetNegativeCache(t *testing.T) {
+	ctx := context.Background()
+	local := NewBuildInMapCache(time.Minute, 1024)
+	defer func() { _ = local.Close() }()
+
+	var loadedKeys [][]string
+	c := NewReadThroughCache(local, nil, time.Minute,
+		ReadThroughCacheWithNegativeCache(time.Minute),
+		ReadThroughCacheWithBatchLoadFunc(func(ctx context.Context, keys []string) (map[string]any, error) {
+			loadedKeys = append(loadedKeys, keys)
+			return map[string]any{"k1": "v1"}, nil
+		}))
+	for i := 0; i < 2; i++ {
+		vals, err := c.MGet(ctx, "k1", "k2")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"k1": "v1"}, vals)
+	}
+	// 第二次 k1 命中缓存，k2 命中空值缓存
+	assert.Equal(t, [][]string{{"k1", "k2"}}, loadedKeys)
+	_, err := c.Get(ctx, "k2")
+	assert.True(t, errors.Is(err, ErrKeyNotFound), "err: %v", err)
+}
@@ -3,14 +3,23 @@ package cache
 import (
 	"Soil/cache/internal/errs"
 	"context"
+	_ "embed"
+	"errors"
+	"fmt"
 	"github.com/redis/go-redis/v9"
+	"sort"
 	"time"
 )
 
+//go:embed lua/mset.lua
+var luaMSet string
+
 type RedisCache struct {
 	client redis.Cmdable
 }
 
+var _ BatchCache = RedisCache{}
+
 func NewRedisCache(client redis.Cmdable) *RedisCache {
 	return &RedisCache{client}
 }
@@ -27,11 +36,121 @@ func (r RedisCache) Set(ctx context.Context, key string, val any, expiration tim
 	return nil
 }
 
+// Get 键不存在时返回 ErrKeyNotFound，与 BuildInMapCache 保持一致
 func (r RedisCache) Get(ctx context.Context, key string) (any, error) {
-	return r.client.Get(ctx, key).Result()
+	res, err := r.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, errs.NewErrKeyNotFound(key)
+	}
+	return res, err
 }
 
 func (r RedisCache) Delete(ctx context.Context, key string) error {
 	_, err := r.client.Del(ctx, key).Result()
 	return err
 }
+
+func (r RedisCache) MGet(ctx context.Context, keys ...string) (map[string]any, error) {
+	// MGET 至少需要一个键
+	if len(keys) == 0 {
+		return map[string]any{}, nil
+	}
+	vals, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]any, len(keys))
+	for i, val := range vals {
+		// 不存在的键返回的是 nil
+		if val != nil {
+			res[keys[i]] = val
+		}
+	}
+	return res, nil
+}
+
+// MSet MSET 不支持过期时间，因此使用 lua 脚本一次写入。
+// 集群模式下所有的键需要使用 hash tag 落在同一个 slot，e.g. {product}:1
+func (r RedisCache) MSet(ctx context.Context, vals map[string]any, expiration time.Duration) error {
+	if len(vals) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(vals))
+	for key := range vals {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	// 与 go-redis 的 SET 一致，不足 1 毫秒的过期时间按 1 毫秒处理，而不是变成 0 永不过期
+	ms := expiration.Milliseconds()
+	if expiration > 0 && ms == 0 {
+		ms = 1
+	}
+	args := make([]any, 0, len(vals)+1)
+	args = append(args, ms)
+	for _, key := range keys {
+		args = append(args, vals[key])
+	}
+	res, err := r.client.Eval(ctx, luaMSet, keys, args...).Result()
+	if err != nil {
+		return err
+	}
+	if res != "OK" {
+		return errs.NewErrRedisSetFailed(fmt.Sprint(res))
+	}
+	return nil
+}
+
+func (r RedisCache) MDelete(ctx context.Context, keys ...string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	return r.client.Del(ctx, keys...).Result()
+}
+
+func (r RedisCache) SetNX(ctx context.Context, key string, val any, expiration time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, val, expiration).Result()
+}
+
+func (r RedisCache) GetSet(ctx context.Context, key string, val any) (any, error) {
+	res, err := r.client.GetSet(ctx, key, val).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, errs.NewErrKeyNotFound(key)
+	}
+	return res, err
+}
+
+func (r RedisCache) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	return r.client.IncrBy(ctx, key, delta).Result()
+}
+
+func (r RedisCache) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	return r.client.DecrBy(ctx, key, delta).Result()
+}
+
+func (r RedisCache) Expire(ctx context.Context, key string, expiration time.Duration) (bool, error) {
+	if expiration > 0 {
+		return r.client.Expire(ctx, key, expiration).Result()
+	}
+	ok, err := r.client.Persist(ctx, key).Result()
+	if err != nil || ok {
+		return ok, err
+	}
+	// 键存在但是没有过期时间时 PERSIST 同样返回 0
+	n, err := r.client.Exists(ctx, key).Result()
+	return n == 1, err
+}
+
+func (r RedisCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	res, err := r.client.TTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// go-redis 对 -1 和 -2 不做单位换算
+	switch res {
+	case -2:
+		return 0, errs.NewErrKeyNotFound(key)
+	case -1:
+		return NoExpiration, nil
+	}
+	return res, nil
+}
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"Soil/cache/internal/errs"
 	"Soil/cache/mocks"
 	"context"
 	"errors"
@@ -120,6 +121,19 @@ func TestRedisCache_Get(t *testing.T) {
 			key:   "nonexisting",
 			value: "(nil)",
 		},
+		{
+			name: "key not found",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := mocks.NewMockCmdable(ctrl)
+				status := redis.NewStringCmd(context.Background())
+				status.SetErr(redis.Nil)
+				cmd.EXPECT().Get(context.Background(), "key2").Return(status)
+
+				return cmd
+			},
+			key:       "key2",
+			wantError: errs.NewErrKeyNotFound("key2"),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -169,3 +183,182 @@ func TestRedisCache_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestRedisCache_MGet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cmd := mocks.NewMockCmdable(ctrl)
+	res := redis.NewSliceCmd(context.Background())
+	res.SetVal([]any{"v1", nil, "v3"})
+	cmd.EXPECT().MGet(context.Background(), "k1", "k2", "k3").Return(res)
+
+	vals, err := NewRedisCache(cmd).MGet(context.Background(), "k1", "k2", "k3")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"k1": "v1", "k3": "v3"}, vals)
+
+	// 没有键时不访问 Redis
+	vals, err = NewRedisCache(cmd).MGet(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{}, vals)
+}
+
+func TestRedisCache_MSet(t *testing.T) {
+	testCases := []struct {
+		name       string
+		mock       func(ctrl *gomock.Controller) redis.Cmdable
+		vals       map[string]any
+		expiration time.Duration
+		wantError  error
+	}{
+		{
+			name: "mset",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := mocks.NewMockCmdable(ctrl)
+				res := redis.NewCmd(context.Background())
+				res.SetVal("OK")
+				cmd.EXPECT().Eval(context.Background(), luaMSet, []string{"k1", "k2"}, int64(1000), "v1", "v2").
+					Return(res)
+				return cmd
+			},
+			vals:       map[string]any{"k2": "v2", "k1": "v1"},
+			expiration: time.Second,
+		},
+		{
+			name: "less than 1ms",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := mocks.NewMockCmdable(ctrl)
+				res := redis.NewCmd(context.Background())
+				res.SetVal("OK")
+				cmd.EXPECT().Eval(context.Background(), luaMSet, []string{"k1"}, int64(1), "v1").
+					Return(res)
+				return cmd
+			},
+			vals:       map[string]any{"k1": "v1"},
+			expiration: time.Microsecond,
+		},
+		{
+			name: "empty",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				return mocks.NewMockCmdable(ctrl)
+			},
+		},
+		{
+			name: "eval error",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := mocks.NewMockCmdable(ctrl)
+				res := redis.NewCmd(context.Background())
+				res.SetErr(context.DeadlineExceeded)
+				cmd.EXPECT().Eval(context.Background(), luaMSet, []string{"k1"}, int64(0), "v1").
+					Return(res)
+				return cmd
+			},
+			vals:      map[string]any{"k1": "v1"},
+			wantError: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			c := NewRedisCache(tc.mock(ctrl))
+			err := c.MSet(context.Background(), tc.vals, tc.expiration)
+			assert.Equal(t, tc.wantError, err)
+		})
+	}
+}
+
+func TestRedisCache_TTL(t *testing.T) {
+	testCases := []struct {
+		name      string
+		ttl       time.Duration
+		wantTTL   time.Duration
+		wantError error
+	}{
+		{
+			name:    "ttl",
+			ttl:     time.Minute,
+			wantTTL: time.Minute,
+		},
+		{
+			name:    "no expiration",
+			ttl:     -1,
+			wantTTL: NoExpiration,
+		},
+		{
+			name:      "key not found",
+			ttl:       -2,
+			wantError: errs.NewErrKeyNotFound("key1"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			cmd := mocks.NewMockCmdable(ctrl)
+			res := redis.NewDurationCmd(context.Background(), time.Second)
+			res.SetVal(tc.ttl)
+			cmd.EXPECT().TTL(context.Background(), "key1").Return(res)
+
+			ttl, err := NewRedisCache(cmd).TTL(context.Background(), "key1")
+			assert.Equal(t, tc.wantError, err)
+			assert.Equal(t, tc.wantTTL, ttl)
+		})
+	}
+}
+
+func TestRedisCache_Expire(t *testing.T) {
+	testCases := []struct {
+		name       string
+		mock       func(ctrl *gomock.Controller) redis.Cmdable
+		expiration time.Duration
+		wantOk     bool
+	}{
+		{
+			name: "expire",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := mocks.NewMockCmdable(ctrl)
+				res := redis.NewBoolCmd(context.Background())
+				res.SetVal(true)
+				cmd.EXPECT().Expire(context.Background(), "key1", time.Minute).Return(res)
+				return cmd
+			},
+			expiration: time.Minute,
+			wantOk:     true,
+		},
+		{
+			name: "persist without expiration",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := mocks.NewMockCmdable(ctrl)
+				res := redis.NewBoolCmd(context.Background())
+				res.SetVal(false)
+				cmd.EXPECT().Persist(context.Background(), "key1").Return(res)
+				exists := redis.NewIntCmd(context.Background())
+				exists.SetVal(1)
+				cmd.EXPECT().Exists(context.Background(), "key1").Return(exists)
+				return cmd
+			},
+			wantOk: true,
+		},
+		{
+			name: "persist key not found",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := mocks.NewMockCmdable(ctrl)
+				res := redis.NewBoolCmd(context.Background())
+				res.SetVal(false)
+				cmd.EXPECT().Persist(context.Background(), "key1").Return(res)
+				exists := redis.NewIntCmd(context.Background())
+				exists.SetVal(0)
+				cmd.EXPECT().Exists(context.Background(), "key1").Return(exists)
+				return cmd
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			ok, err := NewRedisCache(tc.mock(ctrl)).Expire(context.Background(), "key1", tc.expiration)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantOk, ok)
+		})
+	}
+}
@@ -10,3 +10,27 @@ type Cache interface {
 	Get(ctx context.Context, key string) (any, error)
 	Delete(ctx context.Context, key string) error
 }
+
+// NoExpiration TTL 返回的键没有过期时间
+const NoExpiration time.Duration = -1
+
+// BatchCache 在 Cache 的基础上提供批量操作和原子操作，减少与 redis 之间的往返次数
+type BatchCache interface {
+	Cache
+	// MGet 返回的 map 中只包含存在的键
+	MGet(ctx context.Context, keys ...string) (map[string]any, error)
+	MSet(ctx context.Context, vals map[string]any, expiration time.Duration) error
+	// MDelete 忽略不存在的键，返回删除的键的个数
+	MDelete(ctx context.Context, keys ...string) (int64, error)
+	// SetNX 键不存在时才写入，返回是否写入成功
+	SetNX(ctx context.Context, key string, val any, expiration time.Duration) (bool, error)
+	// GetSet 写入新值并返回旧值，同时清除过期时间。键不存在时仍然写入，返回 ErrKeyNotFound
+	GetSet(ctx context.Context, key string, val any) (any, error)
+	// Incr 键不存在时从 0 开始计算，保留原有的过期时间
+	Incr(ctx context.Context, key string, delta int64) (int64, error)
+	Decr(ctx context.Context, key string, delta int64) (int64, error)
+	// Expire expiration 小于等于 0 时清除过期时间，返回键是否存在
+	Expire(ctx context.Context, key string, expiration time.Duration) (bool, error)
+	// TTL 键没有过期时间时返回 NoExpiration，键不存在时返回 ErrKeyNotFound
+	TTL(ctx context.Context, key string) (time.Duration, error)
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"Soil/cache/internal/errs"
+	"context"
+	"time"
+)
+
+// TypedCache 在 Cache 之上提供类型安全的读写，值通过 Codec 编码之后保存，
+// 因此 BuildInMapCache 与 RedisCache 中保存的都是编码之后的字节，e.g.
+//
+//	c := NewTypedCache[User](NewRedisCache(client), JSONCodec{})
+//	u, err := c.Get(ctx, "user:1")
+//	if errors.Is(err, ErrKeyNotFound) { ... }
+type TypedCache[V any] struct {
+	cache Cache
+	codec Codec
+}
+
+func NewTypedCache[V any](c Cache, codec Codec) *TypedCache[V] {
+	return &TypedCache[V]{
+		cache: c,
+		codec: codec,
+	}
+}
+
+func (t *TypedCache[V]) Set(ctx context.Context, key string, val V, expiration time.Duration) error {
+	data, err := t.codec.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return t.cache.Set(ctx, key, data, expiration)
+}
+
+// Get 键不存在时返回 ErrKeyNotFound
+func (t *TypedCache[V]) Get(ctx context.Context, key string) (V, error) {
+	var val V
+	raw, err := t.cache.Get(ctx, key)
+	if err != nil {
+		return val, err
+	}
+	var data []byte
+	switch r := raw.(type) {
+	case []byte:
+		data = r
+	case string:
+		// go-redis 返回的是 string
+		data = []byte(r)
+	default:
+		return val, errs.NewErrInvalidValueType(key, raw)
+	}
+	err = t.codec.Unmarshal(data, &val)
+	return val, err
+}
+
+func (t *TypedCache[V]) Delete(ctx context.Context, key string) error {
+	return t.cache.Delete(ctx, key)
+}
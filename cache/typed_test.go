@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"Soil/cache/mocks"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type typedUser struct {
+	Id   int64
+	Name string
+}
+
+type typedPoint struct {
+	X, Y int32
+}
+
+func TestTypedCache_Local(t *testing.T) {
+	ctx := context.Background()
+	local := NewBuildInMapCache(time.Minute, 1024)
+	defer func() { _ = local.Close() }()
+
+	t.Run("json", func(t *testing.T) {
+		testTypedCache(t, NewTypedCache[typedUser](local, JSONCodec{}), "json", typedUser{Id: 1, Name: "Tom"})
+	})
+	t.Run("gob", func(t *testing.T) {
+		testTypedCache(t, NewTypedCache[*typedUser](local, GobCodec{}), "gob", &typedUser{Id: 1, Name: "Tom"})
+	})
+	t.Run("proto", func(t *testing.T) {
+		c := NewTypedCache[*wrapperspb.StringValue](local, ProtoCodec{})
+		require.NoError(t, c.Set(ctx, "proto", wrapperspb.String("Tom"), 0))
+		val, err := c.Get(ctx, "proto")
+		require.NoError(t, err)
+		assert.Equal(t, "Tom", val.GetValue())
+	})
+	t.Run("msgpack", func(t *testing.T) {
+		testTypedCache(t, NewTypedCache[typedUser](local, MsgpackCodec{}), "msgpack", typedUser{Id: 1, Name: "Tom"})
+	})
+	t.Run("msgpack pointer", func(t *testing.T) {
+		testTypedCache(t, NewTypedCache[*typedUser](local, MsgpackCodec{}), "msgpack pointer", &typedUser{Id: 1, Name: "Tom"})
+	})
+	t.Run("fixed size", func(t *testing.T) {
+		testTypedCache(t, NewTypedCache[typedPoint](local, FixedSizeCodec{}), "fixed size", typedPoint{X: 1, Y: -2})
+	})
+	t.Run("fixed size binary marshaler", func(t *testing.T) {
+		birth := time.Unix(946684800, 0).UTC()
+		testTypedCache(t, NewTypedCache[time.Time](local, FixedSizeCodec{}), "fixed size binary marshaler", birth)
+	})
+	t.Run("fixed size with string", func(t *testing.T) {
+		// 包含 string 的结构体不是定长的，写入时直接返回错误
+		c := NewTypedCache[typedUser](local, FixedSizeCodec{})
+		err := c.Set(ctx, "fixed size with string", typedUser{Id: 1, Name: "Tom"}, 0)
+		assert.Equal(t, errors.New("cache: FixedSizeCodec 不支持变长的类型 cache.typedUser"), err)
+		_, err = local.Get(ctx, "fixed size with string")
+		assert.True(t, errors.Is(err, ErrKeyNotFound), "err: %v", err)
+	})
+	t.Run("invalid value type", func(t *testing.T) {
+		require.NoError(t, local.Set(ctx, "raw", 123, 0))
+		_, err := NewTypedCache[int](local, JSONCodec{}).Get(ctx, "raw")
+		assert.Error(t, err)
+	})
+}
+
+func testTypedCache[V any](t *testing.T, c *TypedCache[V], key string, val V) {
+	ctx := context.Background()
+	_, err := c.Get(ctx, key)
+	assert.True(t, errors.Is(err, ErrKeyNotFound), "err: %v", err)
+
+	require.NoError(t, c.Set(ctx, key, val, 0))
+	got, err := c.Get(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, val, got)
+
+	require.NoError(t, c.Delete(ctx, key))
+	_, err = c.Get(ctx, key)
+	assert.True(t, errors.Is(err, ErrKeyNotFound), "err: %v", err)
+}
+
+func TestTypedCache_Redis(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) redis.Cmdable
+		key     string
+		wantVal typedUser
+		wantErr error
+	}{
+		{
+			name: "get",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := mocks.NewMockCmdable(ctrl)
+				status := redis.NewStringCmd(context.Background())
+				status.SetVal(`{"Id":1,"Name":"Tom"}`)
+				cmd.EXPECT().Get(context.Background(), "key1").Return(status)
+				return cmd
+			},
+			key:     "key1",
+			wantVal: typedUser{Id: 1, Name: "Tom"},
+		},
+		{
+			name: "key not found",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := mocks.NewMockCmdable(ctrl)
+				status := redis.NewStringCmd(context.Background())
+				status.SetErr(redis.Nil)
+				cmd.EXPECT().Get(context.Background(), "key1").Return(status)
+				return cmd
+			},
+			key:     "key1",
+			wantErr: ErrKeyNotFound,
+		},
+		{
+			name: "get error",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				cmd := mocks.NewMockCmdable(ctrl)
+				status := redis.NewStringCmd(context.Background())
+				status.SetErr(context.DeadlineExceeded)
+				cmd.EXPECT().Get(context.Background(), "key1").Return(status)
+				return cmd
+			},
+			key:     "key1",
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			c := NewTypedCache[typedUser](NewRedisCache(tc.mock(ctrl)), JSONCodec{})
+			val, err := c.Get(context.Background(), tc.key)
+			assert.True(t, errors.Is(err, tc.wantErr), "err: %v", err)
+			assert.Equal(t, tc.wantVal, val)
+		})
+	}
+}
+
+func TestTypedCache_RedisSet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cmd := mocks.NewMockCmdable(ctrl)
+	status := redis.NewStatusCmd(context.Background())
+	status.SetVal("OK")
+	cmd.EXPECT().Set(context.Background(), "key1", []byte(`{"Id":1,"Name":"Tom"}`), time.Minute).Return(status)
+
+	c := NewTypedCache[typedUser](NewRedisCache(cmd), JSONCodec{})
+	assert.NoError(t, c.Set(context.Background(), "key1", typedUser{Id: 1, Name: "Tom"}, time.Minute))
+}
@@ -58,3 +58,82 @@ func (a Aggregate) GT(arg any) Predicate {
 		right: exprOf(arg),
 	}
 }
+
+// NE 不等于, e.g. Avg("Age").NE(18) --> AVG(`age`) != ?
+func (a Aggregate) NE(arg any) Predicate {
+	return Predicate{
+		left:  a,
+		op:    opNE,
+		right: exprOf(arg),
+	}
+}
+
+// GE 大于等于
+func (a Aggregate) GE(arg any) Predicate {
+	return Predicate{
+		left:  a,
+		op:    opGE,
+		right: exprOf(arg),
+	}
+}
+
+// LE 小于等于
+func (a Aggregate) LE(arg any) Predicate {
+	return Predicate{
+		left:  a,
+		op:    opLE,
+		right: exprOf(arg),
+	}
+}
+
+// In e.g. Col("Id").In(1, 2, 3) 或 Col("Id").In([]int{1, 2, 3}) --> `id` IN (?,?,?)；
+// 传入 *Selector 时作为子查询：Col("Id").In(NewSelector[Order](db).Select(Col("UserId")))
+// --> `id` IN (SELECT `user_id` FROM `order`)
+func (a Aggregate) In(vals ...any) Predicate {
+	return Predicate{
+		left:  a,
+		op:    opIn,
+		right: inOperand(vals),
+	}
+}
+
+// NotIn 参数规则与 In 相同
+func (a Aggregate) NotIn(vals ...any) Predicate {
+	return Predicate{
+		left:  a,
+		op:    opNotIn,
+		right: inOperand(vals),
+	}
+}
+
+// Between e.g. Avg("Age").Between(18, 30) --> AVG(`age`) BETWEEN ? AND ?
+func (a Aggregate) Between(low, high any) Predicate {
+	return Predicate{
+		left:  a,
+		op:    opBetween,
+		right: betweenExpression{low: exprOf(low), high: exprOf(high)},
+	}
+}
+
+func (a Aggregate) NotBetween(low, high any) Predicate {
+	return Predicate{
+		left:  a,
+		op:    opNotBetween,
+		right: betweenExpression{low: exprOf(low), high: exprOf(high)},
+	}
+}
+
+// IsNull e.g. Max("Age").IsNull() --> MAX(`age`) IS NULL
+func (a Aggregate) IsNull() Predicate {
+	return Predicate{
+		left: a,
+		op:   opIsNull,
+	}
+}
+
+func (a Aggregate) IsNotNull() Predicate {
+	return Predicate{
+		left: a,
+		op:   opIsNotNull,
+	}
+}
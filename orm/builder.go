@@ -11,6 +11,35 @@ type builder struct {
 	sqlStrBuilder strings.Builder
 	args          []any
 	quoter        byte
+
+	// shardDst 指定的分片，跨分片执行时为每个分片设置
+	shardDst *Dst
+	// dst 当前语句使用的分片，nil 表示不改写表名
+	dst *Dst
+	// scope 软删除模型的过滤范围
+	scope softDeleteScope
+	// target 联表 UPDATE/DELETE 时用于限定软删除列和版本列的目标表，nil 表示不限定
+	target TableReference
+}
+
+// softDeleteScope 软删除模型的 WHERE 中对软删除列的过滤方式
+type softDeleteScope int
+
+const (
+	// scopeDefault 只包含未删除的行：deleted_at IS NULL
+	scopeDefault softDeleteScope = iota
+	// scopeWithTrashed 包含已删除的行，不过滤
+	scopeWithTrashed
+	// scopeOnlyTrashed 只包含已删除的行：deleted_at IS NOT NULL
+	scopeOnlyTrashed
+)
+
+// reset 清空上一次 Build 的结果。中间件（例如 slowquery、opentelemetry）可能会先调用一次 Build，
+// 每次 Build 开始前 reset 保证多次 Build 得到相同的语句
+func (b *builder) reset() {
+	b.sqlStrBuilder.Reset()
+	b.args = nil
+	b.target = nil
 }
 
 func (b *builder) buildPredicates(ps []Predicate) error {
@@ -73,6 +102,18 @@ func (b *builder) buildExpression(expr Expression) error {
 		b.sqlStrBuilder.WriteString(e.raw)
 		b.args = append(b.args, e.args...)
 		b.sqlStrBuilder.WriteByte(')')
+	case valuesExpression:
+		return b.buildValues(e)
+	case betweenExpression:
+		if err := b.buildExpression(e.low); err != nil {
+			return err
+		}
+		b.sqlStrBuilder.WriteString(" AND ")
+		return b.buildExpression(e.high)
+	case subqueryExpression:
+		return b.buildSubquery(e)
+	case Subquery:
+		return b.buildSubquery(subqueryExpression{qb: e.q})
 	default:
 		return errors.New("orm: 不支持表达式类型")
 	}
@@ -86,15 +127,74 @@ func (b *builder) buildBinaryExpr(e binaryExpression) error {
 		return err
 	}
 	if e.op != "" {
-		b.sqlStrBuilder.WriteString(" " + e.op.String() + " ")
+		// 一元运算符（NOT、EXISTS）没有左操作数，前面不需要空格
+		if e.left != nil {
+			b.sqlStrBuilder.WriteByte(' ')
+		}
+		b.sqlStrBuilder.WriteString(e.op.String())
 	}
 	if e.right != nil {
+		if e.op != "" {
+			b.sqlStrBuilder.WriteByte(' ')
+		}
 		return b.buildSubExpr(e.right)
 	}
 
 	return nil
 }
 
+// buildValues 展开 IN 的值列表，每个值对应一个占位符，e.g. (?,?,?)
+func (b *builder) buildValues(e valuesExpression) error {
+	if len(e.vals) == 0 {
+		return errs.ErrEmptyInValues
+	}
+	b.sqlStrBuilder.WriteByte('(')
+	for idx, val := range e.vals {
+		if idx > 0 {
+			b.sqlStrBuilder.WriteByte(',')
+		}
+		if err := b.buildExpression(exprOf(val)); err != nil {
+			return err
+		}
+	}
+	b.sqlStrBuilder.WriteByte(')')
+	return nil
+}
+
+// subqueryBuilder 能够生成未改写占位符的语句，嵌入外层语句后由外层统一按方言改写占位符
+type subqueryBuilder interface {
+	buildSubquery() (*Query, error)
+}
+
+// buildSubquery 将子查询语句嵌入到当前语句中，e.g. (SELECT ...)
+func (b *builder) buildSubquery(e subqueryExpression) error {
+	b.sqlStrBuilder.WriteByte('(')
+	if err := b.embedQuery(e.qb); err != nil {
+		return err
+	}
+	b.sqlStrBuilder.WriteByte(')')
+	return nil
+}
+
+// embedQuery 将 qb 的语句嵌入到当前语句中，去掉末尾的分号，qb 的参数追加到当前参数之后
+func (b *builder) embedQuery(qb QueryBuilder) error {
+	var (
+		q   *Query
+		err error
+	)
+	if sb, ok := qb.(subqueryBuilder); ok {
+		q, err = sb.buildSubquery()
+	} else {
+		q, err = qb.Build()
+	}
+	if err != nil {
+		return err
+	}
+	b.sqlStrBuilder.WriteString(strings.TrimSuffix(q.SQL, ";"))
+	b.args = append(b.args, q.Args...)
+	return nil
+}
+
 func (b *builder) buildSubExpr(subExpr Expression) error {
 	switch expr := subExpr.(type) {
 	case Predicate:
@@ -134,13 +234,107 @@ func (b *builder) buildColumn(col Column) error {
 			return errs.NewErrUnknownField(col.name)
 		}
 		if t.alias == "" {
-			b.quote(meta.TableName)
+			b.quoteTable(meta)
 			b.sqlStrBuilder.WriteByte('.')
 		} else {
 			b.quote(t.alias)
 			b.sqlStrBuilder.WriteByte('.')
 		}
 		b.quote(field.ColName)
+	case Subquery:
+		colName, err := b.subqueryColumnName(t, col.name)
+		if err != nil {
+			return err
+		}
+		if t.alias != "" {
+			b.quote(t.alias)
+			b.sqlStrBuilder.WriteByte('.')
+		}
+		b.quote(colName)
+		if col.alias != "" {
+			b.sqlStrBuilder.WriteString(" AS ")
+			b.quote(col.alias)
+		}
+	}
+
+	return nil
+}
+
+// buildTable 写入 TableReference，nil 表示模型对应的表
+func (b *builder) buildTable(table TableReference) error {
+	switch typ := table.(type) {
+	case nil:
+		b.quoteTable(b.model)
+	case Table:
+		meta, err := b.r.Get(typ.entity)
+		if err != nil {
+			return err
+		}
+		b.quoteTable(meta)
+		if typ.alias != "" {
+			b.sqlStrBuilder.WriteString(" AS ")
+			b.quote(typ.alias)
+		}
+	case Join:
+		b.sqlStrBuilder.WriteByte('(')
+		// 处理左节点
+		if err := b.buildTable(typ.left); err != nil {
+			return err
+		}
+		// 处理操作
+		b.sqlStrBuilder.WriteString(" " + typ.typ + " ")
+		// 处理右节点
+		if err := b.buildTable(typ.right); err != nil {
+			return err
+		}
+		// 处理Using
+		if len(typ.using) > 0 {
+			b.sqlStrBuilder.WriteString(" USING (")
+			for idx, u := range typ.using {
+				if idx > 0 {
+					b.sqlStrBuilder.WriteString(", ")
+				}
+				if err := b.buildColumn(Col(u)); err != nil {
+					return err
+				}
+			}
+			b.sqlStrBuilder.WriteByte(')') // using的右括号
+		}
+
+		// 处理on部分
+		if len(typ.on) > 0 {
+			b.sqlStrBuilder.WriteString(" ON ")
+			if err := b.buildPredicates(typ.on); err != nil {
+				return err
+			}
+		}
+
+		b.sqlStrBuilder.WriteByte(')') //join的右括号
+	case Subquery:
+		if err := b.buildSubquery(subqueryExpression{qb: typ.q}); err != nil {
+			return err
+		}
+		if typ.alias != "" {
+			b.sqlStrBuilder.WriteString(" AS ")
+			b.quote(typ.alias)
+		}
+	default:
+		return errs.NewErrUnsupportedTable(table)
+	}
+
+	return nil
+}
+
+// buildOrderBy 写入 ORDER BY 后面的排序列
+func (b *builder) buildOrderBy(orderBy []OrderBy) error {
+	for idx, o := range orderBy {
+		if idx > 0 {
+			b.sqlStrBuilder.WriteByte(',')
+		}
+		if err := b.buildColumn(Col(o.col)); err != nil {
+			return err
+		}
+		b.sqlStrBuilder.WriteString(" " + o.order)
 	}
 
 	return nil
@@ -161,6 +355,14 @@ func (b *builder) buildAggregate(a Aggregate) error {
 	return nil
 }
 
+// buildQuery 结束语句的构造，按方言改写占位符
+func (b *builder) buildQuery() *Query {
+	return &Query{
+		SQL:  b.dialect.rebind(b.sqlStrBuilder.String()),
+		Args: b.args,
+	}
+}
+
 func (b *builder) quote(name string) {
 	b.sqlStrBuilder.WriteByte(b.quoter)
 	// 转义内嵌的引号字符，避免表名/列名中包含引号字符时造成 SQL 注入
@@ -177,21 +379,33 @@ func (b *builder) buildAssignment(assign Assignment) error {
 	return b.buildExpression(assign.val)
 }
 
+// softDeleteFiltered 是否需要在 WHERE 中追加软删除列的过滤条件
+func (b *builder) softDeleteFiltered() bool {
+	return b.model != nil && b.model.DeletedAtField != nil && b.scope != scopeWithTrashed
+}
+
 // buildWhereWithSoftDelete 构造 WHERE 子句。
-// 若模型定义了 DeletedAtField，则自动在 WHERE 最前面追加 `<deleted_at> IS NULL` 谓词，
+// 若模型定义了 DeletedAtField，则自动在 WHERE 最前面追加 `<deleted_at> IS NULL` 谓词
+// （OnlyTrashed 时为 IS NOT NULL，WithTrashed/Unscoped 时不追加），
 // 与用户已有的 WHERE 条件以 AND 组合。无 DeletedAtField 时行为与原来完全一致，
 // 即：无 WHERE 条件时不输出 WHERE，有条件时输出 `WHERE <predicates>`。
 func (b *builder) buildWhereWithSoftDelete(where []Predicate) error {
-	hasSoftDelete := b.model != nil && b.model.DeletedAtField != nil
+	hasSoftDelete := b.softDeleteFiltered()
 	hasUserWhere := len(where) > 0
 	if !hasSoftDelete && !hasUserWhere {
 		return nil
 	}
 	b.sqlStrBuilder.WriteString(" WHERE ")
 	if hasSoftDelete {
-		// 使用模型元数据中的列名，避免硬编码 "deleted_at"
-		b.quote(b.model.DeletedAtField.ColName)
-		b.sqlStrBuilder.WriteString(" IS NULL")
+		// 使用模型元数据中的列名，避免硬编码 "deleted_at"；联表时由目标表限定
+		if err := b.buildColumn(Column{name: b.model.DeletedAtField.GoName, table: b.target}); err != nil {
+			return err
+		}
+		if b.scope == scopeOnlyTrashed {
+			b.sqlStrBuilder.WriteString(" IS NOT NULL")
+		} else {
+			b.sqlStrBuilder.WriteString(" IS NULL")
+		}
 	}
 	if hasUserWhere {
 		if hasSoftDelete {
@@ -0,0 +1,566 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+	"Soil/orm/internal/model"
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// bulkValuesAlias UPDATE ... FROM (VALUES ...) 中 VALUES 的别名
+const bulkValuesAlias = "_v"
+
+// BulkUpdater 按主键批量更新多行，每一行更新为各自实例中的值，e.g.
+// NewBulkUpdater[User](db).Values(u1, u2).Columns("Name", "Age").Exec(ctx)。
+// MySQL、SQLite 生成 UPDATE ... SET col=CASE id WHEN ? THEN ? ... END WHERE id IN (...)，
+// Postgres 生成 UPDATE ... SET col=_v.col FROM (VALUES ...) AS _v(...) WHERE id=_v.id。
+// 按模型的主键匹配行，支持联合主键
+type BulkUpdater[T any] struct {
+	builder
+	values []*T
+	// columns 更新的列（结构体字段名），为空时更新除主键与版本字段之外的所有字段
+	columns   []string
+	chunkSize int
+
+	session Session
+}
+
+func NewBulkUpdater[T any](session Session) *BulkUpdater[T] {
+	c := session.getCore()
+	return &BulkUpdater[T]{
+		builder: builder{
+			core:   c,
+			quoter: c.dialect.quoter(),
+		},
+		session: session,
+	}
+}
+
+func (u *BulkUpdater[T]) Values(vals ...*T) *BulkUpdater[T] {
+	u.values = append(u.values, vals...)
+	return u
+}
+
+// Columns 指定更新的列（结构体字段名），主键和版本字段会被忽略
+func (u *BulkUpdater[T]) Columns(cols ...string) *BulkUpdater[T] {
+	u.columns = append(u.columns, cols...)
+	return u
+}
+
+// ChunkSize 与 Inserter.ChunkSize 相同，n>0 且 values 数量超过 n 时，Exec 按 n 分批执行
+func (u *BulkUpdater[T]) ChunkSize(n int) *BulkUpdater[T] {
+	u.chunkSize = n
+	return u
+}
+
+// BulkResult 批量更新的结果
+type BulkResult struct {
+	Result
+	// NotAffected 没有被更新的行的主键，按 Values 的顺序排列：
+	// 行不存在、已经被软删除，或者模型有版本字段并且版本号不匹配
+	NotAffected []any
+}
+
+func (u *BulkUpdater[T]) Build() (*Query, error) {
+	if len(u.values) == 0 {
+		return nil, errs.ErrUpdateZeroRow
+	}
+	var err error
+	if u.model, err = u.r.Get(new(T)); err != nil {
+		return nil, err
+	}
+	u.reset()
+	if err = u.resolveShard(); err != nil {
+		return nil, err
+	}
+	pks, err := primaryKeys(u.model)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := u.fields()
+	if err != nil {
+		return nil, err
+	}
+
+	u.sqlStrBuilder.WriteString("UPDATE ")
+	u.quoteTable(u.model)
+	u.sqlStrBuilder.WriteString(" SET ")
+	if u.dialect.mutationStyle() == mutationFrom {
+		err = u.buildFromValues(pks, fields)
+	} else {
+		err = u.buildCaseWhen(pks, fields)
+	}
+	if err != nil {
+		return nil, err
+	}
+	u.sqlStrBuilder.WriteByte(';')
+	return u.buildQuery(), nil
+}
+
+// fields 需要更新的字段，版本字段由 version=version+1 更新
+func (u *BulkUpdater[T]) fields() ([]*model.Field, error) {
+	fields := u.model.Fields
+	if len(u.columns) > 0 {
+		fields = make([]*model.Field, 0, len(u.columns))
+		for _, col := range u.columns {
+			field, ok := u.model.FieldMap[col]
+			if !ok {
+				return nil, errs.NewErrUnknownField(col)
+			}
+			fields = append(fields, field)
+		}
+	}
+	res := make([]*model.Field, 0, len(fields))
+	for _, field := range fields {
+		if field.PrimaryKey ||
+			(u.model.VersionField != nil && field.GoName == u.model.VersionField.GoName) {
+			continue
+		}
+		res = append(res, field)
+	}
+	if len(res) == 0 && u.model.VersionField == nil {
+		return nil, errs.NewErrUnsupportedFeature("批量更新没有需要更新的列")
+	}
+	return res, nil
+}
+
+// buildCaseWhen e.g. SET `name`=CASE `id` WHEN ? THEN ? WHEN ? THEN ? END WHERE `id` IN (?,?)。
+// 联合主键为 CASE WHEN `a`=? AND `b`=? THEN ? ... END，有版本字段时 WHERE 为 (`id`=? AND `version`=?) OR ...
+func (u *BulkUpdater[T]) buildCaseWhen(pks []*model.Field, fields []*model.Field) error {
+	keys, err := u.keys(u.values)
+	if err != nil {
+		return err
+	}
+
+	for idx, field := range fields {
+		if idx > 0 {
+			u.sqlStrBuilder.WriteByte(',')
+		}
+		u.quote(field.ColName)
+		u.sqlStrBuilder.WriteString("=CASE")
+		if len(pks) == 1 {
+			u.sqlStrBuilder.WriteByte(' ')
+			u.quote(pks[0].ColName)
+		}
+		for j, val := range u.values {
+			fdVal, err := fieldValue(u.valCreator(val, u.model), field)
+			if err != nil {
+				return err
+			}
+			u.sqlStrBuilder.WriteString(" WHEN ")
+			if len(pks) == 1 {
+				u.sqlStrBuilder.WriteByte('?')
+				u.args = append(u.args, keys[j][0])
+			} else {
+				for i, pk := range pks {
+					if i > 0 {
+						u.sqlStrBuilder.WriteString(" AND ")
+					}
+					u.quote(pk.ColName)
+					u.sqlStrBuilder.WriteString("=?")
+					u.args = append(u.args, keys[j][i])
+				}
+			}
+			u.sqlStrBuilder.WriteString(" THEN ?")
+			u.args = append(u.args, fdVal)
+		}
+		u.sqlStrBuilder.WriteString(" END")
+	}
+	if u.model.VersionField != nil {
+		if len(fields) > 0 {
+			u.sqlStrBuilder.WriteByte(',')
+		}
+		u.quote(u.model.VersionField.ColName)
+		u.sqlStrBuilder.WriteByte('=')
+		u.quote(u.model.VersionField.ColName)
+		u.sqlStrBuilder.WriteString("+1")
+	}
+
+	if u.model.VersionField == nil {
+		return u.buildWhereWithSoftDelete([]Predicate{pkPredicate(pks, keys)})
+	}
+	var p Predicate
+	for j, val := range u.values {
+		version, err := readVersionFromVal(val, u.model.VersionField)
+		if err != nil {
+			return err
+		}
+		row := pkRow(pks, keys[j]).And(Col(u.model.VersionField.GoName).EQ(version))
+		if j == 0 {
+			p = row
+		} else {
+			p = p.Or(row)
+		}
+	}
+	return u.buildWhereWithSoftDelete([]Predicate{p})
+}
+
+// buildFromValues e.g. SET "name"="_v"."name" FROM (VALUES (CAST(? AS BIGINT),CAST(? AS VARCHAR)),...)
+// AS "_v"("id","name") WHERE "user"."id"="_v"."id"。
+// 占位符在 VALUES 中无法推断类型，因此每个值都按列类型显式转换
+func (u *BulkUpdater[T]) buildFromValues(pks []*model.Field, fields []*model.Field) error {
+	// VALUES 中的列：主键、更新的列，有版本字段时最后是版本号
+	cols := make([]*model.Field, 0, len(pks)+len(fields)+1)
+	cols = append(cols, pks...)
+	cols = append(cols, fields...)
+	if u.model.VersionField != nil {
+		cols = append(cols, u.model.VersionField)
+	}
+	types := make([]string, 0, len(cols))
+	for _, col := range cols {
+		typ, err := u.dialect.columnType(col)
+		if err != nil {
+			return err
+		}
+		types = append(types, castType(typ))
+	}
+
+	for idx, field := range fields {
+		if idx > 0 {
+			u.sqlStrBuilder.WriteByte(',')
+		}
+		u.quote(field.ColName)
+		u.sqlStrBuilder.WriteByte('=')
+		u.quoteValuesColumn(field)
+	}
+	if u.model.VersionField != nil {
+		if len(fields) > 0 {
+			u.sqlStrBuilder.WriteByte(',')
+		}
+		u.quote(u.model.VersionField.ColName)
+		u.sqlStrBuilder.WriteByte('=')
+		// 与 VALUES 中的同名列区分，需要用表名限定
+		u.quoteTableColumn(u.model.VersionField)
+		u.sqlStrBuilder.WriteString("+1")
+	}
+
+	u.sqlStrBuilder.WriteString(" FROM (VALUES ")
+	for j, val := range u.values {
+		if j > 0 {
+			u.sqlStrBuilder.WriteByte(',')
+		}
+		valDealer := u.valCreator(val, u.model)
+		u.sqlStrBuilder.WriteByte('(')
+		for idx, col := range cols {
+			if idx > 0 {
+				u.sqlStrBuilder.WriteByte(',')
+			}
+			u.sqlStrBuilder.WriteString("CAST(? AS " + types[idx] + ")")
+			fdVal, err := fieldValue(valDealer, col)
+			if err != nil {
+				return err
+			}
+			u.args = append(u.args, fdVal)
+		}
+		u.sqlStrBuilder.WriteByte(')')
+	}
+	u.sqlStrBuilder.WriteString(") AS ")
+	u.quote(bulkValuesAlias)
+	u.sqlStrBuilder.WriteByte('(')
+	for idx, col := range cols {
+		if idx > 0 {
+			u.sqlStrBuilder.WriteByte(',')
+		}
+		u.quote(col.ColName)
+	}
+	u.sqlStrBuilder.WriteByte(')')
+
+	u.sqlStrBuilder.WriteString(" WHERE ")
+	for i, pk := range pks {
+		if i > 0 {
+			u.sqlStrBuilder.WriteString(" AND ")
+		}
+		u.quoteTableColumn(pk)
+		u.sqlStrBuilder.WriteByte('=')
+		u.quoteValuesColumn(pk)
+	}
+	if u.model.VersionField != nil {
+		u.sqlStrBuilder.WriteString(" AND ")
+		u.quoteTableColumn(u.model.VersionField)
+		u.sqlStrBuilder.WriteByte('=')
+		u.quoteValuesColumn(u.model.VersionField)
+	}
+	if u.softDeleteFiltered() {
+		u.sqlStrBuilder.WriteString(" AND ")
+		u.quoteTableColumn(u.model.DeletedAtField)
+		u.sqlStrBuilder.WriteString(" IS NULL")
+	}
+	return nil
+}
+
+// quoteTableColumn e.g. "user"."id"
+func (u *BulkUpdater[T]) quoteTableColumn(field *model.Field) {
+	u.quoteTable(u.model)
+	u.sqlStrBuilder.WriteByte('.')
+	u.quote(field.ColName)
+}
+
+// quoteValuesColumn e.g. "_v"."id"
+func (u *BulkUpdater[T]) quoteValuesColumn(field *model.Field) {
+	u.quote(bulkValuesAlias)
+	u.sqlStrBuilder.WriteByte('.')
+	u.quote(field.ColName)
+}
+
+// castType 去掉 VARCHAR 的长度，避免 CAST 截断字符串
+func castType(typ string) string {
+	if strings.HasPrefix(typ, "VARCHAR(") {
+		return "VARCHAR"
+	}
+	return typ
+}
+
+// Exec 分块执行批量更新。某一块影响的行数少于这一块的行数时，通过主库查询这一块的主键（以及版本号），
+// 找出没有被更新的行记录到 BulkResult.NotAffected。
+// 有版本字段时只有版本号已经加一的行被视为更新成功，冲突不会返回 ErrOptimisticLock。
+// BeforeUpdate 对所有 values 调用，AfterUpdate 只对更新成功的行调用
+func (u *BulkUpdater[T]) Exec(ctx context.Context) BulkResult {
+	var err error
+	u.model, err = u.r.Get(new(T))
+	if err != nil {
+		return BulkResult{Result: Result{err: err}}
+	}
+
+	for _, v := range u.values {
+		if h, ok := any(v).(BeforeUpdate); ok {
+			if e := h.BeforeUpdate(ctx); e != nil {
+				return BulkResult{Result: Result{err: e}}
+			}
+		}
+	}
+
+	// 自动填充 UpdatedAt，指定了 Columns 时追加到更新的列中
+	if u.model.UpdatedAtField != nil {
+		now := time.Now()
+		for _, v := range u.values {
+			if e := setTimestampField(v, u.model.UpdatedAtField, now); e != nil {
+				return BulkResult{Result: Result{err: e}}
+			}
+		}
+		if len(u.columns) > 0 && !u.hasColumn(u.model.UpdatedAtField.GoName) {
+			u.columns = append(u.columns, u.model.UpdatedAtField.GoName)
+		}
+	}
+
+	dsts, groups, err := u.shardingGroups()
+	if err != nil {
+		return BulkResult{Result: Result{err: err}}
+	}
+	var res BulkResult
+	if len(dsts) > 1 {
+		results := make([]BulkResult, len(dsts))
+		qr := execShards(dsts, func(idx int, dst *Dst) *QueryResult {
+			results[idx] = u.shard(dst, groups[idx]).execValues(ctx)
+			return &QueryResult{Result: results[idx].res, Error: results[idx].err}
+		})
+		res.err = qr.Error
+		var rows int64
+		for _, r := range results {
+			if r.res != nil {
+				n, _ := r.res.RowsAffected()
+				rows += n
+			}
+			res.NotAffected = append(res.NotAffected, r.NotAffected...)
+		}
+		res.res = &aggregatedResult{rowsAffected: rows}
+	} else {
+		res = u.execValues(ctx)
+	}
+	if res.err != nil {
+		return res
+	}
+
+	notAffected := make(map[string]struct{}, len(res.NotAffected))
+	for _, key := range res.NotAffected {
+		// 与 pkResult 相反：联合主键是 []any，单列主键是主键值本身
+		vals, composite := key.([]any)
+		if !composite || len(u.model.PrimaryKeys) == 1 {
+			vals = []any{key}
+		}
+		if k, ok := pkKey(vals); ok {
+			notAffected[k] = struct{}{}
+		}
+	}
+	for _, v := range u.values {
+		h, ok := any(v).(AfterUpdate)
+		if !ok {
+			continue
+		}
+		key, e := u.pkValues(v)
+		if e != nil {
+			return BulkResult{Result: Result{err: e}}
+		}
+		if k, ok := pkKey(key); ok {
+			if _, skip := notAffected[k]; skip {
+				continue
+			}
+		}
+		if e = h.AfterUpdate(ctx); e != nil {
+			return BulkResult{Result: Result{err: e}}
+		}
+	}
+	return res
+}
+
+func (u *BulkUpdater[T]) hasColumn(name string) bool {
+	for _, col := range u.columns {
+		if col == name {
+			return true
+		}
+	}
+	return false
+}
+
+// execValues 按 chunkSize 分块执行，某一块失败时停止执行后面的块
+func (u *BulkUpdater[T]) execValues(ctx context.Context) BulkResult {
+	origValues := u.values
+	defer func() { u.values = origValues }()
+
+	var (
+		res       BulkResult
+		totalRows int64
+	)
+	for _, chunk := range splitChunk(origValues, u.chunkSize) {
+		u.values = chunk
+		q, err := u.Build()
+		if err != nil {
+			return BulkResult{Result: Result{err: err}}
+		}
+		qr := exec(ctx, u.core, u.session, &QueryContext{
+			Type:         "UPDATE",
+			QueryBuilder: &chunkQueryBuilder{query: q},
+			Model:        u.model,
+		})
+		if qr.Error != nil {
+			return BulkResult{Result: Result{err: qr.Error}}
+		}
+		var affected int64
+		if sqlRes, ok := qr.Result.(sql.Result); ok && sqlRes != nil {
+			if affected, err = sqlRes.RowsAffected(); err != nil {
+				return BulkResult{Result: Result{err: err}}
+			}
+		}
+		totalRows += affected
+		if affected >= int64(len(chunk)) {
+			continue
+		}
+		keys, err := u.notAffected(ctx, chunk)
+		if err != nil {
+			return BulkResult{Result: Result{err: err}}
+		}
+		res.NotAffected = append(res.NotAffected, keys...)
+	}
+	res.res = &aggregatedResult{rowsAffected: totalRows}
+	return res
+}
+
+// notAffected 查询 chunk 中没有被更新的行的主键。
+// MySQL 的影响行数不包含值没有变化的行，所以只要行存在（有版本字段时版本号已经加一）就视为更新成功。
+// 查询在主库上执行，避免从库延迟
+func (u *BulkUpdater[T]) notAffected(ctx context.Context, chunk []*T) ([]any, error) {
+	keys, err := u.keys(chunk)
+	if err != nil {
+		return nil, err
+	}
+	cols := make([]Selectable, 0, len(u.model.PrimaryKeys)+1)
+	for _, pk := range u.model.PrimaryKeys {
+		cols = append(cols, Col(pk.GoName))
+	}
+	if u.model.VersionField != nil {
+		cols = append(cols, Col(u.model.VersionField.GoName))
+	}
+	sel := NewSelector[T](u.session).Select(cols...).Where(pkPredicate(u.model.PrimaryKeys, keys))
+	sel.shardDst = u.dst
+	rows, err := sel.GetMulti(UsePrimary(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	// 数据库中的主键 --> 版本号
+	found := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		key, err := u.pkValues(row)
+		if err != nil {
+			return nil, err
+		}
+		var version int64
+		if u.model.VersionField != nil {
+			if version, err = readVersionFromVal(row, u.model.VersionField); err != nil {
+				return nil, err
+			}
+		}
+		if k, ok := pkKey(key); ok {
+			found[k] = version
+		}
+	}
+
+	var res []any
+	for j, val := range chunk {
+		k, _ := pkKey(keys[j])
+		version, ok := found[k]
+		if ok && u.model.VersionField != nil {
+			expected, err := readVersionFromVal(val, u.model.VersionField)
+			if err != nil {
+				return nil, err
+			}
+			ok = version == expected+1
+		}
+		if !ok {
+			res = append(res, pkResult(keys[j]))
+		}
+	}
+	return res, nil
+}
+
+// keys 读取 vals 的主键
+func (u *BulkUpdater[T]) keys(vals []*T) ([][]any, error) {
+	keys := make([][]any, 0, len(vals))
+	for _, val := range vals {
+		key, err := u.pkValues(val)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// shardingGroups 按分片对 values 分组，模型没有分片规则时返回 nil
+func (u *BulkUpdater[T]) shardingGroups() ([]Dst, [][]*T, error) {
+	rule, ok := u.shardings[u.model.TableName]
+	if !ok || u.shardDst != nil {
+		return nil, nil, nil
+	}
+	return routeValues(rule, u.core, u.model, u.values)
+}
+
+// resolveShard values 都属于同一个分片时改写表名，否则返回 ErrCrossShard
+func (u *BulkUpdater[T]) resolveShard() error {
+	u.dst = u.shardDst
+	dsts, _, err := u.shardingGroups()
+	if err != nil || dsts == nil {
+		return err
+	}
+	if len(dsts) != 1 {
+		return errs.ErrCrossShard
+	}
+	u.dst = &dsts[0]
+	return nil
+}
+
+// shard 复制一个只更新 dst 分片的 BulkUpdater
+func (u *BulkUpdater[T]) shard(dst *Dst, values []*T) *BulkUpdater[T] {
+	return &BulkUpdater[T]{
+		builder: builder{
+			core:     u.core,
+			quoter:   u.quoter,
+			shardDst: dst,
+		},
+		values:    values,
+		columns:   u.columns,
+		chunkSize: u.chunkSize,
+		session:   u.session,
+	}
+}
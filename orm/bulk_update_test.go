@@ -0,0 +1,146 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkUpdater_Build(t *testing.T) {
+	newDB := func(dialect Dialect) *DB {
+		mockDB, _, err := sqlmock.New()
+		require.NoError(t, err)
+		db, err := OpenDB(mockDB, DBWithDialect(dialect))
+		require.NoError(t, err)
+		return db
+	}
+	mysql, postgres, sqlite := newDB(MySQL), newDB(Postgres), newDB(SQLite)
+
+	testCases := []struct {
+		name      string
+		builder   QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "mysql case when",
+			builder: NewBulkUpdater[TestModel](mysql).Values(
+				&TestModel{Id: 1, FirstName: "Tom", Age: 18},
+				&TestModel{Id: 2, FirstName: "Jerry", Age: 20},
+			).Columns("FirstName", "Age"),
+			wantQuery: &Query{
+				SQL: "UPDATE `test_model` SET `first_name`=CASE `id` WHEN ? THEN ? WHEN ? THEN ? END," +
+					"`age`=CASE `id` WHEN ? THEN ? WHEN ? THEN ? END WHERE `id` IN (?,?);",
+				Args: []any{int64(1), "Tom", int64(2), "Jerry", int64(1), uint8(18), int64(2), uint8(20), int64(1), int64(2)},
+			},
+		},
+		{
+			name: "mysql version and soft delete",
+			builder: NewBulkUpdater[OptLockSoftDeleteModel](mysql).Values(
+				&OptLockSoftDeleteModel{Id: 1, FirstName: "Tom", Version: 3},
+				&OptLockSoftDeleteModel{Id: 2, FirstName: "Jerry", Version: 5},
+			).Columns("FirstName"),
+			wantQuery: &Query{
+				SQL: "UPDATE `opt_lock_soft_delete_model` SET `first_name`=CASE `id` WHEN ? THEN ? WHEN ? THEN ? END," +
+					"`version`=`version`+1 WHERE `deleted_at` IS NULL AND " +
+					"((`id` = ?) AND (`version` = ?)) OR ((`id` = ?) AND (`version` = ?));",
+				Args: []any{int64(1), "Tom", int64(2), "Jerry", int64(1), int64(3), int64(2), int64(5)},
+			},
+		},
+		{
+			name: "sqlite all columns",
+			builder: NewBulkUpdater[TestModel](sqlite).Values(
+				&TestModel{Id: 1, FirstName: "Tom", Age: 18, LastName: "Cat"},
+			),
+			wantQuery: &Query{
+				SQL: "UPDATE `test_model` SET `first_name`=CASE `id` WHEN ? THEN ? END,`age`=CASE `id` WHEN ? THEN ? END," +
+					"`last_name`=CASE `id` WHEN ? THEN ? END WHERE `id` IN (?);",
+				Args: []any{int64(1), "Tom", int64(1), uint8(18), int64(1), "Cat", int64(1)},
+			},
+		},
+		{
+			name: "postgres from values",
+			builder: NewBulkUpdater[TestModel](postgres).Values(
+				&TestModel{Id: 1, FirstName: "Tom", Age: 18},
+				&TestModel{Id: 2, FirstName: "Jerry", Age: 20},
+			).Columns("FirstName", "Age"),
+			wantQuery: &Query{
+				SQL: `UPDATE "test_model" SET "first_name"="_v"."first_name","age"="_v"."age" FROM (VALUES ` +
+					`(CAST($1 AS BIGINT),CAST($2 AS VARCHAR),CAST($3 AS SMALLINT)),` +
+					`(CAST($4 AS BIGINT),CAST($5 AS VARCHAR),CAST($6 AS SMALLINT))) AS "_v"("id","first_name","age") ` +
+					`WHERE "test_model"."id"="_v"."id";`,
+				Args: []any{int64(1), "Tom", uint8(18), int64(2), "Jerry", uint8(20)},
+			},
+		},
+		{
+			name: "postgres version and soft delete",
+			builder: NewBulkUpdater[OptLockSoftDeleteModel](postgres).Values(
+				&OptLockSoftDeleteModel{Id: 1, FirstName: "Tom", Version: 3},
+			).Columns("FirstName"),
+			wantQuery: &Query{
+				SQL: `UPDATE "opt_lock_soft_delete_model" SET "first_name"="_v"."first_name",` +
+					`"version"="opt_lock_soft_delete_model"."version"+1 FROM (VALUES ` +
+					`(CAST($1 AS BIGINT),CAST($2 AS VARCHAR),CAST($3 AS BIGINT))) AS "_v"("id","first_name","version") ` +
+					`WHERE "opt_lock_soft_delete_model"."id"="_v"."id" AND "opt_lock_soft_delete_model"."version"="_v"."version" ` +
+					`AND "opt_lock_soft_delete_model"."deleted_at" IS NULL;`,
+				Args: []any{int64(1), "Tom", 3},
+			},
+		},
+		{
+			name:    "no values",
+			builder: NewBulkUpdater[TestModel](mysql),
+			wantErr: errs.ErrUpdateZeroRow,
+		},
+		{
+			name:    "unknown column",
+			builder: NewBulkUpdater[TestModel](mysql).Values(&TestModel{Id: 1}).Columns("Invalid"),
+			wantErr: errs.NewErrUnknownField("Invalid"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err == nil {
+				assert.Equal(t, tc.wantQuery, q)
+			}
+		})
+	}
+}
+
+func TestBulkUpdater_Exec(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	vals := []*OptLockModel{
+		{Id: 1, FirstName: "a", Version: 1},
+		{Id: 2, FirstName: "b", Version: 1},
+		{Id: 3, FirstName: "c", Version: 2},
+	}
+	// 第一块全部更新成功，不需要查询
+	mock.ExpectExec("UPDATE `opt_lock_model` SET `first_name`=CASE `id` WHEN ? THEN ? WHEN ? THEN ? END," +
+		"`version`=`version`+1 WHERE ((`id` = ?) AND (`version` = ?)) OR ((`id` = ?) AND (`version` = ?));").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	// 第二块没有更新：id=3 的版本号已经被修改
+	mock.ExpectExec("UPDATE `opt_lock_model` SET `first_name`=CASE `id` WHEN ? THEN ? END," +
+		"`version`=`version`+1 WHERE (`id` = ?) AND (`version` = ?);").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT `id`,`version` FROM `opt_lock_model` WHERE `id` IN (?);").
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version"}).AddRow(3, 5))
+
+	res := NewBulkUpdater[OptLockModel](db).Values(vals...).Columns("FirstName").ChunkSize(2).Exec(context.Background())
+	require.NoError(t, res.Err())
+	affected, err := res.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), affected)
+	assert.Equal(t, []any{int64(3)}, res.NotAffected)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -53,3 +53,99 @@ func (c Column) LT(arg any) Predicate {
 		right: exprOf(arg),
 	}
 }
+
+// NE 不等于, e.g. Col("Age").NE(18) --> `age` != ?
+func (c Column) NE(arg any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opNE,
+		right: exprOf(arg),
+	}
+}
+
+// GE 大于等于
+func (c Column) GE(arg any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opGE,
+		right: exprOf(arg),
+	}
+}
+
+// LE 小于等于
+func (c Column) LE(arg any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opLE,
+		right: exprOf(arg),
+	}
+}
+
+// In e.g. Col("Id").In(1, 2, 3) 或 Col("Id").In([]int{1, 2, 3}) --> `id` IN (?,?,?)；
+// 传入 *Selector 时作为子查询：Col("Id").In(NewSelector[Order](db).Select(Col("UserId")))
+// --> `id` IN (SELECT `user_id` FROM `order`)
+func (c Column) In(vals ...any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opIn,
+		right: inOperand(vals),
+	}
+}
+
+// NotIn 参数规则与 In 相同
+func (c Column) NotIn(vals ...any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opNotIn,
+		right: inOperand(vals),
+	}
+}
+
+// Like e.g. Col("FirstName").Like("Tom%") --> `first_name` LIKE ?
+func (c Column) Like(pattern any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opLike,
+		right: exprOf(pattern),
+	}
+}
+
+func (c Column) NotLike(pattern any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opNotLike,
+		right: exprOf(pattern),
+	}
+}
+
+// Between e.g. Col("Age").Between(18, 30) --> `age` BETWEEN ? AND ?
+func (c Column) Between(low, high any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opBetween,
+		right: betweenExpression{low: exprOf(low), high: exprOf(high)},
+	}
+}
+
+func (c Column) NotBetween(low, high any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opNotBetween,
+		right: betweenExpression{low: exprOf(low), high: exprOf(high)},
+	}
+}
+
+// IsNull e.g. Col("Age").IsNull() --> `age` IS NULL
+func (c Column) IsNull() Predicate {
+	return Predicate{
+		left: c,
+		op:   opIsNull,
+	}
+}
+
+func (c Column) IsNotNull() Predicate {
+	return Predicate{
+		left: c,
+		op:   opIsNotNull,
+	}
+}
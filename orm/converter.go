@@ -0,0 +1,312 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+	"Soil/orm/internal/model"
+	"Soil/orm/internal/valuer"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Converter 在字段的值与数据库中的值之间转换，通过 orm:"serializer(name)" 为字段指定。
+// 内置的 Converter 有：
+//   - json：字段序列化为 JSON 字符串，nil 的指针、切片和 map 保存为 NULL
+//   - csv：元素为字符串、数字或者 bool 的切片保存为逗号分隔的字符串，e.g. []int{1,2} 保存为 "1,2"
+//   - unixtime：time.Time 或者 *time.Time 保存为秒级时间戳，零值保存为 0
+//
+// 枚举需要通过 NewEnumConverter 创建之后使用 DBWithConverter 注册
+type Converter = model.Converter
+
+const (
+	SerializerJSON     = "json"
+	SerializerCSV      = "csv"
+	SerializerUnixTime = "unixtime"
+)
+
+// builtinConverters OpenDB 时注册到 DB 的 Converter
+var builtinConverters = map[string]Converter{
+	SerializerJSON:     jsonConverter{},
+	SerializerCSV:      csvConverter{},
+	SerializerUnixTime: unixTimeConverter{},
+}
+
+// DBWithConverter 注册名为 name 的 Converter，与内置的 Converter 同名时覆盖内置的 Converter
+func DBWithConverter(name string, c Converter) DBOption {
+	return func(db *DB) {
+		db.r.RegisterConverter(name, c)
+	}
+}
+
+// fieldValue 读取字段写入数据库的值，字段指定了 Converter 时返回转换之后的值
+func fieldValue(v valuer.Valuer, fd *model.Field) (any, error) {
+	val, err := v.GetFieldValue(fd.GoName)
+	if err != nil || fd.Converter == nil {
+		return val, err
+	}
+	return fd.Converter.Value(val)
+}
+
+type jsonConverter struct{}
+
+func (jsonConverter) Value(val any) (driver.Value, error) {
+	if isNilValue(reflect.ValueOf(val)) {
+		return nil, nil
+	}
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (jsonConverter) Scan(src any, dst any) error {
+	rv := reflect.ValueOf(dst).Elem()
+	// 先清空字段，避免 map 和切片残留之前的数据
+	rv.SetZero()
+	switch s := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(s, dst)
+	case string:
+		return json.Unmarshal([]byte(s), dst)
+	default:
+		return errs.NewErrConvert(SerializerJSON, src)
+	}
+}
+
+type csvConverter struct{}
+
+func (csvConverter) Value(val any) (driver.Value, error) {
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Slice || !isCSVElemKind(rv.Type().Elem().Kind()) {
+		return nil, errs.NewErrConvert(SerializerCSV, val)
+	}
+	if rv.IsNil() {
+		return nil, nil
+	}
+	elems := make([]string, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		elem := fmt.Sprint(rv.Index(i).Interface())
+		// 元素中的逗号无法与分隔符区分
+		if strings.Contains(elem, ",") {
+			return nil, errs.NewErrConvert(SerializerCSV, val)
+		}
+		elems = append(elems, elem)
+	}
+	return strings.Join(elems, ","), nil
+}
+
+func (csvConverter) Scan(src any, dst any) error {
+	rv := reflect.ValueOf(dst).Elem()
+	if rv.Kind() != reflect.Slice || !isCSVElemKind(rv.Type().Elem().Kind()) {
+		return errs.NewErrConvert(SerializerCSV, dst)
+	}
+	var str string
+	switch s := src.(type) {
+	case nil:
+		rv.SetZero()
+		return nil
+	case []byte:
+		str = string(s)
+	case string:
+		str = s
+	default:
+		return errs.NewErrConvert(SerializerCSV, src)
+	}
+	if str == "" {
+		rv.Set(reflect.MakeSlice(rv.Type(), 0, 0))
+		return nil
+	}
+	parts := strings.Split(str, ",")
+	res := reflect.MakeSlice(rv.Type(), len(parts), len(parts))
+	for i, part := range parts {
+		if err := setCSVElem(res.Index(i), part); err != nil {
+			return err
+		}
+	}
+	rv.Set(res)
+	return nil
+}
+
+func isCSVElemKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func setCSVElem(elem reflect.Value, str string) error {
+	switch elem.Kind() {
+	case reflect.String:
+		elem.SetString(str)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return err
+		}
+		elem.SetBool(b)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(str, elem.Type().Bits())
+		if err != nil {
+			return err
+		}
+		elem.SetFloat(f)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(str, 10, elem.Type().Bits())
+		if err != nil {
+			return err
+		}
+		elem.SetInt(n)
+	default:
+		n, err := strconv.ParseUint(str, 10, elem.Type().Bits())
+		if err != nil {
+			return err
+		}
+		elem.SetUint(n)
+	}
+	return nil
+}
+
+type unixTimeConverter struct{}
+
+func (unixTimeConverter) Value(val any) (driver.Value, error) {
+	switch t := val.(type) {
+	case time.Time:
+		return unixOf(t), nil
+	case *time.Time:
+		if t == nil {
+			return nil, nil
+		}
+		return unixOf(*t), nil
+	default:
+		return nil, errs.NewErrConvert(SerializerUnixTime, val)
+	}
+}
+
+func (unixTimeConverter) Scan(src any, dst any) error {
+	var (
+		sec   int64
+		valid = true
+		err   error
+	)
+	switch s := src.(type) {
+	case nil:
+		valid = false
+	case int64:
+		sec = s
+	case float64:
+		sec = int64(s)
+	case []byte:
+		sec, err = strconv.ParseInt(string(s), 10, 64)
+	case string:
+		sec, err = strconv.ParseInt(s, 10, 64)
+	default:
+		return errs.NewErrConvert(SerializerUnixTime, src)
+	}
+	if err != nil {
+		return err
+	}
+
+	var t time.Time
+	if sec != 0 {
+		t = time.Unix(sec, 0)
+	}
+	switch d := dst.(type) {
+	case *time.Time:
+		*d = t
+	case **time.Time:
+		if valid {
+			*d = &t
+		} else {
+			*d = nil
+		}
+	default:
+		return errs.NewErrConvert(SerializerUnixTime, dst)
+	}
+	return nil
+}
+
+// unixOf 零值保存为 0，而不是公元 1 年对应的负数时间戳
+func unixOf(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// NewEnumConverter values 为枚举值与数据库中的值（字符串或者整数）的对应关系，e.g.
+//
+//	orm.DBWithConverter("status", orm.NewEnumConverter(map[Status]any{
+//		StatusActive: "active",
+//		StatusBanned: "banned",
+//	}))
+//
+// 读取时按照数据库中的值的字符串形式匹配，因此 1 与 "1" 被视为同一个值
+func NewEnumConverter[T comparable](values map[T]any) Converter {
+	enums := make(map[string]T, len(values))
+	for enum, val := range values {
+		enums[fmt.Sprint(val)] = enum
+	}
+	return enumConverter[T]{values: values, enums: enums}
+}
+
+type enumConverter[T comparable] struct {
+	values map[T]any
+	// enums key: 数据库中的值的字符串形式
+	enums map[string]T
+}
+
+func (e enumConverter[T]) Value(val any) (driver.Value, error) {
+	enum, ok := val.(T)
+	if !ok {
+		return nil, errs.NewErrConvert("enum", val)
+	}
+	res, ok := e.values[enum]
+	if !ok {
+		return nil, errs.NewErrConvert("enum", val)
+	}
+	return res, nil
+}
+
+func (e enumConverter[T]) Scan(src any, dst any) error {
+	d, ok := dst.(*T)
+	if !ok {
+		return errs.NewErrConvert("enum", dst)
+	}
+	var key string
+	switch s := src.(type) {
+	case nil:
+		var zero T
+		*d = zero
+		return nil
+	case []byte:
+		key = string(s)
+	default:
+		key = fmt.Sprint(s)
+	}
+	enum, ok := e.enums[key]
+	if !ok {
+		return errs.NewErrConvert("enum", src)
+	}
+	*d = enum
+	return nil
+}
+
+func isNilValue(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ConverterStatus int
+
+const (
+	ConverterStatusActive ConverterStatus = iota + 1
+	ConverterStatusBanned
+)
+
+type ConverterProfile struct {
+	City string `json:"city"`
+}
+
+type ConverterModel struct {
+	Id      int64
+	Profile *ConverterProfile `orm:"serializer(json)"`
+	Tags    []string          `orm:"serializer(csv)"`
+	Scores  []int             `orm:"serializer(csv)"`
+	Birth   time.Time         `orm:"serializer(unixtime)"`
+	Status  ConverterStatus   `orm:"serializer(status)"`
+}
+
+func TestConverter(t *testing.T) {
+	birth := time.Unix(946684800, 0)
+	testCases := []struct {
+		name      string
+		converter Converter
+		val       any
+		wantValue driver.Value
+		// dst 指向字段的指针，src 为数据库返回的值
+		dst     any
+		src     any
+		wantDst any
+		wantErr error
+	}{
+		{
+			name:      "json",
+			converter: jsonConverter{},
+			val:       map[string]int{"a": 1},
+			wantValue: `{"a":1}`,
+			dst:       &map[string]int{"b": 2},
+			src:       []byte(`{"a":1}`),
+			wantDst:   &map[string]int{"a": 1},
+		},
+		{
+			name:      "json nil",
+			converter: jsonConverter{},
+			val:       (*ConverterProfile)(nil),
+			dst:       &[]int{1},
+			wantDst:   new([]int),
+		},
+		{
+			name:      "csv",
+			converter: csvConverter{},
+			val:       []int{1, 2},
+			wantValue: "1,2",
+			dst:       new([]int),
+			src:       []byte("1,2"),
+			wantDst:   &[]int{1, 2},
+		},
+		{
+			name:      "csv empty",
+			converter: csvConverter{},
+			val:       []string{},
+			wantValue: "",
+			dst:       new([]string),
+			src:       "",
+			wantDst:   &[]string{},
+		},
+		{
+			name:      "csv comma",
+			converter: csvConverter{},
+			val:       []string{"a,b"},
+			wantErr:   errs.ErrConvert,
+		},
+		{
+			name:      "csv unsupported",
+			converter: csvConverter{},
+			val:       map[string]int{},
+			wantErr:   errs.ErrConvert,
+		},
+		{
+			name:      "unixtime",
+			converter: unixTimeConverter{},
+			val:       birth,
+			wantValue: int64(946684800),
+			dst:       new(time.Time),
+			src:       int64(946684800),
+			wantDst:   &birth,
+		},
+		{
+			name:      "unixtime zero",
+			converter: unixTimeConverter{},
+			val:       time.Time{},
+			wantValue: int64(0),
+			dst:       new(time.Time),
+			src:       []byte("0"),
+			wantDst:   new(time.Time),
+		},
+		{
+			name:      "unixtime nil pointer",
+			converter: unixTimeConverter{},
+			val:       (*time.Time)(nil),
+			dst:       &[]*time.Time{&birth}[0],
+			wantDst:   new(*time.Time),
+		},
+		{
+			name:      "enum",
+			converter: NewEnumConverter(map[ConverterStatus]any{ConverterStatusActive: "active"}),
+			val:       ConverterStatusActive,
+			wantValue: "active",
+			dst:       new(ConverterStatus),
+			src:       []byte("active"),
+			wantDst:   &[]ConverterStatus{ConverterStatusActive}[0],
+		},
+		{
+			name:      "enum int",
+			converter: NewEnumConverter(map[ConverterStatus]any{ConverterStatusBanned: 9}),
+			val:       ConverterStatusBanned,
+			wantValue: 9,
+			dst:       new(ConverterStatus),
+			src:       int64(9),
+			wantDst:   &[]ConverterStatus{ConverterStatusBanned}[0],
+		},
+		{
+			name:      "enum unknown",
+			converter: NewEnumConverter(map[ConverterStatus]any{ConverterStatusActive: "active"}),
+			val:       ConverterStatusBanned,
+			wantErr:   errs.ErrConvert,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			val, err := tc.converter.Value(tc.val)
+			assert.True(t, errors.Is(err, tc.wantErr), "err: %v", err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantValue, val)
+			require.NoError(t, tc.converter.Scan(tc.src, tc.dst))
+			assert.Equal(t, tc.wantDst, tc.dst)
+		})
+	}
+}
+
+func TestConverter_Model(t *testing.T) {
+	birth := time.Unix(946684800, 0)
+	status := DBWithConverter("status", NewEnumConverter(map[ConverterStatus]any{
+		ConverterStatusActive: "active",
+		ConverterStatusBanned: "banned",
+	}))
+
+	for name, opts := range map[string][]DBOption{
+		"unsafe":  {status},
+		"reflect": {status, DBUseReflect()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+			db, err := OpenDB(mockDB, opts...)
+			require.NoError(t, err)
+
+			mock.ExpectExec("INSERT INTO `converter_model`(`id`,`profile`,`tags`,`scores`,`birth`,`status`) VALUES (?,?,?,?,?,?);").
+				WithArgs(int64(1), `{"city":"Paris"}`, "go,orm", nil, int64(946684800), "active").
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectExec("UPDATE `converter_model` SET `status`=?,`tags`=? WHERE `id` = ?;").
+				WithArgs("banned", "sql", int64(1)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery("SELECT * FROM `converter_model` WHERE `id` = ?;").
+				WithArgs(int64(1)).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "profile", "tags", "scores", "birth", "status"}).
+					AddRow(int64(1), []byte(`{"city":"Paris"}`), []byte("sql"), nil, int64(946684800), []byte("banned")))
+
+			val := &ConverterModel{
+				Id:      1,
+				Profile: &ConverterProfile{City: "Paris"},
+				Tags:    []string{"go", "orm"},
+				Birth:   birth,
+				Status:  ConverterStatusActive,
+			}
+			ctx := context.Background()
+			require.NoError(t, NewInserter[ConverterModel](db).Values(val).Exec(ctx).Err())
+
+			val.Tags = []string{"sql"}
+			require.NoError(t, NewUpdater[ConverterModel](db).Update(val).
+				Set(Assign("Status", ConverterStatusBanned), Col("Tags")).
+				Where(Col("Id").EQ(int64(1))).Exec(ctx).Err())
+
+			got, err := NewSelector[ConverterModel](db).Where(Col("Id").EQ(int64(1))).Get(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, &ConverterModel{
+				Id:      1,
+				Profile: &ConverterProfile{City: "Paris"},
+				Tags:    []string{"sql"},
+				Birth:   birth,
+				Status:  ConverterStatusBanned,
+			}, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -12,4 +12,8 @@ type core struct {
 	valCreator  valuer.Creator
 	dialect     Dialect
 	middlewares []Middleware
+	// shardings 分片规则，key: 模型的表名
+	shardings map[string]*shardingRule
+	// preloadBatchSize 预加载时每条 IN 查询最多包含的键的个数，小于等于 0 时使用 defaultPreloadBatchSize
+	preloadBatchSize int
 }
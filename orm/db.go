@@ -14,7 +14,12 @@ import (
 type DBOption func(*DB)
 
 type DB struct {
+	// db 主库
 	db *sql.DB
+	// replicas 只读副本，nil 表示没有副本
+	replicas *replicaGroup
+	// shardingRules DBWithSharding 设置的分片规则，OpenDB 时校验并索引到 core.shardings
+	shardingRules []*shardingRule
 	core
 }
 
@@ -37,10 +42,19 @@ func OpenDB(db *sql.DB, opts ...DBOption) (*DB, error) {
 		},
 		db: db,
 	}
+	for name, c := range builtinConverters {
+		res.r.RegisterConverter(name, c)
+	}
 
 	for _, opt := range opts {
 		opt(res)
 	}
+	if err := res.initSharding(); err != nil {
+		return nil, err
+	}
+	if res.replicas != nil {
+		res.replicas.startHealthCheck()
+	}
 
 	return res, nil
 }
@@ -61,14 +75,38 @@ func (db *DB) getCore() core {
 	return db.core
 }
 
+// queryContext ctx 中有同一个 DB 开启的事务时在该事务中执行，
+// 因此在 DoTx 的回调中使用 DB 构造的查询也会加入事务
 func (db *DB) queryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if tx, ok := txFromContext(ctx, db); ok {
+		return tx.queryContext(ctx, query, args...)
+	}
 	return db.db.QueryContext(ctx, query, args...)
 }
 
+// readQueryContext 执行只读查询，没有事务且 ctx 没有要求使用主库时发往副本
+func (db *DB) readQueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if db.replicas == nil || usePrimary(ctx) {
+		return db.queryContext(ctx, query, args...)
+	}
+	if _, ok := txFromContext(ctx, db); ok {
+		return db.queryContext(ctx, query, args...)
+	}
+	r := db.replicas.pick()
+	if r == nil {
+		return db.queryContext(ctx, query, args...)
+	}
+	return db.replicas.queryContext(ctx, r, query, args...)
+}
+
 func (db *DB) execContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if tx, ok := txFromContext(ctx, db); ok {
+		return tx.execContext(ctx, query, args...)
+	}
 	return db.db.ExecContext(ctx, query, args...)
 }
 
+// BeginTx 总是开启一个新的事务，不受 ctx 中已有事务的影响
 func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
 	tx, err := db.db.BeginTx(ctx, opts)
 	if err != nil {
@@ -80,7 +118,31 @@ func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
 	}, nil
 }
 
+// DoTx 在事务中执行 fn，传播行为为 PropagationRequired：ctx 中已经有同一个 DB 开启的事务时加入该事务，
+// 否则开启新事务。fn 收到的 ctx 中携带了当前事务，使用该 ctx 执行的查询（包括使用 DB 构造的查询）都在事务中执行
 func (db *DB) DoTx(ctx context.Context,
+	fn func(ctx context.Context, tx *Tx) error,
+	opts *sql.TxOptions) error {
+	return db.DoTxWithPropagation(ctx, PropagationRequired, fn, opts)
+}
+
+// DoTxWithPropagation 按照 propagation 指定的传播行为在事务中执行 fn。
+// 加入已有事务或者使用保存点时 opts 不生效
+func (db *DB) DoTxWithPropagation(ctx context.Context, propagation Propagation,
+	fn func(ctx context.Context, tx *Tx) error,
+	opts *sql.TxOptions) error {
+	if tx, ok := txFromContext(ctx, db); ok {
+		switch propagation {
+		case PropagationRequired:
+			return tx.join(ctx, fn)
+		case PropagationNested:
+			return tx.DoTx(ctx, fn)
+		}
+	}
+	return db.doNewTx(ctx, fn, opts)
+}
+
+func (db *DB) doNewTx(ctx context.Context,
 	fn func(ctx context.Context, tx *Tx) error,
 	opts *sql.TxOptions) (err error) {
 	var tx *Tx
@@ -91,6 +153,9 @@ func (db *DB) DoTx(ctx context.Context,
 
 	panicked := true
 	defer func() {
+		if !panicked && err == nil && tx.rollbackOnly {
+			err = errs.ErrTxRollbackOnly
+		}
 		if panicked || err != nil {
 			e := tx.Rollback()
 			if e != nil {
@@ -101,14 +166,21 @@ func (db *DB) DoTx(ctx context.Context,
 		}
 	}()
 
-	err = fn(ctx, tx)
+	err = fn(withTx(ctx, tx), tx)
 	panicked = false
 
 	return err
 }
 
+// Close 关闭主库与所有副本，并停止健康检查
 func (db *DB) Close() error {
-	return db.db.Close()
+	err := db.db.Close()
+	if db.replicas != nil {
+		if e := db.replicas.close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
 }
 
 // SetMaxOpenConns 设置数据库的最大打开连接数，委托给底层 *sql.DB
@@ -15,6 +15,9 @@ func Test_DB(t *testing.T) {
 		Age       int8
 		LastName  string
 	}
+	type ColumnTag struct {
+		ID uint64 `orm:"column(id_t)"`
+	}
 
 	testCases := []struct {
 		name      string
@@ -29,82 +32,108 @@ func Test_DB(t *testing.T) {
 			val:  &TestModel{},
 			wantModel: &model.Model{
 				TableName: "test_model",
+				Type:      reflect.TypeOf(TestModel{}),
 				FieldMap: map[string]*model.Field{
 					"Id": {
-						ColName: "id",
-						Type:    reflect.TypeOf(int64(0)),
-						GoName:  "Id",
-						Offset:  0,
+						ColName:    "id",
+						Type:       reflect.TypeOf(int64(0)),
+						GoName:     "Id",
+						Offset:     0,
+						Index:      []int{0},
+						PrimaryKey: true,
 					},
 					"FirstName": {
 						ColName: "first_name",
 						Type:    reflect.TypeOf(""),
 						GoName:  "FirstName",
 						Offset:  8,
+						Index:   []int{1},
 					},
 					"Age": {
 						ColName: "age",
 						Type:    reflect.TypeOf(int8(0)),
 						GoName:  "Age",
 						Offset:  24,
+						Index:   []int{2},
 					},
 					"LastName": {
 						ColName: "last_name",
 						Type:    reflect.TypeOf(""),
 						GoName:  "LastName",
 						Offset:  32,
+						Index:   []int{3},
 					},
 				},
 				ColumnMap: map[string]*model.Field{
 					"id": {
-						ColName: "id",
-						Type:    reflect.TypeOf(int64(0)),
-						GoName:  "Id",
-						Offset:  0,
+						ColName:    "id",
+						Type:       reflect.TypeOf(int64(0)),
+						GoName:     "Id",
+						Offset:     0,
+						Index:      []int{0},
+						PrimaryKey: true,
 					},
 					"first_name": {
 						ColName: "first_name",
 						Type:    reflect.TypeOf(""),
 						GoName:  "FirstName",
 						Offset:  8,
+						Index:   []int{1},
 					},
 					"age": {
 						ColName: "age",
 						Type:    reflect.TypeOf(int8(0)),
 						GoName:  "Age",
 						Offset:  24,
+						Index:   []int{2},
 					},
 					"last_name": {
 						ColName: "last_name",
 						Type:    reflect.TypeOf(""),
 						GoName:  "LastName",
 						Offset:  32,
+						Index:   []int{3},
 					},
 				},
 				Fields: []*model.Field{
 					{
-						ColName: "id",
-						Type:    reflect.TypeOf(int64(0)),
-						GoName:  "Id",
-						Offset:  0,
+						ColName:    "id",
+						Type:       reflect.TypeOf(int64(0)),
+						GoName:     "Id",
+						Offset:     0,
+						Index:      []int{0},
+						PrimaryKey: true,
 					},
 					{
 						ColName: "first_name",
 						Type:    reflect.TypeOf(""),
 						GoName:  "FirstName",
 						Offset:  8,
+						Index:   []int{1},
 					},
 					{
 						ColName: "age",
 						Type:    reflect.TypeOf(int8(0)),
 						GoName:  "Age",
 						Offset:  24,
+						Index:   []int{2},
 					},
 					{
 						ColName: "last_name",
 						Type:    reflect.TypeOf(""),
 						GoName:  "LastName",
 						Offset:  32,
+						Index:   []int{3},
+					},
+				},
+				PrimaryKeys: []*model.Field{
+					{
+						ColName:    "id",
+						Type:       reflect.TypeOf(int64(0)),
+						GoName:     "Id",
+						Offset:     0,
+						Index:      []int{0},
+						PrimaryKey: true,
 					},
 				},
 			},
@@ -114,45 +143,44 @@ func Test_DB(t *testing.T) {
 			val:  &TestModel{},
 			wantModel: &model.Model{
 				TableName: "test_model",
+				Type:      reflect.TypeOf(TestModel{}),
 				FieldMap: map[string]*model.Field{
-					"Id":        {ColName: "id", Type: reflect.TypeOf(int64(0)), GoName: "Id", Offset: 0},
-					"FirstName": {ColName: "first_name", Type: reflect.TypeOf(""), GoName: "FirstName", Offset: 8},
-					"Age":       {ColName: "age", Type: reflect.TypeOf(int8(0)), GoName: "Age", Offset: 24},
-					"LastName":  {ColName: "last_name", Type: reflect.TypeOf(""), GoName: "LastName", Offset: 32},
+					"Id":        {ColName: "id", Type: reflect.TypeOf(int64(0)), GoName: "Id", Offset: 0, Index: []int{0}, PrimaryKey: true},
+					"FirstName": {ColName: "first_name", Type: reflect.TypeOf(""), GoName: "FirstName", Offset: 8, Index: []int{1}},
+					"Age":       {ColName: "age", Type: reflect.TypeOf(int8(0)), GoName: "Age", Offset: 24, Index: []int{2}},
+					"LastName":  {ColName: "last_name", Type: reflect.TypeOf(""), GoName: "LastName", Offset: 32, Index: []int{3}},
 				},
 				ColumnMap: map[string]*model.Field{
-					"id":         {ColName: "id", Type: reflect.TypeOf(int64(0)), GoName: "Id", Offset: 0},
-					"first_name": {ColName: "first_name", Type: reflect.TypeOf(""), GoName: "FirstName", Offset: 8},
-					"age":        {ColName: "age", Type: reflect.TypeOf(int8(0)), GoName: "Age", Offset: 24},
-					"last_name":  {ColName: "last_name", Type: reflect.TypeOf(""), GoName: "LastName", Offset: 32},
+					"id":         {ColName: "id", Type: reflect.TypeOf(int64(0)), GoName: "Id", Offset: 0, Index: []int{0}, PrimaryKey: true},
+					"first_name": {ColName: "first_name", Type: reflect.TypeOf(""), GoName: "FirstName", Offset: 8, Index: []int{1}},
+					"age":        {ColName: "age", Type: reflect.TypeOf(int8(0)), GoName: "Age", Offset: 24, Index: []int{2}},
+					"last_name":  {ColName: "last_name", Type: reflect.TypeOf(""), GoName: "LastName", Offset: 32, Index: []int{3}},
 				},
 				Fields: []*model.Field{
-					{ColName: "id", Type: reflect.TypeOf(int64(0)), GoName: "Id", Offset: 0},
-					{ColName: "first_name", Type: reflect.TypeOf(""), GoName: "FirstName", Offset: 8},
-					{ColName: "age", Type: reflect.TypeOf(int8(0)), GoName: "Age", Offset: 24},
-					{ColName: "last_name", Type: reflect.TypeOf(""), GoName: "LastName", Offset: 32},
+					{ColName: "id", Type: reflect.TypeOf(int64(0)), GoName: "Id", Offset: 0, Index: []int{0}, PrimaryKey: true},
+					{ColName: "first_name", Type: reflect.TypeOf(""), GoName: "FirstName", Offset: 8, Index: []int{1}},
+					{ColName: "age", Type: reflect.TypeOf(int8(0)), GoName: "Age", Offset: 24, Index: []int{2}},
+					{ColName: "last_name", Type: reflect.TypeOf(""), GoName: "LastName", Offset: 32, Index: []int{3}},
+				},
+				PrimaryKeys: []*model.Field{
+					{ColName: "id", Type: reflect.TypeOf(int64(0)), GoName: "Id", Offset: 0, Index: []int{0}, PrimaryKey: true},
 				},
 			},
 		},
 		{
 			name: "column tag",
-			// 函数最后有()立即调用函数表达式（IIFE）
-			val: func() any {
-				type ColumnTag struct {
-					ID uint64 `orm:"column(id_t)"`
-				}
-				return &ColumnTag{}
-			}(),
+			val:  &ColumnTag{},
 			wantModel: &model.Model{
 				TableName: "column_tag",
+				Type:      reflect.TypeOf(ColumnTag{}),
 				FieldMap: map[string]*model.Field{
-					"ID": &model.Field{ColName: "id_t", Type: reflect.TypeOf(uint64(0)), GoName: "ID", Offset: 0},
+					"ID": &model.Field{ColName: "id_t", Type: reflect.TypeOf(uint64(0)), GoName: "ID", Offset: 0, Index: []int{0}},
 				},
 				ColumnMap: map[string]*model.Field{
-					"id_t": &model.Field{ColName: "id_t", Type: reflect.TypeOf(uint64(0)), GoName: "ID", Offset: 0},
+					"id_t": &model.Field{ColName: "id_t", Type: reflect.TypeOf(uint64(0)), GoName: "ID", Offset: 0, Index: []int{0}},
 				},
 				Fields: []*model.Field{
-					&model.Field{ColName: "id_t", Type: reflect.TypeOf(uint64(0)), GoName: "ID", Offset: 0},
+					&model.Field{ColName: "id_t", Type: reflect.TypeOf(uint64(0)), GoName: "ID", Offset: 0, Index: []int{0}},
 				},
 			},
 		},
@@ -163,14 +191,15 @@ func Test_DB(t *testing.T) {
 			},
 			wantModel: &model.Model{
 				TableName: "test_custom_table_name_t",
+				Type:      reflect.TypeOf(CustomTableName{}),
 				FieldMap: map[string]*model.Field{
-					"FirstName": &model.Field{ColName: "first_name", Type: reflect.TypeOf(""), GoName: "FirstName", Offset: 0},
+					"FirstName": &model.Field{ColName: "first_name", Type: reflect.TypeOf(""), GoName: "FirstName", Offset: 0, Index: []int{0}},
 				},
 				ColumnMap: map[string]*model.Field{
-					"first_name": &model.Field{ColName: "first_name", Type: reflect.TypeOf(""), GoName: "FirstName", Offset: 0},
+					"first_name": &model.Field{ColName: "first_name", Type: reflect.TypeOf(""), GoName: "FirstName", Offset: 0, Index: []int{0}},
 				},
 				Fields: []*model.Field{
-					&model.Field{ColName: "first_name", Type: reflect.TypeOf(""), GoName: "FirstName", Offset: 0},
+					&model.Field{ColName: "first_name", Type: reflect.TypeOf(""), GoName: "FirstName", Offset: 0, Index: []int{0}},
 				},
 			},
 		},
@@ -224,22 +253,22 @@ func Test_With_XXX(t *testing.T) {
 			wantModel: &model.Model{
 				TableName: "with_table_name_test",
 				FieldMap: map[string]*model.Field{
-					"Id":        {ColName: "id_user", Type: reflect.TypeOf(int64(0)), GoName: "Id", Offset: 0},
-					"FirstName": {ColName: "first_name", Type: reflect.TypeOf(""), GoName: "FirstName", Offset: 8},
-					"Age":       {ColName: "age", Type: reflect.TypeOf(uint8(0)), GoName: "Age", Offset: 24},
-					"LastName":  {ColName: "last_name", Type: reflect.TypeOf(""), GoName: "LastName", Offset: 32},
+					"Id":        {ColName: "id_user", Type: reflect.TypeOf(int64(0)), GoName: "Id", Offset: 0, Index: []int{0}, PrimaryKey: true},
+					"FirstName": {ColName: "first_name", Type: reflect.TypeOf(""), GoName: "FirstName", Offset: 8, Index: []int{1}},
+					"Age":       {ColName: "age", Type: reflect.TypeOf(uint8(0)), GoName: "Age", Offset: 24, Index: []int{2}},
+					"LastName":  {ColName: "last_name", Type: reflect.TypeOf(""), GoName: "LastName", Offset: 32, Index: []int{3}},
 				},
 				ColumnMap: map[string]*model.Field{
-					"id_user":    {ColName: "id_user", Type: reflect.TypeOf(int64(0)), GoName: "Id", Offset: 0},
-					"first_name": {ColName: "first_name", Type: reflect.TypeOf(""), GoName: "FirstName", Offset: 8},
-					"age":        {ColName: "age", Type: reflect.TypeOf(uint8(0)), GoName: "Age", Offset: 24},
-					"last_name":  {ColName: "last_name", Type: reflect.TypeOf(""), GoName: "LastName", Offset: 32},
+					"id_user":    {ColName: "id_user", Type: reflect.TypeOf(int64(0)), GoName: "Id", Offset: 0, Index: []int{0}, PrimaryKey: true},
+					"first_name": {ColName: "first_name", Type: reflect.TypeOf(""), GoName: "FirstName", Offset: 8, Index: []int{1}},
+					"age":        {ColName: "age", Type: reflect.TypeOf(uint8(0)), GoName: "Age", Offset: 24, Index: []int{2}},
+					"last_name":  {ColName: "last_name", Type: reflect.TypeOf(""), GoName: "LastName", Offset: 32, Index: []int{3}},
 				},
 				Fields: []*model.Field{
-					{ColName: "id_user", Type: reflect.TypeOf(int64(0)), GoName: "Id", Offset: 0},
-					{ColName: "first_name", Type: reflect.TypeOf(""), GoName: "FirstName", Offset: 8},
-					{ColName: "age", Type: reflect.TypeOf(uint8(0)), GoName: "Age", Offset: 24},
-					{ColName: "last_name", Type: reflect.TypeOf(""), GoName: "LastName", Offset: 32},
+					{ColName: "id_user", Type: reflect.TypeOf(int64(0)), GoName: "Id", Offset: 0, Index: []int{0}, PrimaryKey: true},
+					{ColName: "first_name", Type: reflect.TypeOf(""), GoName: "FirstName", Offset: 8, Index: []int{1}},
+					{ColName: "age", Type: reflect.TypeOf(uint8(0)), GoName: "Age", Offset: 24, Index: []int{2}},
+					{ColName: "last_name", Type: reflect.TypeOf(""), GoName: "LastName", Offset: 32, Index: []int{3}},
 				},
 			},
 		},
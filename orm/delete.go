@@ -10,6 +10,14 @@ type Deleter[T any] struct {
 	builder
 	tableName string
 	where     []Predicate
+	// table 联表删除时的 TableReference，nil 表示只涉及模型对应的表
+	table   TableReference
+	orderBy []OrderBy
+	limit   int
+	// vals Delete 传入的模型实例，用于调用钩子，没有 Where 时按主键删除这些实例
+	vals []*T
+	// unscoped 软删除模型也执行物理删除
+	unscoped bool
 
 	session Session
 }
@@ -24,68 +32,116 @@ func (d *Deleter[T]) Build() (*Query, error) {
 	if err != nil {
 		return nil, err
 	}
+	d.reset()
+	where, err := d.predicates()
+	if err != nil {
+		return nil, err
+	}
+	if d.tableName == "" {
+		if err = d.resolveShard(where); err != nil {
+			return nil, err
+		}
+	}
+	m := &mutation{table: d.table, orderBy: d.orderBy, limit: d.limit}
+	if err = d.prepareMutation(m); err != nil {
+		return nil, err
+	}
 
 	// 软删除：若模型定义了 DeletedAtField，将 DELETE 改写为
 	// `UPDATE <table> SET deleted_at=? WHERE deleted_at IS NULL [AND <user where>]`。
-	if d.model.DeletedAtField != nil {
-		return d.buildSoftDelete()
+	// Unscoped 时执行物理删除，且不过滤已经软删除的行。
+	if d.model.DeletedAtField != nil && !d.unscoped {
+		return d.buildSoftDelete(m, where)
 	}
 
-	d.sqlStrBuilder.WriteString("DELETE FROM ")
-
 	//处理FROM
-	if d.tableName == "" {
-		// 用户没有调用From, 直接使用泛型的类型名
-		d.quote(d.model.TableName)
+	if m.joined() && m.style == mutationJoin {
+		// 直接联表时需要指明删除哪个表的行，e.g. DELETE `a` FROM `a` JOIN `b` ON ...
+		d.sqlStrBuilder.WriteString("DELETE ")
+		d.buildTargetName(m)
+		d.sqlStrBuilder.WriteString(" FROM ")
+		if err = d.buildTable(m.table); err != nil {
+			return nil, err
+		}
 	} else {
-		//用户调用了From, 用户传入什么就使用什么
-		d.sqlStrBuilder.WriteString(d.tableName)
+		// 用户调用了From, 用户传入什么就使用什么，否则使用泛型的类型名
+		d.sqlStrBuilder.WriteString("DELETE FROM ")
+		if err = d.buildMutationTable(m, d.tableName); err != nil {
+			return nil, err
+		}
+	}
+	if err = d.buildMutationFrom(m, "USING"); err != nil {
+		return nil, err
 	}
 
 	//处理WHERE
-	if len(d.where) > 0 {
-		d.sqlStrBuilder.WriteString(" WHERE ")
-		if err = d.buildPredicates(d.where); err != nil {
-			return nil, err
-		}
+	if _, err = d.buildMutationWhere(m, where); err != nil {
+		return nil, err
 	}
 
+	if err = d.buildMutationLimit(m, false); err != nil {
+		return nil, err
+	}
 	d.sqlStrBuilder.WriteByte(';')
-	return &Query{
-		SQL:  d.sqlStrBuilder.String(),
-		Args: d.args,
-	}, nil
+	return d.buildQuery(), nil
 }
 
 // buildSoftDelete 生成软删除改写后的 UPDATE 语句。
-// 将 deleted_at 列设置为当前时间，并通过 buildWhereWithSoftDelete 追加
+// 将 deleted_at 列设置为当前时间，并通过 buildMutationWhere 追加
 // `deleted_at IS NULL` 过滤，避免重复软删除已删除的行。
-func (d *Deleter[T]) buildSoftDelete() (*Query, error) {
+func (d *Deleter[T]) buildSoftDelete(m *mutation, where []Predicate) (*Query, error) {
 	d.sqlStrBuilder.WriteString("UPDATE ")
-	if d.tableName == "" {
-		d.quote(d.model.TableName)
-	} else {
-		d.sqlStrBuilder.WriteString(d.tableName)
+	if err := d.buildMutationTable(m, d.tableName); err != nil {
+		return nil, err
 	}
 	d.sqlStrBuilder.WriteString(" SET ")
-	d.quote(d.model.DeletedAtField.ColName)
+	if err := d.buildColumn(m.setColumn(d.model.DeletedAtField.GoName)); err != nil {
+		return nil, err
+	}
 	d.sqlStrBuilder.WriteString("=?")
 	d.args = append(d.args, time.Now())
+	if err := d.buildMutationFrom(m, "FROM"); err != nil {
+		return nil, err
+	}
 
-	if err := d.buildWhereWithSoftDelete(d.where); err != nil {
+	if _, err := d.buildMutationWhere(m, where); err != nil {
 		return nil, err
 	}
 
+	if err := d.buildMutationLimit(m, false); err != nil {
+		return nil, err
+	}
 	d.sqlStrBuilder.WriteByte(';')
-	return &Query{
-		SQL:  d.sqlStrBuilder.String(),
-		Args: d.args,
-	}, nil
+	return d.buildQuery(), nil
 }
 
+// From 指定表名，原样写入语句，只能用于单表删除
 func (d *Deleter[T]) From(tableName string) *Deleter[T] {
 	d.tableName = tableName
+	d.table = nil
+
+	return d
+}
+
+// FromTable 联表删除，tbl 中模型对应的表是被删除的表，e.g.
+// NewDeleter[Order](db).FromTable(TableOf(&Order{}).Join(TableOf(&User{})).On(...)).Where(...)。
+// MySQL 生成 DELETE ... FROM ... JOIN，Postgres 生成 DELETE ... USING，SQLite 改写为按 rowid 匹配子查询。
+// 与 From 互斥，后调用的生效
+func (d *Deleter[T]) FromTable(tbl TableReference) *Deleter[T] {
+	d.table = tbl
+	d.tableName = ""
+	return d
+}
 
+// OrderBy 与 Limit 一起使用，只删除排序后的前若干行。Postgres 以及 MySQL 联表删除时不支持
+func (d *Deleter[T]) OrderBy(orderBys ...OrderBy) *Deleter[T] {
+	d.orderBy = orderBys
+	return d
+}
+
+// Limit 最多删除 limit 行
+func (d *Deleter[T]) Limit(limit int) *Deleter[T] {
+	d.limit = limit
 	return d
 }
 
@@ -96,6 +152,40 @@ func (d *Deleter[T]) Where(p ...Predicate) *Deleter[T] {
 	return d
 }
 
+// Delete 删除指定的模型实例，会对每个实例调用 BeforeDelete/AfterDelete 钩子。
+// 没有调用 Where 时按实例的主键生成 WHERE id IN (...)，联合主键为 (a = ? AND b = ?) OR ...；调用了 Where 时以 Where 为准，实例只用于钩子
+func (d *Deleter[T]) Delete(vals ...*T) *Deleter[T] {
+	d.vals = append(d.vals, vals...)
+	return d
+}
+
+// Unscoped 对软删除模型执行物理删除（DELETE 而不是 UPDATE deleted_at），并且已经软删除的行也会被删除
+func (d *Deleter[T]) Unscoped() *Deleter[T] {
+	d.unscoped = true
+	d.scope = scopeWithTrashed
+	return d
+}
+
+// predicates 返回 WHERE 条件，没有调用 Where 时按主键删除 Delete 传入的实例
+func (d *Deleter[T]) predicates() ([]Predicate, error) {
+	if len(d.where) > 0 || len(d.vals) == 0 {
+		return d.where, nil
+	}
+	pks, err := primaryKeys(d.model)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([][]any, 0, len(d.vals))
+	for _, val := range d.vals {
+		key, err := d.pkValues(val)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return []Predicate{pkPredicate(pks, keys)}, nil
+}
+
 func NewDeleter[T any](session Session) *Deleter[T] {
 	c := session.getCore()
 	return &Deleter[T]{
@@ -114,18 +204,78 @@ func (d *Deleter[T]) Exec(ctx context.Context) Result {
 		return Result{err: err}
 	}
 
-	res := exec(ctx, d.core, d.session, &QueryContext{
-		Type:         "DELETE",
-		QueryBuilder: d,
-		Model:        d.model,
-	})
+	// BeforeDelete 钩子：仅当通过 Delete(vals...) 传入了模型实例时调用
+	for _, v := range d.vals {
+		if h, ok := any(v).(BeforeDelete); ok {
+			if e := h.BeforeDelete(ctx); e != nil {
+				return Result{err: e}
+			}
+		}
+	}
+
+	var dsts []Dst
+	if d.tableName == "" {
+		where, err := d.predicates()
+		if err != nil {
+			return Result{err: err}
+		}
+		if dsts, err = d.shardingDsts(where); err != nil {
+			return Result{err: err}
+		}
+	}
+	var res *QueryResult
+	if dsts != nil && len(dsts) != 1 {
+		res = execShards(dsts, func(idx int, dst *Dst) *QueryResult {
+			return exec(ctx, d.core, d.session, &QueryContext{
+				Type:         "DELETE",
+				QueryBuilder: d.shard(dst),
+				Model:        d.model,
+			})
+		})
+	} else {
+		res = exec(ctx, d.core, d.session, &QueryContext{
+			Type:         "DELETE",
+			QueryBuilder: d,
+			Model:        d.model,
+		})
+	}
 
 	var sqlRes sql.Result
 	if res.Result != nil {
 		sqlRes = res.Result.(sql.Result)
 	}
+
+	// AfterDelete 钩子：仅在 SQL 执行成功后调用
+	if res.Error == nil {
+		for _, v := range d.vals {
+			if h, ok := any(v).(AfterDelete); ok {
+				if e := h.AfterDelete(ctx); e != nil {
+					return Result{err: e}
+				}
+			}
+		}
+	}
 	return Result{
 		err: res.Error,
 		res: sqlRes,
 	}
 }
+
+// shard 复制一个只删除 dst 分片的 Deleter
+func (d *Deleter[T]) shard(dst *Dst) *Deleter[T] {
+	return &Deleter[T]{
+		builder: builder{
+			core:     d.core,
+			quoter:   d.quoter,
+			shardDst: dst,
+			scope:    d.scope,
+		},
+		where:    d.where,
+		table:    d.table,
+		orderBy:  d.orderBy,
+		limit:    d.limit,
+		vals:     d.vals,
+		unscoped: d.unscoped,
+		session:  d.session,
+	}
+}
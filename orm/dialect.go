@@ -1,16 +1,43 @@
 package orm
 
-import "Soil/orm/internal/errs"
+import (
+	"Soil/orm/internal/errs"
+	"Soil/orm/internal/model"
+	"strconv"
+	"strings"
+)
 
 var (
-	MySQL  Dialect = &mysqlDialect{}
-	SQLite Dialect = &sqliteDialect{}
+	MySQL    Dialect = &mysqlDialect{}
+	SQLite   Dialect = &sqliteDialect{}
+	Postgres Dialect = &postgresDialect{}
 )
 
 type Dialect interface {
 	quoter() byte
 	// buildOnDuplicateKey 生成upsert语句都在这里处理
 	buildUpsert(b *builder, upsert *Upsert) error
+	// buildReturning 生成 RETURNING 子句，不支持的方言返回 ErrUnsupportedFeature
+	buildReturning(b *builder, cols []string) error
+	// rebind builder 统一使用 ? 作为占位符，语句构造完成后由方言改写为自己的占位符形式
+	rebind(query string) string
+	// unboundedLimit 只有 OFFSET 没有 LIMIT 时补充的 LIMIT 值，返回空字符串表示不需要补充
+	unboundedLimit() string
+	// mutationStyle UPDATE/DELETE 联表以及使用 ORDER BY/LIMIT 时的写法
+	mutationStyle() mutationStyle
+	// buildLock 生成 SELECT 末尾的悲观锁子句，不支持的方言返回 ErrUnsupportedFeature
+	buildLock(b *builder, l rowLock) error
+	// insertIDStyle 批量插入之后获取自增主键的方式
+	insertIDStyle() insertIDStyle
+
+	// columnType 返回字段在 DDL 中的列类型，用于 Migrator
+	columnType(f *model.Field) (string, error)
+	// tableColumnsQuery 查询表中已有列名的语句，表不存在时结果集为空，用于 Migrator
+	tableColumnsQuery(table string) *Query
+	// tableIndexesQuery 查询表中已有索引名的语句，用于 Migrator
+	tableIndexesQuery(table string) *Query
+	// autoIncrement 自增列在列类型之后的定义，inlinePK 为 true 表示定义中已经包含 PRIMARY KEY，用于 Migrator
+	autoIncrement() (clause string, inlinePK bool)
 }
 
 type standardSql struct{}
@@ -26,6 +53,87 @@ func (s standardSql) quoter() byte {
 	return byte('"')
 }
 
+func (s standardSql) buildReturning(b *builder, cols []string) error {
+	b.sqlStrBuilder.WriteString(" RETURNING ")
+	for idx, col := range cols {
+		if idx != 0 {
+			b.sqlStrBuilder.WriteByte(',')
+		}
+		if err := b.buildColumn(Col(col)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s standardSql) rebind(query string) string {
+	return query
+}
+
+// unboundedLimit 标准SQL允许只有 OFFSET 没有 LIMIT
+func (s standardSql) unboundedLimit() string {
+	return ""
+}
+
+// mutationStyle 标准SQL（例如 Postgres）通过 UPDATE ... FROM 和 DELETE ... USING 联表
+func (s standardSql) mutationStyle() mutationStyle {
+	return mutationFrom
+}
+
+// insertIDStyle 标准SQL（例如 Postgres）没有 LastInsertId，通过 RETURNING 获取
+func (s standardSql) insertIDStyle() insertIDStyle {
+	return insertIDReturning
+}
+
+// autoIncrement 标准SQL的 identity 列
+func (s standardSql) autoIncrement() (string, bool) {
+	return " GENERATED BY DEFAULT AS IDENTITY", false
+}
+
+// buildLock e.g. FOR UPDATE SKIP LOCKED，Postgres 与 MySQL 8.0 的写法相同
+func (s standardSql) buildLock(b *builder, l rowLock) error {
+	b.sqlStrBuilder.WriteString(" " + l.strength)
+	if l.wait != "" {
+		b.sqlStrBuilder.WriteString(" " + l.wait)
+	}
+	return nil
+}
+
+var standardColumnTypes = map[string]string{
+	kindBool:    "BOOLEAN",
+	kindInt8:    "SMALLINT",
+	kindInt16:   "SMALLINT",
+	kindInt32:   "INTEGER",
+	kindInt64:   "BIGINT",
+	kindUint8:   "SMALLINT",
+	kindUint16:  "INTEGER",
+	kindUint32:  "BIGINT",
+	kindUint64:  "NUMERIC(20)",
+	kindFloat32: "REAL",
+	kindFloat64: "DOUBLE PRECISION",
+	kindString:  "VARCHAR(%d)",
+	kindBytes:   "BLOB",
+	kindTime:    "TIMESTAMP",
+}
+
+func (s standardSql) columnType(f *model.Field) (string, error) {
+	return columnTypeOf(f, standardColumnTypes)
+}
+
+func (s standardSql) tableColumnsQuery(table string) *Query {
+	return &Query{
+		SQL:  "SELECT column_name FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = ?",
+		Args: []any{table},
+	}
+}
+
+func (s standardSql) tableIndexesQuery(table string) *Query {
+	return &Query{
+		SQL:  "SELECT indexname FROM pg_indexes WHERE schemaname = current_schema() AND tablename = ?",
+		Args: []any{table},
+	}
+}
+
 type mysqlDialect struct {
 	standardSql
 }
@@ -34,6 +142,74 @@ func (m mysqlDialect) quoter() byte {
 	return '`'
 }
 
+// buildReturning MySQL 不支持 RETURNING
+func (m mysqlDialect) buildReturning(b *builder, cols []string) error {
+	return errs.NewErrUnsupportedFeature("returning")
+}
+
+// unboundedLimit MySQL 不允许 OFFSET 不带 LIMIT，这里补充一个极大值 LIMIT 表示无限制
+func (m mysqlDialect) unboundedLimit() string {
+	return "18446744073709551615"
+}
+
+func (m mysqlDialect) mutationStyle() mutationStyle {
+	return mutationJoin
+}
+
+// insertIDStyle MySQL 的 LastInsertId 是批量插入的第一行生成的主键
+func (m mysqlDialect) insertIDStyle() insertIDStyle {
+	return insertIDFirst
+}
+
+func (m mysqlDialect) autoIncrement() (string, bool) {
+	return " AUTO_INCREMENT", false
+}
+
+// buildLock 没有修饰符的共享锁使用 LOCK IN SHARE MODE，兼容 MySQL 5.7；
+// FOR SHARE、SKIP LOCKED 和 NOWAIT 需要 MySQL 8.0
+func (m mysqlDialect) buildLock(b *builder, l rowLock) error {
+	if l.strength == lockForShare && l.wait == "" {
+		b.sqlStrBuilder.WriteString(" LOCK IN SHARE MODE")
+		return nil
+	}
+	return m.standardSql.buildLock(b, l)
+}
+
+var mysqlColumnTypes = map[string]string{
+	kindBool:    "BOOLEAN",
+	kindInt8:    "TINYINT",
+	kindInt16:   "SMALLINT",
+	kindInt32:   "INT",
+	kindInt64:   "BIGINT",
+	kindUint8:   "TINYINT UNSIGNED",
+	kindUint16:  "SMALLINT UNSIGNED",
+	kindUint32:  "INT UNSIGNED",
+	kindUint64:  "BIGINT UNSIGNED",
+	kindFloat32: "FLOAT",
+	kindFloat64: "DOUBLE",
+	kindString:  "VARCHAR(%d)",
+	kindBytes:   "BLOB",
+	kindTime:    "DATETIME",
+}
+
+func (m mysqlDialect) columnType(f *model.Field) (string, error) {
+	return columnTypeOf(f, mysqlColumnTypes)
+}
+
+func (m mysqlDialect) tableColumnsQuery(table string) *Query {
+	return &Query{
+		SQL:  "SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?",
+		Args: []any{table},
+	}
+}
+
+func (m mysqlDialect) tableIndexesQuery(table string) *Query {
+	return &Query{
+		SQL:  "SELECT DISTINCT INDEX_NAME FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?",
+		Args: []any{table},
+	}
+}
+
 func (m mysqlDialect) buildUpsert(b *builder, upsert *Upsert) error {
 	b.sqlStrBuilder.WriteString(" ON DUPLICATE KEY UPDATE ")
 	for idx, assign := range upsert.assigns {
@@ -64,6 +240,26 @@ type sqliteDialect struct {
 	standardSql
 }
 
+// buildLock SQLite 以整个数据库为单位加锁，没有行锁
+func (s sqliteDialect) buildLock(b *builder, l rowLock) error {
+	return errs.NewErrUnsupportedFeature(l.strength)
+}
+
+// mutationStyle SQLite 默认编译选项下 UPDATE/DELETE 不支持 ORDER BY/LIMIT，联表时统一改写为按 rowid 匹配子查询
+func (s sqliteDialect) mutationStyle() mutationStyle {
+	return mutationSubquery
+}
+
+// insertIDStyle SQLite 的 LastInsertId 是最后插入的一行的 rowid
+func (s sqliteDialect) insertIDStyle() insertIDStyle {
+	return insertIDLast
+}
+
+// autoIncrement SQLite 只有 INTEGER PRIMARY KEY 列可以自增，并且必须在列定义中声明主键
+func (s sqliteDialect) autoIncrement() (string, bool) {
+	return " PRIMARY KEY AUTOINCREMENT", true
+}
+
 func (s sqliteDialect) buildUpsert(b *builder, upsert *Upsert) error {
 	b.sqlStrBuilder.WriteString(" ON CONFLICT(")
 	for idx, conflictCol := range upsert.conflictColumns {
@@ -104,3 +300,141 @@ func (s sqliteDialect) buildUpsert(b *builder, upsert *Upsert) error {
 func (s sqliteDialect) quoter() byte {
 	return byte('`')
 }
+
+func (s sqliteDialect) unboundedLimit() string {
+	return "18446744073709551615"
+}
+
+// sqliteColumnTypes SQLite 使用类型亲和性（type affinity），整数统一使用 INTEGER
+var sqliteColumnTypes = map[string]string{
+	kindBool:    "BOOLEAN",
+	kindInt8:    "INTEGER",
+	kindInt16:   "INTEGER",
+	kindInt32:   "INTEGER",
+	kindInt64:   "INTEGER",
+	kindUint8:   "INTEGER",
+	kindUint16:  "INTEGER",
+	kindUint32:  "INTEGER",
+	kindUint64:  "INTEGER",
+	kindFloat32: "REAL",
+	kindFloat64: "REAL",
+	kindString:  "TEXT",
+	kindBytes:   "BLOB",
+	kindTime:    "DATETIME",
+}
+
+func (s sqliteDialect) columnType(f *model.Field) (string, error) {
+	return columnTypeOf(f, sqliteColumnTypes)
+}
+
+// tableColumnsQuery SQLite 没有 information_schema，通过 pragma_table_info 获取列
+func (s sqliteDialect) tableColumnsQuery(table string) *Query {
+	return &Query{
+		SQL:  "SELECT name FROM pragma_table_info(?)",
+		Args: []any{table},
+	}
+}
+
+func (s sqliteDialect) tableIndexesQuery(table string) *Query {
+	return &Query{
+		SQL:  "SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ?",
+		Args: []any{table},
+	}
+}
+
+type postgresDialect struct {
+	standardSql
+}
+
+// buildUpsert e.g. ON CONFLICT ("id") DO UPDATE SET "first_name"=EXCLUDED."first_name"
+// PostgreSQL 的 DO UPDATE 必须指定冲突列
+func (p postgresDialect) buildUpsert(b *builder, upsert *Upsert) error {
+	if len(upsert.conflictColumns) == 0 {
+		return errs.ErrMissingConflictColumns
+	}
+	b.sqlStrBuilder.WriteString(" ON CONFLICT (")
+	for idx, conflictCol := range upsert.conflictColumns {
+		if idx != 0 {
+			b.sqlStrBuilder.WriteByte(',')
+		}
+		if err := b.buildColumn(Col(conflictCol)); err != nil {
+			return err
+		}
+	}
+	b.sqlStrBuilder.WriteString(") DO UPDATE SET ")
+
+	for idx, assign := range upsert.assigns {
+		if idx != 0 {
+			b.sqlStrBuilder.WriteByte(',')
+		}
+		switch assign := assign.(type) {
+		case Assignment:
+			if err := b.buildAssignment(assign); err != nil {
+				return err
+			}
+		case Column:
+			field, ok := b.model.FieldMap[assign.name]
+			if !ok {
+				return errs.NewErrUnknownField(assign.name)
+			}
+			b.quote(field.ColName)
+			b.sqlStrBuilder.WriteString("=EXCLUDED.")
+			b.quote(field.ColName)
+		default:
+			return errs.NewErrUnsupportedAssignableType(assign)
+		}
+	}
+
+	return nil
+}
+
+var postgresColumnTypes = map[string]string{
+	kindBool:    "BOOLEAN",
+	kindInt8:    "SMALLINT",
+	kindInt16:   "SMALLINT",
+	kindInt32:   "INTEGER",
+	kindInt64:   "BIGINT",
+	kindUint8:   "SMALLINT",
+	kindUint16:  "INTEGER",
+	kindUint32:  "BIGINT",
+	kindUint64:  "NUMERIC(20)",
+	kindFloat32: "REAL",
+	kindFloat64: "DOUBLE PRECISION",
+	kindString:  "VARCHAR(%d)",
+	kindBytes:   "BYTEA",
+	kindTime:    "TIMESTAMP",
+}
+
+func (p postgresDialect) columnType(f *model.Field) (string, error) {
+	return columnTypeOf(f, postgresColumnTypes)
+}
+
+// rebind 将 ? 依次改写为 $1..$N。引号内的内容（字符串字面量与引用标识符）原样保留，
+// 因此子查询、RawExpression 中的 ? 会与外层语句一起统一编号
+func (p postgresDialect) rebind(query string) string {
+	var (
+		sb    strings.Builder
+		n     int
+		quote byte
+	)
+	sb.Grow(len(query) + 8)
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			// 引号内重复两次的引号是转义，会在下一次循环中重新进入引号状态
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			n++
+			sb.WriteByte('$')
+			sb.WriteString(strconv.Itoa(n))
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
@@ -0,0 +1,159 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgres_Build(t *testing.T) {
+	type Order struct {
+		Id     int64
+		UserId int64
+	}
+
+	mockDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(Postgres))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		builder   QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "select",
+			builder: NewSelector[TestModel](db).Where(Col("Age").GT(18), Col("FirstName").EQ("Tom")).Limit(10).Offset(20),
+			wantQuery: &Query{
+				SQL:  `SELECT * FROM "test_model" WHERE ("age" > $1) AND ("first_name" = $2) LIMIT $3 OFFSET $4;`,
+				Args: []any{18, "Tom", 10, 20},
+			},
+		},
+		{
+			name:    "only offset",
+			builder: NewSelector[TestModel](db).Offset(20),
+			wantQuery: &Query{
+				SQL:  `SELECT * FROM "test_model" OFFSET $1;`,
+				Args: []any{20},
+			},
+		},
+		{
+			name: "subquery",
+			builder: NewSelector[TestModel](db).Where(Col("Age").GT(18), Col("Id").In(
+				NewSelector[Order](db).Select(Col("UserId")).Where(Col("Id").In(1, 2))), Col("Age").LT(60)),
+			wantQuery: &Query{
+				SQL:  `SELECT * FROM "test_model" WHERE (("age" > $1) AND ("id" IN (SELECT "user_id" FROM "order" WHERE "id" IN ($2,$3)))) AND ("age" < $4);`,
+				Args: []any{18, 1, 2, 60},
+			},
+		},
+		{
+			name:    "raw expression",
+			builder: NewSelector[TestModel](db).Where(Raw(`"first_name" = '?' AND "age" > ?`, 18).AsPredicate(), Col("Id").EQ(1)),
+			wantQuery: &Query{
+				SQL:  `SELECT * FROM "test_model" WHERE (("first_name" = '?' AND "age" > $1)) AND ("id" = $2);`,
+				Args: []any{18, 1},
+			},
+		},
+		{
+			name: "upsert",
+			builder: NewInserter[TestModel](db).Values(&TestModel{Id: 1, FirstName: "Tom", Age: 18, LastName: "Jerry"}).
+				OnDuplicateKey().ConflictColumns("Id").Update(Col("FirstName"), Assign("Age", 20)),
+			wantQuery: &Query{
+				SQL: `INSERT INTO "test_model"("id","first_name","age","last_name") VALUES ($1,$2,$3,$4)` +
+					` ON CONFLICT ("id") DO UPDATE SET "first_name"=EXCLUDED."first_name","age"=$5;`,
+				Args: []any{int64(1), "Tom", uint8(18), "Jerry", 20},
+			},
+		},
+		{
+			name: "upsert without conflict columns",
+			builder: NewInserter[TestModel](db).Values(&TestModel{Id: 1}).
+				OnDuplicateKey().Update(Col("FirstName")),
+			wantErr: errs.ErrMissingConflictColumns,
+		},
+		{
+			name: "returning",
+			builder: NewInserter[TestModel](db).Columns("FirstName", "Age").
+				Values(&TestModel{FirstName: "Tom", Age: 18}, &TestModel{FirstName: "Jerry", Age: 20}).Returning("Id"),
+			wantQuery: &Query{
+				SQL:  `INSERT INTO "test_model"("first_name","age") VALUES ($1,$2),($3,$4) RETURNING "id";`,
+				Args: []any{"Tom", uint8(18), "Jerry", uint8(20)},
+			},
+		},
+		{
+			name:    "update",
+			builder: NewUpdater[TestModel](db).Set(Assign("FirstName", "Tom")).Where(Col("Id").EQ(1)),
+			wantQuery: &Query{
+				SQL:  `UPDATE "test_model" SET "first_name"=$1 WHERE "id" = $2;`,
+				Args: []any{"Tom", 1},
+			},
+		},
+		{
+			name:    "delete",
+			builder: NewDeleter[TestModel](db).Where(Col("Id").Between(1, 10)),
+			wantQuery: &Query{
+				SQL:  `DELETE FROM "test_model" WHERE "id" BETWEEN $1 AND $2;`,
+				Args: []any{1, 10},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestMySQL_ReturningUnsupported(t *testing.T) {
+	mockDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	_, err = NewInserter[TestModel](db).Values(&TestModel{}).Returning("Id").Build()
+	assert.ErrorIs(t, err, errs.ErrUnsupportedFeature)
+}
+
+func TestPostgres_InserterReturningExec(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(Postgres))
+	require.NoError(t, err)
+
+	values := []*TestModel{
+		{FirstName: "a", Age: 1},
+		{FirstName: "b", Age: 2},
+		{FirstName: "c", Age: 3},
+	}
+
+	// chunkSize=2 → 2 批，每批的占位符都从 $1 开始编号
+	mock.ExpectQuery(`INSERT INTO "test_model"("first_name","age") VALUES ($1,$2),($3,$4) RETURNING "id";`).
+		WithArgs("a", uint8(1), "b", uint8(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11).AddRow(12))
+	mock.ExpectQuery(`INSERT INTO "test_model"("first_name","age") VALUES ($1,$2) RETURNING "id";`).
+		WithArgs("c", uint8(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(13))
+
+	res := NewInserter[TestModel](db).Columns("FirstName", "Age").Values(values...).
+		ChunkSize(2).Returning("Id").Exec(context.Background())
+	require.NoError(t, res.Err())
+
+	affected, err := res.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), affected)
+	assert.Equal(t, int64(11), values[0].Id)
+	assert.Equal(t, int64(12), values[1].Id)
+	assert.Equal(t, int64(13), values[2].Id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -6,4 +6,10 @@ import "Soil/orm/internal/errs"
 // 便于使用方通过 orm.ErrXxx 直接引用，并支持 errors.Is 匹配。
 var (
 	ErrOptimisticLock = errs.ErrOptimisticLock
+	ErrEmptyInValues  = errs.ErrEmptyInValues
+	ErrTxRollbackOnly = errs.ErrTxRollbackOnly
+	ErrCrossShard     = errs.ErrCrossShard
+	ErrLockOutsideTx  = errs.ErrLockOutsideTx
+	ErrNoPrimaryKey   = errs.ErrNoPrimaryKey
+	ErrConvert        = errs.ErrConvert
 )
@@ -1,9 +1,13 @@
 package orm
 
-import "context"
+import (
+	"context"
+	"database/sql"
+)
 
 // exec 将中间件链起来
 func exec(ctx context.Context, core core, session Session, qc *QueryContext) *QueryResult {
+	qc.Tx = sessionTx(ctx, session)
 	var root Handler = func(ctx context.Context, queryCtx *QueryContext) *QueryResult {
 		return execHandler(ctx, session, queryCtx)
 	}
@@ -23,3 +27,35 @@ func execHandler(ctx context.Context, session Session, queryCtx *QueryContext) *
 	res, err := session.execContext(ctx, query.SQL, query.Args...)
 	return &QueryResult{Result: Result{err: err, res: res}, Error: err}
 }
+
+// execReturning 与 exec 一样将中间件链起来，但语句带有 RETURNING 子句，需要通过查询执行，
+// 返回的结果集交给 scan 处理，scan 返回处理的行数作为影响行数
+func execReturning(ctx context.Context, core core, session Session, qc *QueryContext,
+	scan func(rows *sql.Rows) (int64, error)) *QueryResult {
+	qc.Tx = sessionTx(ctx, session)
+	var root Handler = func(ctx context.Context, queryCtx *QueryContext) *QueryResult {
+		return execReturningHandler(ctx, session, queryCtx, scan)
+	}
+	for i := len(core.middlewares) - 1; i >= 0; i-- {
+		root = core.middlewares[i](root)
+	}
+
+	return root(ctx, qc)
+}
+
+func execReturningHandler(ctx context.Context, session Session, queryCtx *QueryContext,
+	scan func(rows *sql.Rows) (int64, error)) *QueryResult {
+	query, err := queryCtx.QueryBuilder.Build()
+	if err != nil {
+		return &QueryResult{Error: err, Result: Result{err: err}}
+	}
+
+	rows, err := session.queryContext(ctx, query.SQL, query.Args...)
+	if err != nil {
+		return &QueryResult{Error: err, Result: Result{err: err}}
+	}
+	defer rows.Close()
+
+	cnt, err := scan(rows)
+	return &QueryResult{Result: Result{err: err, res: &aggregatedResult{rowsAffected: cnt}}, Error: err}
+}
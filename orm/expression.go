@@ -67,3 +67,26 @@ func (m MathExpression) Div(val any) MathExpression {
 		right: exprOf(val),
 	}
 }
+
+// valuesExpression IN/NOT IN 的值列表，构造时展开为 (?,?,...)，每个值对应一个占位符
+type valuesExpression struct {
+	vals []any
+}
+
+func (valuesExpression) expr() {}
+
+// betweenExpression BETWEEN 的上下界，构造为 low AND high
+type betweenExpression struct {
+	low  Expression
+	high Expression
+}
+
+func (betweenExpression) expr() {}
+
+// subqueryExpression 将一个 QueryBuilder（例如 *Selector）作为子查询嵌入，
+// 构造为 (SELECT ...)，子查询的参数按出现的顺序追加到外层语句的参数中
+type subqueryExpression struct {
+	qb QueryBuilder
+}
+
+func (subqueryExpression) expr() {}
@@ -1,14 +1,282 @@
 package main
 
 import (
-	"github.com/stretchr/testify/require"
+	"bytes"
 	"os"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGen(t *testing.T) {
-	f, err := os.Create("./testdata/user.gen.go")
+	require.NoError(t, genPackage("./testdata", nil))
+
+	data, err := os.ReadFile("./testdata/user.gen.go")
 	require.NoError(t, err)
-	err = gen(f, "./testdata/user.go")
+	assert.Equal(t, `// Code generated by orm-gen. DO NOT EDIT.
+
+package testdata
+
+import (
+	"Soil/orm"
+	sqlx "database/sql"
+	"time"
+)
+
+// UserColumns User 各字段在数据表中的列名
+var UserColumns = struct {
+	Id        string
+	CreatedAt string
+	UpdatedAt string
+	Name      string
+	Age       string
+	NickName  string
+	Picture   string
+}{
+	Id:        "id",
+	CreatedAt: "created_at",
+	UpdatedAt: "updated_at",
+	Name:      "user_name",
+	Age:       "age",
+	NickName:  "nick_name",
+	Picture:   "picture",
+}
+
+// User 各字段带类型的列，e.g. UserId.EQ(...)
+var (
+	UserId        = orm.TypedCol[int64]("Id")
+	UserCreatedAt = orm.TypedCol[time.Time]("CreatedAt")
+	UserUpdatedAt = orm.TypedCol[time.Time]("UpdatedAt")
+	UserName      = orm.TypedCol[string]("Name")
+	UserAge       = orm.TypedCol[int]("Age")
+	UserNickName  = orm.TypedCol[sqlx.NullString]("NickName")
+	UserPicture   = orm.TypedCol[[]byte]("Picture")
+)
+
+// UserDetailColumns UserDetail 各字段在数据表中的列名
+var UserDetailColumns = struct {
+	Id      string
+	UserId  string
+	Address string
+}{
+	Id:      "id",
+	UserId:  "user_id",
+	Address: "address",
+}
+
+// UserDetail 各字段带类型的列，e.g. UserDetailId.EQ(...)
+var (
+	UserDetailId      = orm.TypedCol[int64]("Id")
+	UserDetailUserId  = orm.TypedCol[int64]("UserId")
+	UserDetailAddress = orm.TypedCol[string]("Address")
+)
+`, string(data))
+
+	// 按 import 路径查找包，嵌入到其它结构体中的模型也会单独生成
+	require.NoError(t, genPackage("Soil/orm/gen/orm-gen/testdata", nil))
+	_, err = os.Stat("./testdata/base.gen.go")
+	assert.NoError(t, err)
+}
+
+func TestGen_Types(t *testing.T) {
+	files, err := parsePackage("./testdata")
+	require.NoError(t, err)
+	var (
+		buf bytes.Buffer
+		ok  bool
+	)
+	for _, f := range files {
+		if f.Name == "user.go" {
+			ok, err = gen(&buf, f, files, []string{"UserDetail"})
+		}
+	}
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.NotContains(t, buf.String(), "UserColumns")
+	assert.Contains(t, buf.String(), "UserDetailColumns")
+
+	buf.Reset()
+	for _, f := range files {
+		if f.Name == "base.go" {
+			ok, err = gen(&buf, f, files, []string{"User"})
+		}
+	}
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestGen_Flatten(t *testing.T) {
+	files, err := parsePackage("./testdata")
 	require.NoError(t, err)
+	var buf bytes.Buffer
+	for _, f := range files {
+		if f.Name == "order.go" {
+			_, err = gen(&buf, f, files, []string{"Order"})
+		}
+	}
+	require.NoError(t, err)
+	assert.Equal(t, `// Code generated by orm-gen. DO NOT EDIT.
+
+package testdata
+
+import (
+	"Soil/orm"
+)
+
+// OrderColumns Order 各字段在数据表中的列名
+var OrderColumns = struct {
+	Id             string
+	Operator       string
+	Remark         string
+	AddressCity    string
+	AddressStreet  string
+	ShippingCity   string
+	ShippingStreet string
+	Price          string
+}{
+	Id:             "id",
+	Operator:       "operator",
+	Remark:         "note",
+	AddressCity:    "addr_city",
+	AddressStreet:  "addr_road",
+	ShippingCity:   "ship_city",
+	ShippingStreet: "ship_road",
+	Price:          "price",
+}
+
+// Order 各字段带类型的列，e.g. OrderId.EQ(...)
+var (
+	OrderId             = orm.TypedCol[int64]("Id")
+	OrderOperator       = orm.TypedCol[string]("Operator")
+	OrderRemark         = orm.TypedCol[string]("Remark")
+	OrderAddressCity    = orm.TypedCol[string]("Address.City")
+	OrderAddressStreet  = orm.TypedCol[string]("Address.Street")
+	OrderShippingCity   = orm.TypedCol[string]("Shipping.City")
+	OrderShippingStreet = orm.TypedCol[string]("Shipping.Street")
+	OrderPrice          = orm.TypedCol[Money]("Price")
+)
+`, buf.String())
+}
+
+func TestGen_ExternalEmbedded(t *testing.T) {
+	files, err := parsePackage("./testdata")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	for _, f := range files {
+		if f.Name == "account.go" {
+			_, err = gen(&buf, f, files, nil)
+		}
+	}
+	require.NoError(t, err)
+	assert.Equal(t, `// Code generated by orm-gen. DO NOT EDIT.
+
+package testdata
+
+import (
+	"Soil/orm"
+	"Soil/orm/gen/orm-gen/testdata/ext"
+	"time"
+)
+
+// AccountColumns Account 各字段在数据表中的列名
+var AccountColumns = struct {
+	Id        string
+	Status    string
+	CreatedAt string
+	Tags      string
+	Email     string
+}{
+	Id:        "id",
+	Status:    "status",
+	CreatedAt: "created_at",
+	Tags:      "tags",
+	Email:     "email",
+}
+
+// Account 各字段带类型的列，e.g. AccountId.EQ(...)
+var (
+	AccountId        = orm.TypedCol[int64]("Id")
+	AccountStatus    = orm.TypedCol[ext.Status]("Status")
+	AccountCreatedAt = orm.TypedCol[time.Time]("CreatedAt")
+	AccountTags      = orm.TypedCol[map[string][]*ext.Status]("Tags")
+	AccountEmail     = orm.TypedCol[string]("Email")
+)
+`, buf.String())
+
+	// 其它包中未导出的类型无法引用
+	bad := &FileInfo{Package: "testdata", Name: "bad.go", Types: []*TypeInfo{{
+		Name: "Bad",
+		Fields: []*FieldInfo{{Name: "Hidden", Type: "ext.Hidden", Embedded: true,
+			Imports: []string{`"Soil/orm/gen/orm-gen/testdata/ext"`}}},
+	}}}
+	_, err = gen(&buf, bad, []*FileInfo{bad}, nil)
+	assert.ErrorContains(t, err, "ext.Hidden.Kind 的类型 kind 未导出")
+}
+
+func TestGen_IdentConflict(t *testing.T) {
+	testCases := []struct {
+		name    string
+		files   []*FileInfo
+		names   []string
+		wantErr string
+	}{
+		{
+			name: "field",
+			files: []*FileInfo{{Package: "testdata", Name: "user.go", Types: []*TypeInfo{
+				{Name: "User", Fields: []*FieldInfo{{Name: "DetailId", Type: "int64"}}},
+				{Name: "UserDetail", Fields: []*FieldInfo{{Name: "Id", Type: "int64"}}},
+			}}},
+			wantErr: "User.DetailId 与 UserDetail.Id 生成的标识符 UserDetailId 冲突",
+		},
+		{
+			// 其它文件中的模型生成的标识符也不能重复
+			name: "other file",
+			files: []*FileInfo{
+				{Package: "testdata", Name: "detail.go", Types: []*TypeInfo{
+					{Name: "UserDetail", Fields: []*FieldInfo{{Name: "Id", Type: "int64"}}},
+				}},
+				{Package: "testdata", Name: "user.go", Types: []*TypeInfo{
+					{Name: "User", Fields: []*FieldInfo{{Name: "DetailId", Type: "int64"}}},
+				}},
+			},
+			wantErr: "UserDetail.Id 与 User.DetailId 生成的标识符 UserDetailId 冲突",
+		},
+		{
+			name: "columns",
+			files: []*FileInfo{{Package: "testdata", Name: "user.go", Types: []*TypeInfo{
+				{Name: "User", Fields: []*FieldInfo{{Name: "Columns", Type: "string"}}},
+			}}},
+			wantErr: "User 与 User.Columns 生成的标识符 UserColumns 冲突",
+		},
+		{
+			name: "type",
+			files: []*FileInfo{{Package: "testdata", Name: "order.go", Types: []*TypeInfo{
+				{Name: "Order", Fields: []*FieldInfo{{Name: "Item", Type: "string"}}},
+				{Name: "OrderItem", Fields: []*FieldInfo{{Name: "Id", Type: "int64"}}},
+			}}},
+			names:   []string{"Order"},
+			wantErr: "类型 OrderItem 与 Order.Item 生成的标识符 OrderItem 冲突",
+		},
+		{
+			// 没有选择的模型不会生成代码
+			name: "not selected",
+			files: []*FileInfo{{Package: "testdata", Name: "user.go", Types: []*TypeInfo{
+				{Name: "User", Fields: []*FieldInfo{{Name: "DetailId", Type: "int64"}}},
+				{Name: "UserDetail", Fields: []*FieldInfo{{Name: "Id", Type: "int64"}}},
+			}}},
+			names: []string{"User"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			_, err := gen(&buf, tc.files[len(tc.files)-1], tc.files, tc.names)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.wantErr)
+		})
+	}
 }
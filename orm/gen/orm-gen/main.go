@@ -1,82 +1,518 @@
+// orm-gen 为模型生成列名常量和带类型的查询条件构造器。
+//
+// 用法：
+//
+//	orm-gen [-type User,Order] [package ...]
+//
+// package 可以是目录或者 import 路径，默认为当前目录。包中每个声明了模型的源文件 xxx.go
+// 会生成一个 xxx.gen.go。通常在模型所在的包中通过 go:generate 调用：
+//
+//	//go:generate go run Soil/orm/gen/orm-gen
 package main
 
 import (
+	"Soil/orm/internal/model"
+	"bytes"
 	_ "embed"
+	"flag"
+	"fmt"
 	"go/ast"
+	"go/build"
+	"go/format"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"text/template"
+	"unicode"
+	"unicode/utf8"
 )
 
-type studentInfo struct {
+//go:embed template.gohtml
+var genOrm string
+
+var tpl = template.Must(template.New("gen-orm").Parse(genOrm))
+
+// genFile 一个生成文件的模板数据
+type genFile struct {
+	Package string
+	Imports []string
+	Models  []*genModel
+}
+
+type genModel struct {
 	Name    string
-	Country string
+	Columns []*genColumn
 }
 
-type student struct {
-	Id uint64
-	studentInfo
+type genColumn struct {
+	// Field 生成的标识符中使用的字段名，匿名字段中的字段展开之后使用字段自己的名字，
+	// 命名的嵌套结构体中的字段去掉 GoName 中的点，e.g. AddressCity
+	Field string
+	// GoName 字段在模型中的名字，与 model.Field 的 GoName 一致，e.g. Address.City
+	GoName string
+	// Column 数据表中的列名，规则与 model.Registry 相同
+	Column string
+	// Type TypedCol 的类型参数，指针字段使用指针指向的类型
+	Type string
 }
 
-//go:embed template.gohtml
-var genOrm string
+func main() {
+	typeNames := flag.String("type", "", "逗号分隔的模型名，默认为包中所有导出的结构体")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "用法: orm-gen [-type User,Order] [package ...]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
 
-func gen(w io.Writer, filename string) error {
-	fileSet := token.NewFileSet()
-	f, err := parser.ParseFile(fileSet, filename, nil, parser.ParseComments)
-	if err != nil {
-		return err
+	var names []string
+	if *typeNames != "" {
+		names = strings.Split(*typeNames, ",")
 	}
-	visitor := &SingleFileEntryVisitor{}
-	ast.Walk(visitor, f)
-	fileInfo := visitor.Get()
+	pkgs := flag.Args()
+	if len(pkgs) == 0 {
+		pkgs = []string{"."}
+	}
+	for _, pkg := range pkgs {
+		if err := genPackage(pkg, names); err != nil {
+			fmt.Fprintln(os.Stderr, "orm-gen:", err)
+			os.Exit(1)
+		}
+	}
+}
 
-	//读取模板文件
-	tpl, err := template.New("gen-orm").Parse(genOrm)
+// genPackage 为包中每个声明了模型的源文件生成 xxx.gen.go，names 为空时生成所有导出的结构体
+func genPackage(pkg string, names []string) error {
+	dir, err := packageDir(pkg)
 	if err != nil {
 		return err
 	}
-	if err = tpl.Execute(w, fileInfo); err != nil {
+	files, err := parsePackage(dir)
+	if err != nil {
 		return err
 	}
+	for _, f := range files {
+		var buf bytes.Buffer
+		ok, err := gen(&buf, f, files, names)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		output := filepath.Join(dir, strings.TrimSuffix(f.Name, ".go")+".gen.go")
+		if err = os.WriteFile(output, buf.Bytes(), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+// packageDir 目录直接使用，否则按 import 路径查找包所在的目录
+func packageDir(pkg string) (string, error) {
+	if info, err := os.Stat(pkg); err == nil && info.IsDir() {
+		return pkg, nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	p, err := build.Import(pkg, wd, build.FindOnly)
+	if err != nil {
+		return "", err
+	}
+	return p.Dir, nil
+}
+
+// parsePackage 解析目录中除测试文件和生成文件之外的源文件，按文件名排序
+func parsePackage(dir string) ([]*FileInfo, error) {
+	fileSet := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fileSet, dir, func(info os.FileInfo) bool {
+		name := info.Name()
+		return !strings.HasSuffix(name, "_test.go") && !strings.HasSuffix(name, ".gen.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	var res []*FileInfo
+	for _, pkg := range pkgs {
+		for filename, f := range pkg.Files {
+			visitor := &SingleFileEntryVisitor{}
+			ast.Walk(visitor, f)
+			fileInfo := visitor.Get()
+			fileInfo.Name = filepath.Base(filename)
+			res = append(res, fileInfo)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res, nil
+}
+
+// gen 生成 file 中模型的代码，pkg 是同一个包中的所有文件，用于展开其它文件中声明的匿名字段。
+// file 中没有需要生成的模型时返回 false
+func gen(w io.Writer, file *FileInfo, pkg []*FileInfo, names []string) (bool, error) {
+	p := newPkgInfo(pkg)
+	if err := checkIdents(pkg, p, names); err != nil {
+		return false, err
+	}
+	data := &genFile{Package: file.Package}
+	imports := make(map[string]struct{})
+	for _, typ := range file.Types {
+		if !selected(typ.Name, names) {
+			continue
+		}
+		m, err := newGenModel(typ, p, imports)
+		if err != nil {
+			return false, err
+		}
+		if len(m.Columns) > 0 {
+			data.Models = append(data.Models, m)
+		}
+	}
+	if len(data.Models) == 0 {
+		return false, nil
+	}
+	for imp := range imports {
+		data.Imports = append(data.Imports, imp)
+	}
+	sort.Strings(data.Imports)
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return false, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return false, err
+	}
+	_, err = w.Write(src)
+	return err == nil, err
+}
+
+// newGenModel 返回 typ 中数据表的列，列的类型需要的导入加入到 imports 中
+func newGenModel(typ *TypeInfo, p *pkgInfo, imports map[string]struct{}) (*genModel, error) {
+	fields, err := flatten(typ, p)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", typ.Name, err)
+	}
+	m := &genModel{Name: typ.Name}
+	for _, fd := range fields {
+		col, ok, err := model.ColumnName(fd.Name, reflect.StructTag(fd.Tag))
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", typ.Name, fd.goName, err)
+		}
+		// 关联字段不是数据表的列
+		if !ok {
+			continue
+		}
+		m.Columns = append(m.Columns, &genColumn{
+			Field:  strings.ReplaceAll(fd.goName, ".", ""),
+			GoName: fd.goName,
+			Column: fd.colPrefix + col,
+			Type:   strings.TrimPrefix(fd.Type, "*"),
+		})
+		for _, imp := range fd.Imports {
+			imports[imp] = struct{}{}
+		}
+	}
+	return m, nil
+}
+
+// checkIdents 检查包中所有模型生成的标识符 <Model>Columns、<Model><Field> 是否重复，
+// 或者与包中声明的类型同名，e.g. User.DetailId 与 UserDetail.Id 都会生成 UserDetailId。
+// 无法生成的模型跳过，生成所在的文件时再返回错误
+func checkIdents(pkg []*FileInfo, p *pkgInfo, names []string) error {
+	idents := make(map[string]string)
+	for name := range p.types {
+		idents[name] = "类型 " + name
+	}
+	add := func(ident, origin string) error {
+		if prev, ok := idents[ident]; ok {
+			return fmt.Errorf("%s 与 %s 生成的标识符 %s 冲突", prev, origin, ident)
+		}
+		idents[ident] = origin
+		return nil
+	}
+	for _, f := range pkg {
+		for _, typ := range f.Types {
+			if !selected(typ.Name, names) {
+				continue
+			}
+			m, err := newGenModel(typ, p, make(map[string]struct{}))
+			if err != nil || len(m.Columns) == 0 {
+				continue
+			}
+			if err = add(m.Name+"Columns", m.Name); err != nil {
+				return err
+			}
+			for _, c := range m.Columns {
+				if err = add(m.Name+c.Field, m.Name+"."+c.GoName); err != nil {
+					return err
+				}
+			}
+		}
+	}
 	return nil
 }
 
-func main() {
-	//name := "cats"
-	//strTemplate := "I like {{.}}!\n"
-	//templ, err := template.New("templateName").Parse(strTemplate) //解析模板
-	//if err != nil {
-	//	panic(err)
-	//}
-	//err = templ.Execute(os.Stdout, name) //将name值填充到模板中
-	//
-	//yi := student{Id: 1234, studentInfo: studentInfo{Name: "yi", Country: "china"}}
-	//strTemplate = "My name is {{.Name}}[id: {{.Id}}] from {{.Country}}\n"
-	//templ, err = template.New("templateStruct").Parse(strTemplate)
-	//if err != nil {
-	//	panic(err)
-	//}
-	//err = templ.Execute(os.Stdout, yi)
-	//
-	//yang := student{Id: 1234, studentInfo: studentInfo{Name: "yang", Country: "China"}}
-	//strTemplate = "my name is {{.Name}}[{{.Id}}] from {{if eq .Name `yi`}}{{.Country}}{{else}}Chinese{{end}}\n"
-	//templ, err = template.New("template_if").Parse(strTemplate)
-	//if err != nil {
-	//	panic(err)
-	//}
-	//err = templ.Execute(os.Stdout, yi)
-	//err = templ.Execute(os.Stdout, yang)
-	//
-	//students := make([]student, 0, 2)
-	//students = append(students, yi)
-	//students = append(students, yang)
-	//strTemplate = "{{range.}}My name is {{.Name}}[id: {{.Id}}] from {{.Country}}\n{{end}}"
-	//templ, err = template.New("template_range").Parse(strTemplate)
-	//if err != nil {
-	//	panic(err)
-	//}
-	//err = templ.Execute(os.Stdout, students)
+// selected names 为空时选择所有导出的结构体
+func selected(name string, names []string) bool {
+	if len(names) == 0 {
+		return ast.IsExported(name)
+	}
+	for _, n := range names {
+		if strings.TrimSpace(n) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// pkgInfo 包中声明的结构体和方法
+type pkgInfo struct {
+	types   map[string]*TypeInfo
+	methods map[string]map[string]bool
+	// imported 已经解析的其它包，key 为 import 路径，同一次生成中的所有 pkgInfo 共用
+	imported map[string]*pkgInfo
+}
+
+func newPkgInfo(files []*FileInfo) *pkgInfo {
+	return newImportedPkgInfo(files, make(map[string]*pkgInfo))
+}
+
+func newImportedPkgInfo(files []*FileInfo, imported map[string]*pkgInfo) *pkgInfo {
+	p := &pkgInfo{
+		types:    make(map[string]*TypeInfo),
+		methods:  make(map[string]map[string]bool),
+		imported: imported,
+	}
+	for _, f := range files {
+		for _, typ := range f.Types {
+			p.types[typ.Name] = typ
+		}
+		for recv, methods := range f.Methods {
+			if p.methods[recv] == nil {
+				p.methods[recv] = make(map[string]bool)
+			}
+			for _, m := range methods {
+				p.methods[recv][m] = true
+			}
+		}
+	}
+	return p
+}
+
+// localStructType 返回包中声明的可以展开的结构体，与 model.Registry 一样，
+// 实现了 sql.Scanner 或者 driver.Valuer 的结构体作为一列读写，不会展开
+func (p *pkgInfo) localStructType(name string) (*TypeInfo, bool) {
+	typ, ok := p.types[name]
+	if !ok || p.methods[name]["Scan"] || p.methods[name]["Value"] {
+		return nil, false
+	}
+	return typ, true
+}
+
+// structType 返回字段类型对应的可以展开的结构体。其它包中的类型会解析所在的包，
+// 返回的结构体中字段的类型加上包名，e.g. Status -> base.Status
+func (p *pkgInfo) structType(fd *FieldInfo) (*TypeInfo, bool, error) {
+	name := strings.TrimPrefix(fd.Type, "*")
+	alias, typName, ok := strings.Cut(name, ".")
+	if !ok {
+		typ, ok := p.localStructType(name)
+		return typ, ok, nil
+	}
+	var spec string
+	for _, imp := range fd.Imports {
+		if importName(imp) == alias {
+			spec = imp
+		}
+	}
+	if spec == "" {
+		return nil, false, nil
+	}
+	path, err := strconv.Unquote(spec[strings.LastIndexByte(spec, ' ')+1:])
+	if err != nil {
+		return nil, false, err
+	}
+	// time.Time 作为一列读写
+	if path == "time" && typName == "Time" {
+		return nil, false, nil
+	}
+	ext, err := p.load(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("解析匿名字段 %s 所在的包失败: %w", fd.Name, err)
+	}
+	typ, ok := ext.localStructType(typName)
+	if !ok {
+		return nil, false, nil
+	}
+
+	res := &TypeInfo{Name: path + "." + typName, Fields: make([]*FieldInfo, 0, len(typ.Fields))}
+	for _, f := range typ.Fields {
+		qualified, ok := qualify(f.Type, alias)
+		if !ok && (ast.IsExported(f.Name) || f.Embedded) {
+			return nil, false, fmt.Errorf("%s.%s 的类型 %s 未导出，无法在其它包中使用", name, f.Name, f.Type)
+		}
+		imports := f.Imports
+		if qualified != f.Type {
+			imports = append(slices.Clone(imports), spec)
+		}
+		res.Fields = append(res.Fields, &FieldInfo{
+			Name:     f.Name,
+			Type:     qualified,
+			Tag:      f.Tag,
+			Embedded: f.Embedded,
+			Imports:  imports,
+		})
+	}
+	return res, true, nil
+}
+
+// load 解析 import 路径对应的包
+func (p *pkgInfo) load(path string) (*pkgInfo, error) {
+	if res, ok := p.imported[path]; ok {
+		return res, nil
+	}
+	dir, err := packageDir(path)
+	if err != nil {
+		return nil, err
+	}
+	files, err := parsePackage(dir)
+	if err != nil {
+		return nil, err
+	}
+	res := newImportedPkgInfo(files, p.imported)
+	p.imported[path] = res
+	return res, nil
+}
+
+// qualify 给类型的源码中包内声明的类型加上包名 pkg，e.g. []*Status -> []*base.Status。
+// 类型中有未导出的类型时无法在其它包中使用，返回 false
+func qualify(typ string, pkg string) (string, bool) {
+	var sb strings.Builder
+	ok := true
+	for i := 0; i < len(typ); {
+		r, size := utf8.DecodeRuneInString(typ[i:])
+		if r != '_' && !unicode.IsLetter(r) {
+			sb.WriteString(typ[i : i+size])
+			i += size
+			continue
+		}
+		j := i
+		for j < len(typ) {
+			r, size := utf8.DecodeRuneInString(typ[j:])
+			if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				break
+			}
+			j += size
+		}
+		ident := typ[i:j]
+		// 已经有包名的类型、包名本身、预声明的类型和关键字不需要处理
+		local := (i == 0 || typ[i-1] != '.') && (j == len(typ) || typ[j] != '.') &&
+			types.Universe.Lookup(ident) == nil && !token.Lookup(ident).IsKeyword()
+		if local {
+			if !ast.IsExported(ident) {
+				ok = false
+			}
+			sb.WriteString(pkg + ".")
+		}
+		sb.WriteString(ident)
+		i = j
+	}
+	return sb.String(), ok
+}
+
+// leafField 展开匿名字段和 orm:"prefix()" 标记的嵌套结构体之后得到的字段
+type leafField struct {
+	*FieldInfo
+	// goName 字段在模型中的名字，命名的嵌套结构体中的字段为 <结构体字段名>.<字段名>
+	goName    string
+	colPrefix string
+	depth     int
+}
+
+// flatten 按照与 model.Registry 相同的规则展开匿名字段和 orm:"prefix()" 标记的嵌套结构体，
+// 同名的字段层次浅的优先，跳过未导出的字段
+func flatten(typ *TypeInfo, p *pkgInfo) ([]leafField, error) {
+	leaves, err := collectFields(typ, p, leafField{}, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	depth := make(map[string]int, len(leaves))
+	count := make(map[string]int, len(leaves))
+	for _, leaf := range leaves {
+		d, ok := depth[leaf.goName]
+		switch {
+		case !ok || leaf.depth < d:
+			depth[leaf.goName], count[leaf.goName] = leaf.depth, 1
+		case leaf.depth == d:
+			count[leaf.goName]++
+		}
+	}
+	res := make([]leafField, 0, len(leaves))
+	for _, leaf := range leaves {
+		if leaf.depth != depth[leaf.goName] {
+			continue
+		}
+		if count[leaf.goName] > 1 {
+			return nil, fmt.Errorf("重复的字段 %s", leaf.goName)
+		}
+		res = append(res, leaf)
+	}
+	return res, nil
+}
+
+// collectFields 按照声明的顺序展开结构体中的字段，path 中是外层结构体的 goName 前缀、列名前缀和层次
+func collectFields(typ *TypeInfo, p *pkgInfo, path leafField, visiting map[string]bool) ([]leafField, error) {
+	if visiting[typ.Name] {
+		return nil, fmt.Errorf("结构体 %s 循环嵌入", typ.Name)
+	}
+	visiting[typ.Name] = true
+	defer delete(visiting, typ.Name)
+
+	var res []leafField
+	for _, fd := range typ.Fields {
+		var (
+			nested   *TypeInfo
+			isStruct bool
+			err      error
+		)
+		// 只有匿名字段和 orm:"prefix()" 标记的字段可能需要展开
+		if fd.Embedded || strings.Contains(reflect.StructTag(fd.Tag).Get("orm"), "prefix(") {
+			if nested, isStruct, err = p.structType(fd); err != nil {
+				return nil, err
+			}
+		}
+		prefix, ok, err := model.Flatten(fd.Name, fd.Embedded, strings.HasPrefix(fd.Type, "*"),
+			isStruct, reflect.StructTag(fd.Tag))
+		if err != nil {
+			return nil, fmt.Errorf("%s%s: %w", path.goName, fd.Name, err)
+		}
+		if ok {
+			sub := leafField{goName: path.goName, colPrefix: path.colPrefix + prefix, depth: path.depth + 1}
+			if !fd.Embedded {
+				sub.goName += fd.Name + "."
+			}
+			fields, err := collectFields(nested, p, sub, visiting)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, fields...)
+			continue
+		}
+		if ast.IsExported(fd.Name) {
+			res = append(res, leafField{FieldInfo: fd, goName: path.goName + fd.Name,
+				colPrefix: path.colPrefix, depth: path.depth})
+		}
+	}
+	return res, nil
 }
@@ -2,12 +2,38 @@ package main
 
 import (
 	"go/ast"
+	"go/types"
+	"path"
+	"strconv"
+	"strings"
 )
 
 type FileInfo struct {
-	Package   string
-	Imports   []string
-	Variables map[string][]string
+	Package string
+	// Name 源文件名，生成的文件名为 <Name 去掉 .go>.gen.go
+	Name    string
+	Imports []string
+	Types   []*TypeInfo
+	// Methods 源文件中声明的方法，key 为接收者的类型名
+	Methods map[string][]string
+}
+
+// TypeInfo 源文件中声明的结构体
+type TypeInfo struct {
+	Name   string
+	Fields []*FieldInfo
+}
+
+// FieldInfo 结构体字段，匿名字段和 orm:"prefix()" 标记的嵌套结构体由 flatten 展开到外层结构体
+type FieldInfo struct {
+	Name string
+	// Type 字段类型的源码，e.g. *sqlx.NullString
+	Type string
+	Tag  string
+	// Embedded 匿名字段
+	Embedded bool
+	// Imports 字段类型用到的 import，是声明字段的源文件中的写法
+	Imports []string
 }
 
 // SingleFileEntryVisitor 获取package内容
@@ -37,14 +63,32 @@ func (s *SingleFileEntryVisitor) Get() *FileInfo {
 type fileVisitor struct {
 	Package      string
 	Imports      []string
+	Methods      map[string][]string
 	typeVisitors []*typeVisitor
 }
 
 func (f *fileVisitor) Get() *FileInfo {
-	return &FileInfo{
+	// 按包名查找 import，用于确定字段类型引用了哪些 import
+	imports := make(map[string]string, len(f.Imports))
+	for _, imp := range f.Imports {
+		imports[importName(imp)] = imp
+	}
+	res := &FileInfo{
 		Imports: f.Imports,
 		Package: f.Package,
+		Methods: f.Methods,
+	}
+	for _, tv := range f.typeVisitors {
+		if tv.fields == nil {
+			continue
+		}
+		typ := &TypeInfo{Name: tv.Name}
+		for _, fd := range tv.fields.List {
+			typ.Fields = append(typ.Fields, newFieldInfos(fd, imports)...)
+		}
+		res.Types = append(res.Types, typ)
 	}
+	return res
 }
 
 func (f *fileVisitor) Visit(node ast.Node) (w ast.Visitor) {
@@ -61,23 +105,102 @@ func (f *fileVisitor) Visit(node ast.Node) (w ast.Visitor) {
 		}
 		f.typeVisitors = append(f.typeVisitors, typVisitor)
 		return typVisitor
+	case *ast.FuncDecl:
+		if n.Recv != nil && len(n.Recv.List) > 0 {
+			if f.Methods == nil {
+				f.Methods = make(map[string][]string)
+			}
+			recv := receiverName(n.Recv.List[0].Type)
+			f.Methods[recv] = append(f.Methods[recv], n.Name.Name)
+		}
+		// 不记录函数体中声明的类型
+		return nil
 	}
 
 	return f
 }
 
+// typeVisitor 只记录结构体最外层的字段，嵌套的匿名结构体类型不会被当作字段
 type typeVisitor struct {
-	Name      string
-	Variables []string
+	Name   string
+	fields *ast.FieldList
 }
 
 func (t *typeVisitor) Visit(node ast.Node) (w ast.Visitor) {
-	n, ok := node.(*ast.Field)
-	if ok {
-		for _, name := range n.Names {
-			t.Variables = append(t.Variables, name.Name)
+	if n, ok := node.(*ast.StructType); ok && t.fields == nil {
+		t.fields = n.Fields
+		return nil
+	}
+	return t
+}
+
+func newFieldInfos(fd *ast.Field, imports map[string]string) []*FieldInfo {
+	var tag string
+	if fd.Tag != nil {
+		tag, _ = strconv.Unquote(fd.Tag.Value)
+	}
+	var deps []string
+	ast.Inspect(fd.Type, func(node ast.Node) bool {
+		if sel, ok := node.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok && imports[x.Name] != "" {
+				deps = append(deps, imports[x.Name])
+			}
 		}
+		return true
+	})
+	typ := types.ExprString(fd.Type)
+	if len(fd.Names) == 0 {
+		return []*FieldInfo{{
+			Name:     embeddedName(typ),
+			Type:     typ,
+			Tag:      tag,
+			Embedded: true,
+			Imports:  deps,
+		}}
+	}
+	res := make([]*FieldInfo, 0, len(fd.Names))
+	for _, name := range fd.Names {
+		res = append(res, &FieldInfo{Name: name.Name, Type: typ, Tag: tag, Imports: deps})
 	}
+	return res
+}
 
-	return t
+// receiverName 方法接收者的类型名，去掉指针和类型参数
+func receiverName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// embeddedName 匿名字段的字段名是去掉指针和包名之后的类型名
+func embeddedName(typ string) string {
+	typ = strings.TrimPrefix(typ, "*")
+	if idx := strings.LastIndexByte(typ, '.'); idx >= 0 {
+		typ = typ[idx+1:]
+	}
+	return typ
+}
+
+// importName import 的包名：有别名时使用别名，否则使用路径的最后一段，忽略 /v2 这类版本后缀
+func importName(imp string) string {
+	if name, _, ok := strings.Cut(imp, " "); ok {
+		return name
+	}
+	p, _ := strconv.Unquote(imp)
+	name := path.Base(p)
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(p))
+	}
+	return strings.ReplaceAll(name, "-", "_")
 }
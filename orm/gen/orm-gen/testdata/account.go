@@ -0,0 +1,8 @@
+package testdata
+
+import "Soil/orm/gen/orm-gen/testdata/ext"
+
+type Account struct {
+	*ext.Model
+	Email string
+}
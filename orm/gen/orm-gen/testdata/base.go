@@ -0,0 +1,10 @@
+package testdata
+
+import "time"
+
+// BaseModel 在另一个文件中声明，嵌入到 User 中
+type BaseModel struct {
+	Id        int64
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+}
@@ -0,0 +1,20 @@
+package ext
+
+import "time"
+
+type Status int
+
+type kind int
+
+// Model 在其它包中声明，嵌入到 testdata.Account 中
+type Model struct {
+	Id        int64
+	Status    Status
+	CreatedAt time.Time
+	Tags      map[string][]*Status
+}
+
+// Hidden 字段的类型未导出，无法在其它包中生成
+type Hidden struct {
+	Kind kind
+}
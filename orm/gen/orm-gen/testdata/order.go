@@ -0,0 +1,33 @@
+package testdata
+
+import "database/sql/driver"
+
+type Address struct {
+	City   string
+	Street string `orm:"column(road)"`
+}
+
+// Audit 通过指针嵌入到 Order 中
+type Audit struct {
+	Operator string
+	Remark   string
+}
+
+// Money 实现了 driver.Valuer，作为一列读写，不会展开
+type Money struct {
+	Cents int64
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.Cents, nil
+}
+
+type Order struct {
+	Id int64
+	*Audit
+	// Remark 覆盖 Audit 中的同名字段
+	Remark   string   `orm:"column(note)"`
+	Address  Address  `orm:"prefix(addr_)"`
+	Shipping *Address `orm:"prefix(ship_)"`
+	Price    Money
+}
@@ -3,12 +3,17 @@ package testdata
 import sqlx "database/sql"
 
 type User struct {
-	Name     string
+	BaseModel
+	Name     string `orm:"column(user_name)"`
 	Age      *int
 	NickName *sqlx.NullString
 	Picture  []byte
+	Detail   *UserDetail `orm:"has_one()"`
+	password string
 }
 
 type UserDetail struct {
+	Id      int64
+	UserId  int64
 	Address string
 }
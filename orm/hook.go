@@ -11,12 +11,12 @@ import "context"
 //                     AfterInsert  在 SQL 执行成功后调用。
 //   - Selector.Get:   BeforeQuery 在生成/执行 SQL 前调用；
 //                     AfterQuery  在结果填充成功后调用。
+//   - Selector.GetMulti/Iter: BeforeQuery 在生成/执行 SQL 前对一个新建的实例调用一次；
+//                     AfterQuery  在每一行填充成功后对该行的实例调用。
 //   - Updater.Exec:   仅当 Updater 通过 Update(val) 持有模型实例时调用 BeforeUpdate/AfterUpdate；
 //                     否则跳过（例如仅使用 Set(...) 的批量更新场景）。
-//
-// Deleter 当前不实现钩子：Deleter 只持有表名与 WHERE 条件，没有可识别的模型实例引用，
-// 无法在其上调用模型方法风格的钩子。如需删除前/后逻辑，请使用中间件或在业务层显式调用。
-// 后续若为 Deleter 增加模型实例引用，可再补充 BeforeDelete/AfterDelete 支持。
+//   - Deleter.Exec:   仅当 Deleter 通过 Delete(vals...) 持有模型实例时，对每个实例调用 BeforeDelete/AfterDelete；
+//                     否则跳过（例如仅使用 Where(...) 的批量删除场景）。
 
 // BeforeInsert 在 INSERT 执行前调用。
 type BeforeInsert interface {
@@ -38,12 +38,12 @@ type AfterUpdate interface {
 	AfterUpdate(ctx context.Context) error
 }
 
-// BeforeDelete 在 DELETE 执行前调用（当前 Deleter 未实现，预留接口）。
+// BeforeDelete 在 DELETE 执行前调用（仅 Deleter 持有模型实例时）。
 type BeforeDelete interface {
 	BeforeDelete(ctx context.Context) error
 }
 
-// AfterDelete 在 DELETE 执行成功后调用（当前 Deleter 未实现，预留接口）。
+// AfterDelete 在 DELETE 执行成功后调用（仅 Deleter 持有模型实例时）。
 type AfterDelete interface {
 	AfterDelete(ctx context.Context) error
 }
@@ -129,6 +129,23 @@ func (h *HookUpdateModel) AfterUpdate(ctx context.Context) error {
 	return nil
 }
 
+// ---- Delete 钩子测试模型 ----
+
+// HookDeleteModel 实现 BeforeDelete/AfterDelete。
+type HookDeleteModel struct {
+	Id int64
+}
+
+func (h *HookDeleteModel) BeforeDelete(ctx context.Context) error {
+	hookTrace = append(hookTrace, "before_delete")
+	return nil
+}
+
+func (h *HookDeleteModel) AfterDelete(ctx context.Context) error {
+	hookTrace = append(hookTrace, "after_delete")
+	return nil
+}
+
 // ---- Insert 钩子测试 ----
 
 func TestInsertHook_BeforeInsertModifiesValueAndAfterInsertCalled(t *testing.T) {
@@ -335,3 +352,66 @@ func TestUpdaterHook_HooksSkippedWhenValNil(t *testing.T) {
 	assert.Empty(t, hookTrace)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestSelectorHook_GetMultiAndIter(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	newRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id"}).AddRow([]byte("1")).AddRow([]byte("2"))
+	}
+	mock.ExpectQuery("SELECT .*").WillReturnRows(newRows())
+	mock.ExpectQuery("SELECT .*").WillReturnRows(newRows())
+
+	// BeforeQuery 调用一次，AfterQuery 每行调用一次
+	resetHookTrace()
+	res, err := NewSelector[HookQueryModel](db).GetMulti(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, []string{"before_query", "after_query", "after_query"}, hookTrace)
+
+	resetHookTrace()
+	rows, err := NewSelector[HookQueryModel](db).Iter(context.Background())
+	require.NoError(t, err)
+	for rows.Next() {
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"before_query", "after_query", "after_query"}, hookTrace)
+
+	// BeforeQuery 返回错误时不执行查询
+	resetHookTrace()
+	_, err = NewSelector[HookQueryErrModel](db).GetMulti(context.Background())
+	assert.Equal(t, errBeforeQuery, err)
+	_, err = NewSelector[HookQueryErrModel](db).Iter(context.Background())
+	assert.Equal(t, errBeforeQuery, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleterHook_HooksCalledForEachVal(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	resetHookTrace()
+	// 没有 Where 时按实例的 Id 删除
+	mock.ExpectExec("DELETE FROM `hook_delete_model` WHERE `id` IN (?,?);").
+		WithArgs(int64(1), int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	res := NewDeleter[HookDeleteModel](db).Delete(&HookDeleteModel{Id: 1}, &HookDeleteModel{Id: 2}).
+		Exec(context.Background())
+	require.NoError(t, res.Err())
+	assert.Equal(t, []string{"before_delete", "before_delete", "after_delete", "after_delete"}, hookTrace)
+
+	// 执行失败时不调用 AfterDelete
+	resetHookTrace()
+	mock.ExpectExec("DELETE FROM `hook_delete_model` WHERE `id` IN (?);").
+		WithArgs(int64(3)).
+		WillReturnError(errors.New("mock error"))
+	res = NewDeleter[HookDeleteModel](db).Delete(&HookDeleteModel{Id: 3}).Exec(context.Background())
+	assert.Equal(t, errors.New("mock error"), res.Err())
+	assert.Equal(t, []string{"before_delete"}, hookTrace)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -19,11 +19,7 @@ type UpsertBuilder[T any] struct {
 // 支持 time.Time 与 *time.Time 两种常见字段类型；其它类型在可赋值时直接赋值，否则返回错误。
 // 该函数供 Inserter/Updater 在 Exec 阶段自动填充 CreatedAt/UpdatedAt 使用。
 func setTimestampField(val any, field *model.Field, now time.Time) error {
-	v := reflect.ValueOf(val).Elem()
-	fv := v.FieldByName(field.GoName)
-	if !fv.IsValid() {
-		return errs.NewErrUnknownField(field.GoName)
-	}
+	fv, _ := field.FieldValue(reflect.ValueOf(val).Elem(), true)
 	if !fv.CanSet() {
 		return fmt.Errorf("orm: 字段 %s 不可设置", field.GoName)
 	}
@@ -44,24 +40,17 @@ func setTimestampField(val any, field *model.Field, now time.Time) error {
 
 // isFieldZero 通过反射判断 val 上 field 指定的字段是否为零值。
 // 用于自动填充时间戳时跳过用户/钩子已设置的值（仅 CreatedAt 使用）。
+// 字段所在的结构体指针为 nil 时视为零值。
 func isFieldZero(val any, field *model.Field) bool {
-	v := reflect.ValueOf(val).Elem()
-	fv := v.FieldByName(field.GoName)
-	if !fv.IsValid() {
-		return true
-	}
-	return fv.IsZero()
+	fv, ok := field.FieldValue(reflect.ValueOf(val).Elem(), false)
+	return !ok || fv.IsZero()
 }
 
 // setIntFieldIfZero 通过反射将 val（指向结构体的指针）上 field 指定的整数族字段设置为 v，
 // 仅当字段当前为零值时才写入；非零值保留（用户/钩子已显式设置）。
 // 用于 Inserter 在 Exec 阶段自动填充 Version=1：registry 已保证 VersionField 为整数族类型。
 func setIntFieldIfZero(val any, field *model.Field, v int64) error {
-	vElem := reflect.ValueOf(val).Elem()
-	fv := vElem.FieldByName(field.GoName)
-	if !fv.IsValid() {
-		return errs.NewErrUnknownField(field.GoName)
-	}
+	fv, _ := field.FieldValue(reflect.ValueOf(val).Elem(), true)
 	if !fv.CanSet() {
 		return fmt.Errorf("orm: 字段 %s 不可设置", field.GoName)
 	}
@@ -103,7 +92,8 @@ type Inserter[T any] struct {
 	session   Session
 	chunkSize int // 分块大小，0 表示不分块（一次插完）
 
-	upsert *Upsert
+	upsert    *Upsert
+	returning []string
 }
 
 func NewInserter[T any](session Session) *Inserter[T] {
@@ -143,6 +133,14 @@ func (i *Inserter[T]) ChunkSize(n int) *Inserter[T] {
 	return i
 }
 
+// Returning 指定 RETURNING 的列（结构体字段名），e.g. Returning("Id")。
+// Exec 时数据库按 VALUES 的顺序返回这些列，并依次写回对应的 values 中，用于获取自增主键等数据库生成的值。
+// MySQL 不支持 RETURNING，Build 时返回 ErrUnsupportedFeature。
+func (i *Inserter[T]) Returning(cols ...string) *Inserter[T] {
+	i.returning = cols
+	return i
+}
+
 func (i *Inserter[T]) Build() (*Query, error) {
 	if len(i.values) == 0 {
 		return nil, errs.ErrInsertZeroRow
@@ -154,13 +152,26 @@ func (i *Inserter[T]) Build() (*Query, error) {
 	if err != nil {
 		return nil, err
 	}
+	i.reset()
+	if err = i.resolveShard(); err != nil {
+		return nil, err
+	}
 
 	i.sqlStrBuilder.WriteString("INSERT INTO ")
-	i.quote(i.model.TableName)
+	i.quoteTable(i.model)
 	i.sqlStrBuilder.WriteByte('(')
 
 	// 获取列名
 	fields := i.model.Fields
+	if i.generatesID() {
+		// 自增主键由数据库生成
+		fields = make([]*model.Field, 0, len(i.model.Fields))
+		for _, field := range i.model.Fields {
+			if field != i.model.AutoIncrementField {
+				fields = append(fields, field)
+			}
+		}
+	}
 	if len(i.columns) != 0 {
 		//用户指定列名
 		fields = make([]*model.Field, 0, len(i.columns))
@@ -198,7 +209,7 @@ func (i *Inserter[T]) Build() (*Query, error) {
 				i.sqlStrBuilder.WriteByte(',')
 			}
 			i.sqlStrBuilder.WriteByte('?')
-			fdVal, err := valDealer.GetFieldValue(field.GoName)
+			fdVal, err := fieldValue(valDealer, field)
 			if err != nil {
 				return nil, err
 			}
@@ -215,11 +226,15 @@ func (i *Inserter[T]) Build() (*Query, error) {
 		}
 	}
 
+	// 处理RETURNING部分
+	if returning := i.returningColumns(); len(returning) > 0 {
+		if err = i.dialect.buildReturning(&(i.builder), returning); err != nil {
+			return nil, err
+		}
+	}
+
 	i.sqlStrBuilder.WriteByte(';')
-	return &Query{
-		SQL:  i.sqlStrBuilder.String(),
-		Args: i.args,
-	}, nil
+	return i.buildQuery(), nil
 }
 
 //	func (i *Inserter[T]) buildAssigment(a Assignable) error {
@@ -288,16 +303,19 @@ func (i *Inserter[T]) Exec(ctx context.Context) Result {
 		}
 	}
 
-	// 执行：分块或一次性。钩子已在上面统一调用一次，下面不再触发。
+	// 执行：values 涉及多个分片时按分片分组执行，每个分片内再分块或一次性执行。
+	// 钩子已在上面统一调用一次，下面不再触发。
 	var res *QueryResult
-	if i.chunkSize > 0 && len(i.values) > i.chunkSize {
-		res = i.execChunked(ctx)
-	} else {
-		res = exec(ctx, i.core, i.session, &QueryContext{
-			Type:         "INSERT",
-			QueryBuilder: i,
-			Model:        i.model,
+	dsts, groups, err := i.shardingGroups()
+	if err != nil {
+		return Result{err: err}
+	}
+	if len(dsts) > 1 {
+		res = execShards(dsts, func(idx int, dst *Dst) *QueryResult {
+			return i.shard(dst, groups[idx]).execValues(ctx)
 		})
+	} else {
+		res = i.execValues(ctx)
 	}
 
 	var sqlRes sql.Result
@@ -322,6 +340,54 @@ func (i *Inserter[T]) Exec(ctx context.Context) Result {
 	}
 }
 
+// execValues 分块或一次性执行 values
+func (i *Inserter[T]) execValues(ctx context.Context) *QueryResult {
+	if i.chunkSize > 0 && len(i.values) > i.chunkSize {
+		return i.execChunked(ctx)
+	}
+	return i.exec(ctx, i.values, i)
+}
+
+// shardingGroups 按分片对 values 分组，模型没有分片规则时返回 nil
+func (i *Inserter[T]) shardingGroups() ([]Dst, [][]*T, error) {
+	rule, ok := i.shardings[i.model.TableName]
+	if !ok || i.shardDst != nil {
+		return nil, nil, nil
+	}
+	return routeValues(rule, i.core, i.model, i.values)
+}
+
+// resolveShard values 都属于同一个分片时改写表名，否则返回 ErrCrossShard
+func (i *Inserter[T]) resolveShard() error {
+	i.dst = i.shardDst
+	dsts, _, err := i.shardingGroups()
+	if err != nil || dsts == nil {
+		return err
+	}
+	if len(dsts) != 1 {
+		return errs.ErrCrossShard
+	}
+	i.dst = &dsts[0]
+	return nil
+}
+
+// shard 复制一个只插入 dst 分片的 Inserter
+func (i *Inserter[T]) shard(dst *Dst, values []*T) *Inserter[T] {
+	return &Inserter[T]{
+		builder: builder{
+			core:     i.core,
+			quoter:   i.quoter,
+			shardDst: dst,
+		},
+		values:    values,
+		columns:   i.columns,
+		session:   i.session,
+		chunkSize: i.chunkSize,
+		upsert:    i.upsert,
+		returning: i.returning,
+	}
+}
+
 // execChunked 将 values 按 chunkSize 分块，分别 Build + 执行，汇总影响行数。
 // 钩子（BeforeInsert/AfterInsert）已在 Exec 中对全部 values 调用一次，这里不再触发。
 // 某批失败则停止后续批次并返回该错误。
@@ -336,18 +402,12 @@ func (i *Inserter[T]) execChunked(ctx context.Context) *QueryResult {
 	var lastID int64
 	for _, chunk := range chunks {
 		i.values = chunk
-		// Build 会在已有 sqlStrBuilder 上追加，需先 Reset。
-		i.sqlStrBuilder.Reset()
 		q, err := i.Build()
 		if err != nil {
 			return &QueryResult{Error: err, Result: Result{err: err}}
 		}
 		// 用包装好的 Query 透传给 exec，避免再次 Build。
-		res := exec(ctx, i.core, i.session, &QueryContext{
-			Type:         "INSERT",
-			QueryBuilder: &chunkQueryBuilder{query: q},
-			Model:        i.model,
-		})
+		res := i.exec(ctx, chunk, &chunkQueryBuilder{query: q})
 		if res.Error != nil {
 			return res
 		}
@@ -365,6 +425,99 @@ func (i *Inserter[T]) execChunked(ctx context.Context) *QueryResult {
 	return &QueryResult{Result: Result{res: agg}, Error: nil}
 }
 
+// exec 执行一批 values 对应的 INSERT。指定了 RETURNING 时通过查询执行，
+// 并把返回的每一行按顺序写回 values 中对应的元素；否则由 LastInsertId 推算数据库生成的自增主键并写回。
+func (i *Inserter[T]) exec(ctx context.Context, values []*T, qb QueryBuilder) *QueryResult {
+	qc := &QueryContext{
+		Type:         "INSERT",
+		QueryBuilder: qb,
+		Model:        i.model,
+	}
+	if len(i.returningColumns()) == 0 {
+		res := exec(ctx, i.core, i.session, qc)
+		if res.Error == nil && i.generatesID() && i.upsert == nil {
+			if err := i.setGeneratedIDs(values, res); err != nil {
+				return &QueryResult{Error: err, Result: Result{err: err}}
+			}
+		}
+		return res
+	}
+	return execReturning(ctx, i.core, i.session, qc, func(rows *sql.Rows) (int64, error) {
+		var cnt int64
+		for rows.Next() {
+			if cnt >= int64(len(values)) {
+				return cnt, errs.ErrTooManyRows
+			}
+			if err := i.valCreator(values[cnt], i.model).SetColumns(rows); err != nil {
+				return cnt, err
+			}
+			cnt++
+		}
+		return cnt, rows.Err()
+	})
+}
+
+// generatesID 模型有自增主键、没有通过 Columns 指定列，并且 values 的自增主键都是零值时，
+// INSERT 不包含自增列，由数据库生成主键
+func (i *Inserter[T]) generatesID() bool {
+	if i.model.AutoIncrementField == nil || len(i.columns) > 0 {
+		return false
+	}
+	for _, val := range i.values {
+		if !isFieldZero(val, i.model.AutoIncrementField) {
+			return false
+		}
+	}
+	return true
+}
+
+// returningColumns RETURNING 的列。没有调用 Returning 并且需要获取自增主键的方言（例如 Postgres）
+// 通过 RETURNING 返回数据库生成的主键
+func (i *Inserter[T]) returningColumns() []string {
+	if len(i.returning) > 0 {
+		return i.returning
+	}
+	if i.upsert == nil && i.dialect.insertIDStyle() == insertIDReturning && i.generatesID() {
+		return []string{i.model.AutoIncrementField.GoName}
+	}
+	return nil
+}
+
+// setGeneratedIDs 根据 LastInsertId 推算 values 的自增主键并写回。
+// MySQL 的 LastInsertId 是第一行的主键，要求 innodb_autoinc_lock_mode 保证一条语句生成的主键连续；
+// SQLite 的 LastInsertId 是最后一行的主键
+func (i *Inserter[T]) setGeneratedIDs(values []*T, res *QueryResult) error {
+	sqlRes, ok := res.Result.(sql.Result)
+	if !ok || sqlRes == nil {
+		return nil
+	}
+	id, err := sqlRes.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if i.dialect.insertIDStyle() == insertIDLast {
+		id -= int64(len(values) - 1)
+	}
+	for j, val := range values {
+		if err = setIntFieldIfZero(val, i.model.AutoIncrementField, id+int64(j)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertIDStyle 批量插入之后获取数据库生成的自增主键的方式
+type insertIDStyle int
+
+const (
+	// insertIDReturning 通过 RETURNING 返回，例如 Postgres
+	insertIDReturning insertIDStyle = iota
+	// insertIDFirst LastInsertId 是第一行的主键，之后的行依次加一，例如 MySQL
+	insertIDFirst
+	// insertIDLast LastInsertId 是最后一行的主键，例如 SQLite
+	insertIDLast
+)
+
 // chunkQueryBuilder 包装一个已构建好的 Query，使其满足 QueryBuilder 接口。
 // 分块插入时用于将每个 chunk 的 Query 透传给 exec，避免重复 Build。
 type chunkQueryBuilder struct {
@@ -513,5 +513,59 @@ func TestInsert_ChunkSizeNotTriggeredWhenValuesLE(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+type NestedBase struct {
+	Id int64
+}
+
+type NestedAddress struct {
+	City string
+}
+
+// NestedModel 嵌入的结构体展开到顶层，Address 的列加上前缀 addr_，Shipping 为 nil 时所有列为 NULL
+type NestedModel struct {
+	NestedBase
+	Address  NestedAddress  `orm:"prefix(addr_)"`
+	Shipping *NestedAddress `orm:"prefix(ship_)"`
+}
+
+func TestInserter_Nested(t *testing.T) {
+	for name, opts := range map[string][]DBOption{
+		"unsafe":  nil,
+		"reflect": {DBUseReflect()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+			db, err := OpenDB(mockDB, opts...)
+			require.NoError(t, err)
+
+			mock.ExpectExec("INSERT INTO `nested_model`(`id`,`addr_city`,`ship_city`) VALUES (?,?,?),(?,?,?);").
+				WithArgs(int64(1), "paris", nil, int64(2), "rome", "oslo").
+				WillReturnResult(sqlmock.NewResult(2, 2))
+			mock.ExpectQuery("SELECT * FROM `nested_model` WHERE `addr_city` = ?;").
+				WithArgs("paris").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "addr_city", "ship_city"}).
+					AddRow(int64(1), "paris", nil).
+					AddRow(int64(2), "paris", "oslo"))
+
+			ctx := context.Background()
+			require.NoError(t, NewInserter[NestedModel](db).Values(
+				&NestedModel{NestedBase: NestedBase{Id: 1}, Address: NestedAddress{City: "paris"}},
+				&NestedModel{NestedBase: NestedBase{Id: 2}, Address: NestedAddress{City: "rome"},
+					Shipping: &NestedAddress{City: "oslo"}},
+			).Exec(ctx).Err())
+
+			got, err := NewSelector[NestedModel](db).Where(Col("Address.City").EQ("paris")).GetMulti(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, []*NestedModel{
+				{NestedBase: NestedBase{Id: 1}, Address: NestedAddress{City: "paris"}},
+				{NestedBase: NestedBase{Id: 2}, Address: NestedAddress{City: "paris"},
+					Shipping: &NestedAddress{City: "oslo"}},
+			}, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
 // 确保 errs 包在新增 import 后仍被使用（避免编译期 unused 报错）。
 var _ = errs.ErrInsertZeroRow
@@ -12,10 +12,19 @@ var (
 	ErrTooManyRows            = errors.New("orm: 查询到过多数据")
 	ErrTooManyReturnedColumns = errors.New("orm: 查询返回的列数比接收的列数多")
 	ErrInsertZeroRow          = errors.New("orm: 插入0行")
+	ErrUpdateZeroRow          = errors.New("orm: 批量更新0行")
 	ErrInvalidColumn          = errors.New("orm: 非法列")
 	ErrUnsupportedFeature     = errors.New("orm: 不支持的功能")
 	ErrNilPointer             = errors.New("orm: 空指针")
 	ErrOptimisticLock         = errors.New("orm: 乐观锁冲突")
+	ErrEmptyInValues          = errors.New("orm: IN 的值列表为空")
+	ErrMissingConflictColumns = errors.New("orm: upsert 没有指定冲突列")
+	ErrTxRollbackOnly         = errors.New("orm: 加入的事务中有操作失败，事务只能回滚")
+	ErrCrossShard             = errors.New("orm: 语句涉及多个分片，无法生成单条 SQL")
+	ErrLockOutsideTx          = errors.New("orm: FOR UPDATE/FOR SHARE 只能在事务中使用")
+	ErrNoPrimaryKey           = errors.New("orm: 模型没有主键")
+	ErrPrimaryKeyValues       = errors.New("orm: 主键值的数量与主键字段的数量不一致")
+	ErrConvert                = errors.New("orm: 字段的值转换失败")
 )
 
 func NewErrUnsupportedExpressionType(exp any) error {
@@ -55,3 +64,49 @@ func NewErrUnsupportedTable(table any) error {
 func NewErrUnsupportedFeature(feature string) error {
 	return fmt.Errorf("%w: %s", ErrUnsupportedFeature, feature)
 }
+
+func NewErrInvalidRelation(field string) error {
+	return fmt.Errorf("orm: 非法关联字段 %s", field)
+}
+
+func NewErrUnknownRelation(name string) error {
+	return fmt.Errorf("orm: 未知关联 %s", name)
+}
+
+func NewErrUnsupportedColumnType(field string, typ any) error {
+	return fmt.Errorf("orm: 无法推断字段 %s 的列类型 %v，请使用 orm:\"type()\" 指定", field, typ)
+}
+
+func NewErrInvalidMigrationFile(name string) error {
+	return fmt.Errorf("orm: 非法迁移文件名 %s", name)
+}
+
+func NewErrUnknownMigration(version string) error {
+	return fmt.Errorf("orm: 未找到已执行的迁移 %s", version)
+}
+
+func NewErrInvalidShardLayout(shards, dbShards int) error {
+	return fmt.Errorf("orm: 非法的分片数量 Shards=%d DBShards=%d，Shards 必须大于 0，DBShards 不能小于 0", shards, dbShards)
+}
+
+func NewErrShardNotFound(val any) error {
+	return fmt.Errorf("orm: 分片键 %v 找不到对应的分片", val)
+}
+
+// NewErrConvert 返回一个 wrap 了 ErrConvert 的错误，serializer 为转换器的名字
+func NewErrConvert(serializer string, val any) error {
+	return fmt.Errorf("%w: %s 不支持 %T 类型的值 %v", ErrConvert, serializer, val, val)
+}
+
+func NewErrDuplicateField(field string) error {
+	return fmt.Errorf("orm: 重复的字段 %s", field)
+}
+
+func NewErrDuplicateColumn(column string) error {
+	return fmt.Errorf("orm: 重复的列 %s", column)
+}
+
+// NewErrUnexpectedResult 中间件返回的查询结果类型与调用方期望的不一致
+func NewErrUnexpectedResult(res any) error {
+	return fmt.Errorf("orm: 非预期的查询结果类型 %T", res)
+}
@@ -102,6 +102,17 @@ func TestNewErrUnsupportedTable(t *testing.T) {
 	}
 }
 
+// TestNewErrConvert 验证 NewErrConvert 返回的错误 wrap 了 ErrConvert 并包含转换器的名字。
+func TestNewErrConvert(t *testing.T) {
+	err := NewErrConvert("csv", 42)
+	if !errors.Is(err, ErrConvert) {
+		t.Fatalf("errors.Is(%v, ErrConvert) = false", err)
+	}
+	if !strings.Contains(err.Error(), "csv") {
+		t.Fatalf("err.Error() = %q, want it to contain %q", err.Error(), "csv")
+	}
+}
+
 // TestExtraSentinelErrors 验证尚未在 error_test.go 中覆盖的 sentinel 错误
 // 非空且可被 errors.Is 自身匹配。
 func TestExtraSentinelErrors(t *testing.T) {
@@ -113,6 +124,7 @@ func TestExtraSentinelErrors(t *testing.T) {
 		{"ErrPointerOnly", ErrPointerOnly},
 		{"ErrTooManyReturnedColumns", ErrTooManyReturnedColumns},
 		{"ErrInsertZeroRow", ErrInsertZeroRow},
+		{"ErrUpdateZeroRow", ErrUpdateZeroRow},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
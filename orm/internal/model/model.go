@@ -1,6 +1,7 @@
 package model
 
 import (
+	"database/sql/driver"
 	"reflect"
 )
 
@@ -10,6 +11,17 @@ const (
 	tagKeyUpdatedAt = "updated_at"
 	tagKeyDeletedAt = "deleted_at"
 	tagKeyVersion   = "version"
+	tagKeySize      = "size"
+	tagKeyType      = "type"
+	tagKeyIndex     = "index"
+	tagKeyUnique    = "unique"
+	// tagKeyPrimaryKey 多个字段都标记时组成联合主键
+	tagKeyPrimaryKey    = "primary_key"
+	tagKeyAutoIncrement = "auto_increment"
+	// tagKeySerializer 通过 Converter 在字段的值和列的值之间转换，e.g. orm:"serializer(json)"
+	tagKeySerializer = "serializer"
+	// tagKeyPrefix 展开命名的嵌套结构体，字段的列名加上前缀，e.g. orm:"prefix(addr_)"
+	tagKeyPrefix = "prefix"
 )
 
 type ModelOpt func(model *Model) error
@@ -17,6 +29,8 @@ type ModelOpt func(model *Model) error
 // Model 一个model对应一个数据表
 type Model struct {
 	TableName string
+	// Type 模型对应的结构体类型（不是指针），用于在没有实例的时候创建实例，例如缓存中间件反序列化查询结果
+	Type reflect.Type
 	// FieldMap key: go结构体中字段名称
 	FieldMap map[string]*Field
 	// ColumnMap key: 数据库中列名, 主要是为了提高查询速度
@@ -39,14 +53,87 @@ type Model struct {
 	// 字段类型必须为整数族（int/int8/.../int64/uint/.../uint64），否则跳过识别。
 	// UPDATE 时自动追加 version = version + 1 与 WHERE version = ? 条件。
 	VersionField *Field
+	// Relations 关联字段，key: go结构体中字段名称，nil 表示模型没有关联字段
+	Relations map[string]*Relation
+	// Indexes 通过 orm:"index()"/orm:"unique()" 声明的索引，按声明顺序排列，主要用于生成 DDL。
+	// 多个字段使用相同的索引名时组成联合索引，列的顺序与字段的顺序一致
+	Indexes []*Index
+	// PrimaryKeys 通过 orm:"primary_key()" 标记的主键字段，按声明顺序排列，多个字段组成联合主键。
+	// 没有字段被标记时，Id 字段作为主键；没有 Id 字段时为空
+	PrimaryKeys []*Field
+	// AutoIncrementField 通过 orm:"auto_increment()" 标记的自增字段，nil 表示模型没有自增字段。
+	// 自增字段必须是整数族，并且同时是主键。插入时值为零的自增字段由数据库生成，并写回到结构体中
+	AutoIncrementField *Field
 }
 
 // Field 列的属性，比如列名，是否是主键...
+// 嵌入的结构体中的字段展开到模型上，GoName 是提升之后的名字；
+// 通过 orm:"prefix()" 展开的命名的嵌套结构体中的字段，GoName 为 <结构体字段名>.<字段名>，e.g. Address.City
 type Field struct {
 	ColName string
 	GoName  string
 	Type    reflect.Type
 	Offset  uintptr
+	// Size 通过 orm:"size(64)" 指定的列长度，0 表示使用方言的默认长度，主要用于生成 DDL
+	Size int
+	// SQLType 通过 orm:"type(TEXT)" 指定的列类型，为空时由方言根据 Type 推断，主要用于生成 DDL
+	SQLType string
+	// PrimaryKey 字段是主键或者联合主键的一部分
+	PrimaryKey bool
+	// AutoIncrement 字段的值由数据库自增生成
+	AutoIncrement bool
+	// Converter 通过 orm:"serializer(name)" 指定的转换器，nil 表示直接读写字段的值
+	Converter Converter
+	// Index 字段在模型中的路径，与 reflect.Value.FieldByIndex 的参数一致，
+	// 嵌入或者嵌套的结构体中的字段长度大于 1
+	Index []int
+	// Indirects 字段的路径上经过的结构体指针，从外到内排列，nil 表示路径上没有指针。
+	// 有指针时 Offset 是相对于最后一个指针指向的结构体的偏移量
+	Indirects []Indirect
+}
+
+// Indirect 字段的路径上经过的结构体指针
+type Indirect struct {
+	// Offset 指针相对于外层结构体（模型或者上一个指针指向的结构体）的偏移量
+	Offset uintptr
+	// Type 指针指向的结构体类型
+	Type reflect.Type
+}
+
+// FieldValue 按照 Index 返回结构体 v 中的字段。路径上有 nil 的结构体指针时，
+// alloc 为 true 则分配新的结构体，否则返回 false
+func (f *Field) FieldValue(v reflect.Value, alloc bool) (reflect.Value, bool) {
+	for i, idx := range f.Index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(idx)
+	}
+	return v, true
+}
+
+// Converter 在字段的值与数据库中的值之间转换，使字段可以使用普通的 Go 类型，
+// 而不需要实现 sql.Scanner 和 driver.Valuer
+type Converter interface {
+	// Value 将字段的值 val 转换为写入数据库的值
+	Value(val any) (driver.Value, error)
+	// Scan 将从数据库中读取的值 src 写入 dst，dst 是指向字段的指针。
+	// src 的类型与 sql.Scanner 收到的一致，src 为 []byte 时 Scan 返回之后不能再持有它
+	Scan(src any, dst any) error
+}
+
+// Index 索引的元数据
+type Index struct {
+	Name   string
+	Unique bool
+	// Columns 索引包含的列名
+	Columns []string
 }
 
 // TableName 自定义表明
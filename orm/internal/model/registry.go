@@ -3,9 +3,16 @@ package model
 import (
 	"Soil/orm/internal/errs"
 	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"go/token"
 	"reflect"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 	"unicode"
 )
 
@@ -14,20 +21,32 @@ type Registry interface {
 	Get(val any) (*Model, error)
 	// Registry 注册一个模型
 	Registry(val any, opts ...ModelOpt) (*Model, error)
+	// Models 返回已经缓存的所有模型，按表名排序
+	Models() []*Model
+	// RegisterConverter 注册 orm:"serializer(name)" 使用的 Converter，只影响之后解析的模型
+	RegisterConverter(name string, c Converter)
 }
 
 // registry 作为一个缓存，缓存数据表元数据
 type registry struct {
-	lock   sync.RWMutex //读写锁
-	models map[reflect.Type]*Model
+	lock       sync.RWMutex //读写锁
+	models     map[reflect.Type]*Model
+	converters map[string]Converter
 }
 
 func NewRegistry() Registry {
 	return &registry{
-		models: make(map[reflect.Type]*Model),
+		models:     make(map[reflect.Type]*Model),
+		converters: make(map[string]Converter),
 	}
 }
 
+func (r *registry) RegisterConverter(name string, c Converter) {
+	r.lock.Lock()
+	r.converters[name] = c
+	r.lock.Unlock()
+}
+
 // Get 接收一级指针
 func (r *registry) Get(entity any) (*Model, error) {
 	// 第一次检查: 通过读取锁来检查数据是否已经存在。如果存在直接返回结果，避免不必要的写锁开销
@@ -55,6 +74,19 @@ func (r *registry) Get(entity any) (*Model, error) {
 	return m, nil
 }
 
+func (r *registry) Models() []*Model {
+	r.lock.RLock()
+	res := make([]*Model, 0, len(r.models))
+	for _, m := range r.models {
+		res = append(res, m)
+	}
+	r.lock.RUnlock()
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].TableName < res[j].TableName
+	})
+	return res
+}
+
 func (r *registry) Registry(val any, opts ...ModelOpt) (*Model, error) {
 	m, err := r.parseModel(val)
 	if err != nil {
@@ -83,38 +115,73 @@ func (r *registry) parseModel(entity any) (*Model, error) {
 	numField := typ.NumField()
 	fields := make(map[string]*Field, numField)
 	columns := make(map[string]*Field, numField)
-	fds := make([]*Field, numField)
-	m := &Model{FieldMap: fields, ColumnMap: columns, Fields: fds}
-	for i := 0; i < numField; i++ {
-		f := typ.Field(i)
-		tags, err := r.parseTag(f.Tag) //如果有tag列名按照tag设置
-		if err != nil {
-			return nil, err
-		}
+	fds := make([]*Field, 0, numField)
+	m := &Model{Type: typ, FieldMap: fields, ColumnMap: columns}
+	leaves, err := m.collectFields(typ, structPath{})
+	if err != nil {
+		return nil, err
+	}
+	// 索引的默认名字依赖表名，等表名确定之后再处理
+	var indexTags []fieldTags
+	for _, leaf := range leaves {
+		f, tags := leaf.field, leaf.tags
 		colName := tags[tagKeyColumn]
 		if colName == "" {
 			//没有指定列名，对列名默认驼峰转下划线
 			colName = Camel2Case(f.Name)
 		}
-		fieldMeta := &Field{ColName: colName, Type: f.Type, GoName: f.Name, Offset: f.Offset}
-		fields[f.Name] = fieldMeta
+		colName = leaf.path.colPrefix + colName
+		goName := leaf.name()
+		if _, ok := columns[colName]; ok {
+			return nil, errs.NewErrDuplicateColumn(colName)
+		}
+		fieldMeta := &Field{ColName: colName, Type: f.Type, GoName: goName, Offset: leaf.path.offset,
+			SQLType: tags[tagKeyType], Index: leaf.path.index, Indirects: leaf.path.indirects}
+		if size, ok := tags[tagKeySize]; ok {
+			if fieldMeta.Size, err = strconv.Atoi(size); err != nil || fieldMeta.Size <= 0 {
+				return nil, errs.NewErrInvalidTagContent(tagKeySize + "(" + size + ")")
+			}
+		}
+		if name, ok := tags[tagKeySerializer]; ok {
+			if fieldMeta.Converter = r.converters[name]; fieldMeta.Converter == nil {
+				return nil, errs.NewErrInvalidTagContent(tagKeySerializer + "(" + name + ")")
+			}
+		}
+		if _, ok := tags[tagKeyAutoIncrement]; ok {
+			// 自增字段只能有一个，并且必须是整数族
+			if m.AutoIncrementField != nil || !isIntegerKind(f.Type.Kind()) {
+				return nil, errs.NewErrInvalidTagContent(tagKeyAutoIncrement + "()")
+			}
+			fieldMeta.AutoIncrement = true
+			fieldMeta.PrimaryKey = true
+			m.AutoIncrementField = fieldMeta
+		}
+		if _, ok := tags[tagKeyPrimaryKey]; ok {
+			fieldMeta.PrimaryKey = true
+		}
+		if fieldMeta.PrimaryKey {
+			m.PrimaryKeys = append(m.PrimaryKeys, fieldMeta)
+		}
+		indexTags = append(indexTags, fieldTags{tags: tags, colName: colName})
+		fields[goName] = fieldMeta
 		columns[colName] = fieldMeta
-		fds[i] = fieldMeta
+		fds = append(fds, fieldMeta)
 
-		// 识别时间戳/软删除字段：优先通过 tag 标记，其次按 Go 字段名匹配
+		// 识别时间戳/软删除字段：优先通过 tag 标记，其次按 Go 字段名匹配。
+		// 嵌入的结构体中的字段按提升之后的名字匹配，命名的嵌套结构体中的字段只能通过 tag 标记
 		if _, ok := tags[tagKeyCreatedAt]; ok {
 			m.CreatedAtField = fieldMeta
-		} else if f.Name == "CreatedAt" {
+		} else if goName == "CreatedAt" {
 			m.CreatedAtField = fieldMeta
 		}
 		if _, ok := tags[tagKeyUpdatedAt]; ok {
 			m.UpdatedAtField = fieldMeta
-		} else if f.Name == "UpdatedAt" {
+		} else if goName == "UpdatedAt" {
 			m.UpdatedAtField = fieldMeta
 		}
 		if _, ok := tags[tagKeyDeletedAt]; ok {
 			m.DeletedAtField = fieldMeta
-		} else if f.Name == "DeletedAt" {
+		} else if goName == "DeletedAt" {
 			m.DeletedAtField = fieldMeta
 		}
 		// 识别乐观锁版本字段：优先通过 orm:"version()" tag 标记，其次按 Go 字段名 Version 匹配。
@@ -123,12 +190,28 @@ func (r *registry) parseModel(entity any) (*Model, error) {
 			if isIntegerKind(f.Type.Kind()) {
 				m.VersionField = fieldMeta
 			}
-		} else if f.Name == "Version" {
+		} else if goName == "Version" {
 			if isIntegerKind(f.Type.Kind()) {
 				m.VersionField = fieldMeta
 			}
 		}
 	}
+	m.Fields = fds
+	// 没有标记主键时按照约定使用 Id 字段
+	if len(m.PrimaryKeys) == 0 {
+		if id, ok := fields["Id"]; ok {
+			id.PrimaryKey = true
+			m.PrimaryKeys = []*Field{id}
+		}
+	}
+
+	// 关联字段引用的当前模型上的字段必须存在
+	for _, rel := range m.Relations {
+		if _, ok := fields[rel.OwnerKey]; !ok {
+			return nil, errs.NewErrUnknownField(rel.OwnerKey)
+		}
+	}
+
 	// 处理表名
 	var tableName string
 	if tn, ok := entity.(TableName); ok {
@@ -139,9 +222,209 @@ func (r *registry) parseModel(entity any) (*Model, error) {
 		tableName = Camel2Case(typ.Name())
 	}
 	m.TableName = tableName
+
+	for _, ft := range indexTags {
+		m.addIndex(ft.tags, tagKeyIndex, false, ft.colName)
+		m.addIndex(ft.tags, tagKeyUnique, true, ft.colName)
+	}
 	return m, nil
 }
 
+// structPath 嵌入或者嵌套的结构体在模型中的位置
+type structPath struct {
+	index     []int
+	indirects []Indirect
+	// offset 结构体或者字段相对于最后一个指针指向的结构体的偏移量，路径上没有指针时是相对于模型的偏移量
+	offset uintptr
+	// goPrefix 命名的嵌套结构体中的字段的 Go 字段名前缀，e.g. Address.
+	goPrefix string
+	// colPrefix 通过 orm:"prefix()" 指定的列名前缀
+	colPrefix string
+}
+
+// child 结构体中的第 i 个字段 f 的路径
+func (p structPath) child(i int, f reflect.StructField) structPath {
+	res := p
+	res.index = append(slices.Clone(p.index), i)
+	res.offset = p.offset + f.Offset
+	return res
+}
+
+// leafField 展开嵌入和嵌套的结构体之后得到的字段
+type leafField struct {
+	field reflect.StructField
+	tags  map[string]string
+	path  structPath
+}
+
+// name 字段在模型中的名字，e.g. Address.City
+func (l leafField) name() string {
+	return l.path.goPrefix + l.field.Name
+}
+
+// collectFields 按照声明的顺序展开结构体中的字段，同名的字段按照 Go 的规则，层次浅的字段覆盖层次深的字段。
+// 只有模型上直接定义的字段可以是关联字段
+func (m *Model) collectFields(typ reflect.Type, path structPath) ([]leafField, error) {
+	var leaves []leafField
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tags, err := parseTag(f.Tag) //如果有tag列名按照tag设置
+		if err != nil {
+			return nil, err
+		}
+		if len(path.index) == 0 {
+			// 关联字段不是数据表的列，单独记录
+			rel, err := parseRelation(typ, f, tags)
+			if err != nil {
+				return nil, err
+			}
+			if rel != nil {
+				if m.Relations == nil {
+					m.Relations = make(map[string]*Relation)
+				}
+				m.Relations[f.Name] = rel
+				continue
+			}
+		}
+		sub := path.child(i, f)
+		structTyp, ok, err := flattenType(f, tags)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			leaves = append(leaves, leafField{field: f, tags: tags, path: sub})
+			continue
+		}
+		if f.Type.Kind() == reflect.Ptr {
+			sub.indirects = append(slices.Clone(path.indirects), Indirect{Offset: sub.offset, Type: structTyp})
+			sub.offset = 0
+		}
+		if !f.Anonymous {
+			sub.goPrefix = path.goPrefix + f.Name + "."
+		}
+		sub.colPrefix = path.colPrefix + tags[tagKeyPrefix]
+		nested, err := m.collectFields(structTyp, sub)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, nested...)
+	}
+	if len(path.index) > 0 {
+		return leaves, nil
+	}
+
+	// 同名的字段只保留层次最浅的，同一层次有多个同名字段时与 Go 的规则一样无法确定使用哪一个
+	depth := make(map[string]int, len(leaves))
+	count := make(map[string]int, len(leaves))
+	for _, leaf := range leaves {
+		d, ok := depth[leaf.name()]
+		switch {
+		case !ok || len(leaf.path.index) < d:
+			depth[leaf.name()], count[leaf.name()] = len(leaf.path.index), 1
+		case len(leaf.path.index) == d:
+			count[leaf.name()]++
+		}
+	}
+	res := make([]leafField, 0, len(leaves))
+	for _, leaf := range leaves {
+		if len(leaf.path.index) != depth[leaf.name()] {
+			continue
+		}
+		if count[leaf.name()] > 1 {
+			return nil, errs.NewErrDuplicateField(leaf.name())
+		}
+		res = append(res, leaf)
+	}
+	return res, nil
+}
+
+// flattenType 判断字段是否需要展开，返回需要展开的结构体类型：
+//   - 匿名的结构体或者结构体指针，没有通过 orm:"column()" 或者 orm:"serializer()" 指定为一列
+//   - 通过 orm:"prefix()" 标记的结构体或者结构体指针
+//
+// time.Time 和实现了 sql.Scanner 或者 driver.Valuer 的类型总是作为一列
+func flattenType(f reflect.StructField, tags map[string]string) (reflect.Type, bool, error) {
+	typ := f.Type
+	isPtr := typ.Kind() == reflect.Ptr
+	if isPtr {
+		typ = typ.Elem()
+	}
+	isStruct := typ.Kind() == reflect.Struct && !isColumnType(typ)
+	ok, err := flattenField(f.Name, f.Anonymous, isPtr, isStruct, tags)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return typ, true, nil
+}
+
+func flattenField(name string, anonymous, isPtr, isStruct bool, tags map[string]string) (bool, error) {
+	prefix, hasPrefix := tags[tagKeyPrefix]
+	_, hasColumn := tags[tagKeyColumn]
+	_, hasSerializer := tags[tagKeySerializer]
+	if hasPrefix && (!isStruct || hasColumn || hasSerializer) {
+		return false, errs.NewErrInvalidTagContent(tagKeyPrefix + "(" + prefix + ")")
+	}
+	if !isStruct || (!anonymous && !hasPrefix) || hasColumn || hasSerializer {
+		return false, nil
+	}
+	// 未导出的嵌套结构体中的字段无法通过反射读写；未导出的嵌入结构体指针无法通过反射分配
+	if !token.IsExported(name) && (!anonymous || isPtr) {
+		return false, errs.NewErrUnsupportedFeature("未导出的嵌套结构体 " + name)
+	}
+	return true, nil
+}
+
+// Flatten 按照与 Registry 相同的规则判断字段是否需要展开，prefix 为展开之后的列名前缀。
+// isStruct 表示字段的类型是结构体或者结构体指针，并且不是 time.Time、sql.Scanner 这类作为一列读写的类型。
+// 供 orm-gen 这类只能拿到源码的工具使用
+func Flatten(field string, anonymous, isPtr, isStruct bool, tag reflect.StructTag) (prefix string, ok bool, err error) {
+	tags, err := parseTag(tag)
+	if err != nil {
+		return "", false, err
+	}
+	ok, err = flattenField(field, anonymous, isPtr, isStruct, tags)
+	return tags[tagKeyPrefix], ok, err
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// isColumnType 结构体类型的值作为一列读写
+func isColumnType(typ reflect.Type) bool {
+	return typ == timeType || reflect.PointerTo(typ).Implements(scannerType) || typ.Implements(valuerType)
+}
+
+type fieldTags struct {
+	tags    map[string]string
+	colName string
+}
+
+// addIndex 处理 orm:"index()"、orm:"index(idx_name)" 与 orm:"unique()"，
+// 没有指定索引名时使用 idx_<表名>_<列名>/uk_<表名>_<列名>，相同索引名的字段组成联合索引
+func (m *Model) addIndex(tags map[string]string, key string, unique bool, colName string) {
+	name, ok := tags[key]
+	if !ok {
+		return
+	}
+	if name == "" {
+		prefix := "idx_"
+		if unique {
+			prefix = "uk_"
+		}
+		name = prefix + m.TableName + "_" + colName
+	}
+	for _, idx := range m.Indexes {
+		if idx.Name == name {
+			idx.Columns = append(idx.Columns, colName)
+			return
+		}
+	}
+	m.Indexes = append(m.Indexes, &Index{Name: name, Unique: unique, Columns: []string{colName}})
+}
+
 // isIntegerKind 判断 reflect.Kind 是否为整数族（int/int8-64、uint/uint8-64）。
 // 用于乐观锁 VersionField 的类型守卫：非整数族字段将被静默跳过。
 func isIntegerKind(k reflect.Kind) bool {
@@ -153,8 +436,26 @@ func isIntegerKind(k reflect.Kind) bool {
 	return false
 }
 
+// ColumnName 按照与 Registry 相同的规则返回字段的列名：优先使用 orm:"column(...)"，否则驼峰转下划线。
+// ok 为 false 表示字段是关联字段，不是数据表的列。供 orm-gen 这类只能拿到源码的工具使用
+func ColumnName(field string, tag reflect.StructTag) (col string, ok bool, err error) {
+	tags, err := parseTag(tag)
+	if err != nil {
+		return "", false, err
+	}
+	for key := range relationTagKeys {
+		if _, isRel := tags[key]; isRel {
+			return "", false, nil
+		}
+	}
+	if col = tags[tagKeyColumn]; col == "" {
+		col = Camel2Case(field)
+	}
+	return col, true, nil
+}
+
 // "orm:column(user_t);size(60)"
-func (r *registry) parseTag(tag reflect.StructTag) (map[string]string, error) {
+func parseTag(tag reflect.StructTag) (map[string]string, error) {
 	val, ok := tag.Lookup("orm")
 	if !ok {
 		return map[string]string{}, nil
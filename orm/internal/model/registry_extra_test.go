@@ -2,6 +2,7 @@ package model
 
 import (
 	"Soil/orm/internal/errs"
+	"database/sql/driver"
 	"reflect"
 	"strings"
 	"testing"
@@ -188,8 +189,8 @@ func TestRegister_InvalidTag_MultiParen(t *testing.T) {
 		"err.Error() = %q, want it to contain %q", err.Error(), "col(())")
 }
 
-// TestRegister_AnonymousField 验证匿名字段处理：parseModel 不递归展开，
-// 匿名嵌入字段作为普通字段出现，GoName 为类型名。
+// TestRegister_AnonymousField 验证匿名字段处理：嵌入的结构体展开到模型上，
+// GoName 为提升之后的字段名，Index 为字段的路径。
 type embedded struct {
 	Extra string
 }
@@ -204,16 +205,142 @@ func TestRegister_AnonymousField(t *testing.T) {
 	m, err := r.Registry(&embeddingModel{})
 	require.NoError(t, err)
 
-	fd, ok := m.FieldMap["embedded"]
+	_, ok := m.FieldMap["embedded"]
+	assert.False(t, ok)
+	fd, ok := m.FieldMap["Extra"]
 	require.True(t, ok)
-	assert.Equal(t, "embedded", fd.ColName) // Camel2Case("embedded") = "embedded"
-	assert.Equal(t, reflect.TypeOf(embedded{}), fd.Type)
+	assert.Equal(t, "extra", fd.ColName)
+	assert.Equal(t, []int{0, 0}, fd.Index)
+	assert.Equal(t, reflect.TypeOf(""), fd.Type)
 
 	_, ok = m.FieldMap["Id"]
 	assert.True(t, ok)
 	assert.Len(t, m.Fields, 2)
 }
 
+type NestedBase struct {
+	Id        int64
+	CreatedAt time.Time
+}
+
+type NestedAddress struct {
+	City string
+	Zip  *string
+}
+
+type NestedAudit struct {
+	Operator string
+	// Name 比 nestedModel.Name 层次更深，被覆盖
+	Name string
+}
+
+type NestedOther struct {
+	Id int64
+}
+
+type nestedModel struct {
+	NestedBase
+	*NestedAudit
+	Name     string
+	Address  NestedAddress  `orm:"prefix(addr_)"`
+	Shipping *NestedAddress `orm:"prefix(ship_)"`
+	Birth    time.Time
+}
+
+func TestRegister_Nested(t *testing.T) {
+	r := NewRegistry()
+	m, err := r.Registry(&nestedModel{})
+	require.NoError(t, err)
+
+	cols := make([]string, 0, len(m.Fields))
+	for _, fd := range m.Fields {
+		cols = append(cols, fd.GoName+":"+fd.ColName)
+	}
+	assert.Equal(t, []string{"Id:id", "CreatedAt:created_at", "Operator:operator", "Name:name",
+		"Address.City:addr_city", "Address.Zip:addr_zip",
+		"Shipping.City:ship_city", "Shipping.Zip:ship_zip", "Birth:birth"}, cols)
+
+	assert.Equal(t, m.FieldMap["Id"], m.PrimaryKeys[0])
+	assert.Equal(t, m.FieldMap["CreatedAt"], m.CreatedAtField)
+	assert.Equal(t, []int{0, 0}, m.FieldMap["Id"].Index)
+	assert.Nil(t, m.FieldMap["Id"].Indirects)
+
+	typ := reflect.TypeOf(nestedModel{})
+	address, _ := typ.FieldByName("Address")
+	city, _ := reflect.TypeOf(NestedAddress{}).FieldByName("City")
+	zip, _ := reflect.TypeOf(NestedAddress{}).FieldByName("Zip")
+	assert.Equal(t, address.Offset+city.Offset, m.FieldMap["Address.City"].Offset)
+
+	audit, _ := typ.FieldByName("NestedAudit")
+	assert.Equal(t, []Indirect{{Offset: audit.Offset, Type: reflect.TypeOf(NestedAudit{})}},
+		m.FieldMap["Operator"].Indirects)
+	shipping, _ := typ.FieldByName("Shipping")
+	fd := m.FieldMap["Shipping.Zip"]
+	assert.Equal(t, []Indirect{{Offset: shipping.Offset, Type: reflect.TypeOf(NestedAddress{})}}, fd.Indirects)
+	assert.Equal(t, zip.Offset, fd.Offset)
+	assert.Equal(t, []int{4, 1}, fd.Index)
+}
+
+func TestRegister_InvalidNested(t *testing.T) {
+	testCases := []struct {
+		name    string
+		val     any
+		wantErr error
+	}{
+		{
+			name: "prefix on scalar",
+			val: &struct {
+				Name string `orm:"prefix(a_)"`
+			}{},
+			wantErr: errs.NewErrInvalidTagContent("prefix(a_)"),
+		},
+		{
+			name: "prefix on time",
+			val: &struct {
+				Birth time.Time `orm:"prefix(a_)"`
+			}{},
+			wantErr: errs.NewErrInvalidTagContent("prefix(a_)"),
+		},
+		{
+			name: "duplicate column",
+			val: &struct {
+				Address  NestedAddress `orm:"prefix(addr_)"`
+				AddrCity string
+			}{},
+			wantErr: errs.NewErrDuplicateColumn("addr_city"),
+		},
+		{
+			name: "duplicate promoted column",
+			val: &struct {
+				embedded
+				Other string `orm:"column(extra)"`
+			}{},
+			wantErr: errs.NewErrDuplicateColumn("extra"),
+		},
+		{
+			name: "same depth",
+			val: &struct {
+				NestedBase
+				NestedOther
+			}{},
+			wantErr: errs.NewErrDuplicateField("Id"),
+		},
+		{
+			name: "unexported nested",
+			val: &struct {
+				address NestedAddress `orm:"prefix(addr_)"`
+			}{},
+			wantErr: errs.NewErrUnsupportedFeature("未导出的嵌套结构体 address"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewRegistry().Registry(tc.val)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
 // TestWithTableName 验证 WithTableName opt 覆盖默认表名。
 func TestWithTableName(t *testing.T) {
 	r := NewRegistry()
@@ -380,3 +507,185 @@ func TestRegister_VersionField(t *testing.T) {
 		}
 	})
 }
+
+type relationOwner struct {
+	Id       int64
+	Profile  *relationProfile `orm:"has_one()"`
+	Items    []relationItem   `orm:"has_many(foreign_key=OwnerId,references=Id)"`
+	Parent   *relationOwner   `orm:"belongs_to(foreign_key=ParentId)"`
+	ParentId int64
+	Tags     []*relationTag `orm:"many_to_many()"`
+}
+
+type relationProfile struct {
+	Id              int64
+	RelationOwnerId int64
+}
+
+type relationItem struct {
+	Id      int64
+	OwnerId int64
+}
+
+type relationTag struct {
+	Id int64
+}
+
+// TestRegister_Relations 验证关联字段的解析与默认值，且关联字段不作为列
+func TestRegister_Relations(t *testing.T) {
+	r := NewRegistry()
+	m, err := r.Registry(&relationOwner{})
+	require.NoError(t, err)
+
+	assert.Len(t, m.Fields, 2)
+	_, ok := m.FieldMap["Items"]
+	assert.False(t, ok)
+	require.Len(t, m.Relations, 4)
+
+	profile := m.Relations["Profile"]
+	assert.Equal(t, HasOne, profile.Type)
+	assert.Equal(t, reflect.TypeOf(relationProfile{}), profile.Elem)
+	assert.Equal(t, "Id", profile.OwnerKey)
+	assert.Equal(t, "relationOwnerId", profile.TargetKey)
+
+	items := m.Relations["Items"]
+	assert.Equal(t, HasMany, items.Type)
+	assert.Equal(t, reflect.TypeOf([]relationItem{}), items.FieldType)
+	assert.Equal(t, reflect.TypeOf(relationItem{}), items.Elem)
+	assert.Equal(t, "Id", items.OwnerKey)
+	assert.Equal(t, "OwnerId", items.TargetKey)
+
+	parent := m.Relations["Parent"]
+	assert.Equal(t, BelongsTo, parent.Type)
+	assert.Equal(t, "ParentId", parent.OwnerKey)
+	assert.Equal(t, "Id", parent.TargetKey)
+
+	tags := m.Relations["Tags"]
+	assert.Equal(t, ManyToMany, tags.Type)
+	assert.Equal(t, "relation_owner_relation_tag", tags.JoinTable)
+	assert.Equal(t, "relation_owner_id", tags.JoinOwnerColumn)
+	assert.Equal(t, "relation_tag_id", tags.JoinTargetColumn)
+	assert.Equal(t, "Id", tags.OwnerKey)
+	assert.Equal(t, "Id", tags.TargetKey)
+}
+
+type invalidRelationType struct {
+	Id    int64
+	Items relationItem `orm:"has_many()"`
+}
+
+type invalidRelationOption struct {
+	Id    int64
+	Items []relationItem `orm:"has_many(unknown=Id)"`
+}
+
+type invalidRelationOwnerKey struct {
+	Id     int64
+	Parent *relationOwner `orm:"belongs_to()"`
+}
+
+func TestRegister_InvalidRelations(t *testing.T) {
+	r := NewRegistry()
+	_, err := r.Registry(&invalidRelationType{})
+	assert.Equal(t, errs.NewErrInvalidRelation("Items"), err)
+
+	_, err = r.Registry(&invalidRelationOption{})
+	assert.Equal(t, errs.NewErrInvalidTagContent("unknown=Id"), err)
+
+	_, err = r.Registry(&invalidRelationOwnerKey{})
+	assert.Equal(t, errs.NewErrUnknownField("ParentId"), err)
+}
+
+type ddlModel struct {
+	Id        int64
+	Email     string `orm:"size(64);unique()"`
+	FirstName string `orm:"index(idx_name)"`
+	LastName  string `orm:"index(idx_name)"`
+	Age       int8   `orm:"index()"`
+	Bio       string `orm:"type(TEXT)"`
+}
+
+// TestRegister_DDLTags 验证 size/type/index/unique 标签的解析
+func TestRegister_DDLTags(t *testing.T) {
+	r := NewRegistry()
+	m, err := r.Registry(&ddlModel{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 64, m.FieldMap["Email"].Size)
+	assert.Equal(t, "TEXT", m.FieldMap["Bio"].SQLType)
+	assert.Equal(t, []*Index{
+		{Name: "uk_ddl_model_email", Unique: true, Columns: []string{"email"}},
+		{Name: "idx_name", Columns: []string{"first_name", "last_name"}},
+		{Name: "idx_ddl_model_age", Columns: []string{"age"}},
+	}, m.Indexes)
+
+	_, err = r.Registry(&struct {
+		Name string `orm:"size(abc)"`
+	}{})
+	assert.Equal(t, errs.NewErrInvalidTagContent("size(abc)"), err)
+}
+
+type pkModel struct {
+	Id   int64 `orm:"auto_increment()"`
+	Name string
+}
+
+type compositePKModel struct {
+	TenantId int64  `orm:"primary_key()"`
+	Code     string `orm:"primary_key()"`
+	Name     string
+}
+
+// TestRegister_PrimaryKey 验证 primary_key/auto_increment 标签以及默认的 Id 主键
+func TestRegister_PrimaryKey(t *testing.T) {
+	r := NewRegistry()
+
+	m, err := r.Registry(&basicModel{})
+	require.NoError(t, err)
+	assert.Equal(t, []*Field{m.FieldMap["Id"]}, m.PrimaryKeys)
+	assert.True(t, m.FieldMap["Id"].PrimaryKey)
+	assert.Nil(t, m.AutoIncrementField)
+
+	m, err = r.Registry(&pkModel{})
+	require.NoError(t, err)
+	assert.Equal(t, []*Field{m.FieldMap["Id"]}, m.PrimaryKeys)
+	assert.Equal(t, m.FieldMap["Id"], m.AutoIncrementField)
+	assert.True(t, m.FieldMap["Id"].AutoIncrement)
+
+	m, err = r.Registry(&compositePKModel{})
+	require.NoError(t, err)
+	assert.Equal(t, []*Field{m.FieldMap["TenantId"], m.FieldMap["Code"]}, m.PrimaryKeys)
+	assert.False(t, m.FieldMap["Name"].PrimaryKey)
+
+	m, err = r.Registry(&struct{ Name string }{})
+	require.NoError(t, err)
+	assert.Empty(t, m.PrimaryKeys)
+
+	_, err = r.Registry(&struct {
+		Code string `orm:"auto_increment()"`
+	}{})
+	assert.Equal(t, errs.NewErrInvalidTagContent("auto_increment()"), err)
+}
+
+type stringConverter struct{}
+
+func (stringConverter) Value(val any) (driver.Value, error) { return val, nil }
+func (stringConverter) Scan(src any, dst any) error         { return nil }
+
+func TestRegister_Serializer(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterConverter("str", stringConverter{})
+
+	m, err := r.Registry(&struct {
+		Tags []string `orm:"serializer(str)"`
+		Name string
+	}{})
+	require.NoError(t, err)
+	assert.Equal(t, stringConverter{}, m.FieldMap["Tags"].Converter)
+	assert.Nil(t, m.FieldMap["Name"].Converter)
+
+	_, err = r.Registry(&struct {
+		Tags []string `orm:"serializer(unknown)"`
+	}{})
+	assert.Equal(t, errs.NewErrInvalidTagContent("serializer(unknown)"), err)
+}
@@ -0,0 +1,179 @@
+package model
+
+import (
+	"Soil/orm/internal/errs"
+	"reflect"
+	"strings"
+)
+
+const (
+	tagKeyHasOne     = "has_one"
+	tagKeyHasMany    = "has_many"
+	tagKeyBelongsTo  = "belongs_to"
+	tagKeyManyToMany = "many_to_many"
+
+	relKeyForeignKey     = "foreign_key"
+	relKeyReferences     = "references"
+	relKeyTargetKey      = "target_key"
+	relKeyJoinTable      = "join_table"
+	relKeyJoinForeignKey = "join_foreign_key"
+	relKeyJoinReferences = "join_references"
+)
+
+type RelationType int
+
+const (
+	// HasOne e.g. User 有一个 Profile，Profile.UserId 引用 User.Id
+	HasOne RelationType = iota + 1
+	// HasMany e.g. Order 有多个 OrderItem，OrderItem.OrderId 引用 Order.Id
+	HasMany
+	// BelongsTo e.g. OrderItem 属于 Order，OrderItem.OrderId 引用 Order.Id
+	BelongsTo
+	// ManyToMany e.g. Order 与 Tag 通过中间表 order_tag(order_id, tag_id) 关联
+	ManyToMany
+)
+
+// Relation 关联字段的元数据，关联字段不是数据表的列，不出现在 Fields/FieldMap/ColumnMap 中。
+// tag 的写法为 orm:"has_many(foreign_key=OrderId,references=Id)"，括号内的选项都可以省略：
+//   - has_one/has_many: foreign_key 为关联模型上的字段，默认 <当前结构体名>Id；
+//     references 为当前模型上的字段，默认 Id
+//   - belongs_to: foreign_key 为当前模型上的字段，默认 <关联字段名>Id；references 为关联模型上的字段，默认 Id
+//   - many_to_many: join_table 中间表名，默认 <当前结构体名>_<关联结构体名>（驼峰转下划线）；
+//     join_foreign_key 中间表中引用当前模型的列，默认 <当前结构体名>_id；
+//     join_references 中间表中引用关联模型的列，默认 <关联结构体名>_id；
+//     references 为当前模型上的字段，默认 Id；target_key 为关联模型上的字段，默认 Id
+type Relation struct {
+	Type RelationType
+	// GoName 关联字段在结构体中的名字
+	GoName string
+	// FieldType 关联字段的类型，例如 *Profile、[]*OrderItem
+	FieldType reflect.Type
+	// Elem 关联的结构体类型，例如 OrderItem
+	Elem   reflect.Type
+	Offset uintptr
+
+	// OwnerKey 当前模型上参与关联的字段（Go 字段名）
+	OwnerKey string
+	// TargetKey 关联模型上参与关联的字段（Go 字段名）
+	TargetKey string
+
+	// JoinTable 多对多关联的中间表
+	JoinTable string
+	// JoinOwnerColumn 中间表中引用当前模型的列名
+	JoinOwnerColumn string
+	// JoinTargetColumn 中间表中引用关联模型的列名
+	JoinTargetColumn string
+}
+
+// relationTagKeys 按 tag 中的 key 查找关联类型
+var relationTagKeys = map[string]RelationType{
+	tagKeyHasOne:     HasOne,
+	tagKeyHasMany:    HasMany,
+	tagKeyBelongsTo:  BelongsTo,
+	tagKeyManyToMany: ManyToMany,
+}
+
+// parseRelation 解析关联字段，tags 中没有关联相关的 key 时返回 nil
+func parseRelation(owner reflect.Type, f reflect.StructField, tags map[string]string) (*Relation, error) {
+	var (
+		typ   RelationType
+		value string
+	)
+	for key, rt := range relationTagKeys {
+		if v, ok := tags[key]; ok {
+			if typ != 0 {
+				return nil, errs.NewErrInvalidRelation(f.Name)
+			}
+			typ, value = rt, v
+		}
+	}
+	if typ == 0 {
+		return nil, nil
+	}
+
+	opts, err := parseRelationOptions(value)
+	if err != nil {
+		return nil, err
+	}
+
+	elem, ok := relationElem(typ, f.Type)
+	if !ok {
+		return nil, errs.NewErrInvalidRelation(f.Name)
+	}
+
+	rel := &Relation{
+		Type:      typ,
+		GoName:    f.Name,
+		FieldType: f.Type,
+		Elem:      elem,
+		Offset:    f.Offset,
+	}
+	switch typ {
+	case HasOne, HasMany:
+		rel.OwnerKey = optOrDefault(opts, relKeyReferences, "Id")
+		rel.TargetKey = optOrDefault(opts, relKeyForeignKey, owner.Name()+"Id")
+	case BelongsTo:
+		rel.OwnerKey = optOrDefault(opts, relKeyForeignKey, f.Name+"Id")
+		rel.TargetKey = optOrDefault(opts, relKeyReferences, "Id")
+	case ManyToMany:
+		ownerTable, elemTable := Camel2Case(owner.Name()), Camel2Case(elem.Name())
+		rel.OwnerKey = optOrDefault(opts, relKeyReferences, "Id")
+		rel.TargetKey = optOrDefault(opts, relKeyTargetKey, "Id")
+		rel.JoinTable = optOrDefault(opts, relKeyJoinTable, ownerTable+"_"+elemTable)
+		rel.JoinOwnerColumn = optOrDefault(opts, relKeyJoinForeignKey, ownerTable+"_id")
+		rel.JoinTargetColumn = optOrDefault(opts, relKeyJoinReferences, elemTable+"_id")
+	}
+	return rel, nil
+}
+
+// parseRelationOptions 解析 "foreign_key=OrderId,references=Id"
+func parseRelationOptions(value string) (map[string]string, error) {
+	res := make(map[string]string)
+	if value == "" {
+		return res, nil
+	}
+	for _, opt := range strings.Split(value, ",") {
+		pair := strings.Split(opt, "=")
+		if len(pair) != 2 || pair[0] == "" || pair[1] == "" {
+			return nil, errs.NewErrInvalidTagContent(opt)
+		}
+		switch pair[0] {
+		case relKeyForeignKey, relKeyReferences, relKeyTargetKey,
+			relKeyJoinTable, relKeyJoinForeignKey, relKeyJoinReferences:
+		default:
+			return nil, errs.NewErrInvalidTagContent(opt)
+		}
+		res[pair[0]] = pair[1]
+	}
+	return res, nil
+}
+
+// relationElem 校验关联字段的类型并返回关联的结构体类型：
+// has_one/belongs_to 只支持 *Struct，has_many/many_to_many 支持 []Struct 和 []*Struct
+func relationElem(typ RelationType, fieldType reflect.Type) (reflect.Type, bool) {
+	switch typ {
+	case HasOne, BelongsTo:
+		if fieldType.Kind() == reflect.Ptr && fieldType.Elem().Kind() == reflect.Struct {
+			return fieldType.Elem(), true
+		}
+	case HasMany, ManyToMany:
+		if fieldType.Kind() != reflect.Slice {
+			return nil, false
+		}
+		elem := fieldType.Elem()
+		if elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		if elem.Kind() == reflect.Struct {
+			return elem, true
+		}
+	}
+	return nil, false
+}
+
+func optOrDefault(opts map[string]string, key string, def string) string {
+	if v, ok := opts[key]; ok {
+		return v
+	}
+	return def
+}
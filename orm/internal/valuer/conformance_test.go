@@ -0,0 +1,203 @@
+package valuer
+
+import (
+	"Soil/orm/internal/errs"
+	"Soil/orm/internal/model"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 此文件是 reflect 与 unsafe 两种实现共用的测试，两种实现的行为必须完全一致
+
+func Test_reflectValue_Conformance(t *testing.T) {
+	testValuer(t, NewReflectValue)
+}
+
+func Test_unsafeValue_Conformance(t *testing.T) {
+	testValuer(t, NewUnsafeValue)
+}
+
+// upperConverter 读取时转换为大写，写入时转换为小写
+type upperConverter struct{}
+
+func (upperConverter) Value(val any) (driver.Value, error) {
+	return strings.ToLower(val.(string)), nil
+}
+
+func (upperConverter) Scan(src any, dst any) error {
+	*(dst.(*string)) = strings.ToUpper(string(src.([]byte)))
+	return nil
+}
+
+type BaseStruct struct {
+	Id   int64
+	Name string `orm:"serializer(upper)"`
+}
+
+type AuditStruct struct {
+	Operator string
+	Level    *int
+}
+
+type AddressStruct struct {
+	City  string
+	Extra *ExtraStruct `orm:"prefix(extra_)"`
+}
+
+type ExtraStruct struct {
+	Note string
+}
+
+// NestedStruct 覆盖嵌入的结构体、嵌入的结构体指针、命名的嵌套结构体、多层的嵌套结构体指针以及指针字段
+type NestedStruct struct {
+	BaseStruct
+	*AuditStruct
+	Age      *int
+	Address  AddressStruct  `orm:"prefix(addr_)"`
+	Shipping *AddressStruct `orm:"prefix(ship_)"`
+}
+
+var nestedColumns = []string{"id", "name", "operator", "level", "age",
+	"addr_city", "addr_extra_note", "ship_city", "ship_extra_note"}
+
+func testValuer(t *testing.T, creator Creator) {
+	r := model.NewRegistry()
+	r.RegisterConverter("upper", upperConverter{})
+	meta, err := r.Get(&NestedStruct{})
+	require.NoError(t, err)
+	level, age := 3, 18
+
+	setColumnsCases := []struct {
+		name    string
+		values  []driver.Value
+		entity  *NestedStruct
+		wantVal *NestedStruct
+	}{
+		{
+			name:   "all columns",
+			values: []driver.Value{int64(1), []byte("tom"), "jerry", int64(3), int64(18), "paris", "n1", "rome", "n2"},
+			entity: &NestedStruct{},
+			wantVal: &NestedStruct{
+				BaseStruct:  BaseStruct{Id: 1, Name: "TOM"},
+				AuditStruct: &AuditStruct{Operator: "jerry", Level: &level},
+				Age:         &age,
+				Address:     AddressStruct{City: "paris", Extra: &ExtraStruct{Note: "n1"}},
+				Shipping:    &AddressStruct{City: "rome", Extra: &ExtraStruct{Note: "n2"}},
+			},
+		},
+		{
+			// 结构体指针中的列都是 NULL 时结构体指针保持为 nil
+			name:   "null columns",
+			values: []driver.Value{int64(1), []byte("tom"), nil, nil, nil, "paris", nil, nil, nil},
+			entity: &NestedStruct{},
+			wantVal: &NestedStruct{
+				BaseStruct: BaseStruct{Id: 1, Name: "TOM"},
+				Address:    AddressStruct{City: "paris"},
+			},
+		},
+		{
+			// 只要有一列不是 NULL 就分配结构体，其它 NULL 的列为零值
+			name:   "partial null",
+			values: []driver.Value{int64(1), []byte("tom"), nil, int64(3), nil, "", nil, nil, "n2"},
+			entity: &NestedStruct{},
+			wantVal: &NestedStruct{
+				BaseStruct:  BaseStruct{Id: 1, Name: "TOM"},
+				AuditStruct: &AuditStruct{Level: &level},
+				Shipping:    &AddressStruct{Extra: &ExtraStruct{Note: "n2"}},
+			},
+		},
+		{
+			// 已经分配的结构体指针中 NULL 的列置为零值
+			name:   "allocated",
+			values: []driver.Value{int64(1), []byte("tom"), nil, nil, nil, "", nil, nil, nil},
+			entity: &NestedStruct{AuditStruct: &AuditStruct{Operator: "old", Level: &level}},
+			wantVal: &NestedStruct{
+				BaseStruct:  BaseStruct{Id: 1, Name: "TOM"},
+				AuditStruct: &AuditStruct{},
+			},
+		},
+	}
+	for _, tc := range setColumnsCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer func() { _ = db.Close() }()
+			mock.ExpectQuery("SELECT *").WillReturnRows(sqlmock.NewRows(nestedColumns).AddRow(tc.values...))
+			rows, err := db.Query("SELECT *")
+			require.NoError(t, err)
+			require.True(t, rows.Next())
+
+			require.NoError(t, creator(tc.entity, meta).SetColumns(rows))
+			assert.Equal(t, tc.wantVal, tc.entity)
+		})
+	}
+
+	getFieldValueCases := []struct {
+		name    string
+		entity  *NestedStruct
+		field   string
+		wantVal any
+		wantErr error
+	}{
+		{
+			name:    "embedded",
+			entity:  &NestedStruct{BaseStruct: BaseStruct{Id: 1}},
+			field:   "Id",
+			wantVal: int64(1),
+		},
+		{
+			// 返回字段本身的值，写入数据库时由调用方通过 Converter 转换
+			name:    "converter",
+			entity:  &NestedStruct{BaseStruct: BaseStruct{Name: "TOM"}},
+			field:   "Name",
+			wantVal: "TOM",
+		},
+		{
+			name:    "embedded pointer",
+			entity:  &NestedStruct{AuditStruct: &AuditStruct{Operator: "jerry"}},
+			field:   "Operator",
+			wantVal: "jerry",
+		},
+		{
+			// 结构体指针为 nil 时返回 nil，写入数据库时为 NULL
+			name:   "nil embedded pointer",
+			entity: &NestedStruct{},
+			field:  "Operator",
+		},
+		{
+			name:    "nested",
+			entity:  &NestedStruct{Address: AddressStruct{City: "paris"}},
+			field:   "Address.City",
+			wantVal: "paris",
+		},
+		{
+			name:   "nil nested pointer",
+			entity: &NestedStruct{Shipping: &AddressStruct{}},
+			field:  "Shipping.Extra.Note",
+		},
+		{
+			name:    "pointer field",
+			entity:  &NestedStruct{Age: &age},
+			field:   "Age",
+			wantVal: &age,
+		},
+		{
+			name:    "unknown field",
+			entity:  &NestedStruct{},
+			field:   "AuditStruct",
+			wantErr: errs.NewErrUnknownField("AuditStruct"),
+		},
+	}
+	for _, tc := range getFieldValueCases {
+		t.Run(tc.name, func(t *testing.T) {
+			val, err := creator(tc.entity, meta).GetFieldValue(tc.field)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantVal, val)
+		})
+	}
+}
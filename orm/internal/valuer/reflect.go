@@ -29,14 +29,15 @@ func (r reflectValue) SetColumns(rows *sql.Rows) error {
 		return err
 	}
 
+	dsts := make([]*columnDst, len(columns))
 	vals := make([]any, len(columns))
 	for i, column := range columns {
 		fd, ok := r.meta.ColumnMap[column]
 		if !ok {
 			return errs.NewErrUnknownColumn(column)
 		}
-		newVal := reflect.New(fd.Type)
-		vals[i] = newVal.Interface()
+		dsts[i] = newColumnDst(fd)
+		vals[i] = dsts[i].target()
 	}
 
 	err = rows.Scan(vals...)
@@ -44,22 +45,39 @@ func (r reflectValue) SetColumns(rows *sql.Rows) error {
 		return err
 	}
 
-	for i, column := range columns {
-		fd, ok := r.meta.ColumnMap[column]
+	for _, dst := range dsts {
+		val, ok := dst.value()
 		if !ok {
-			return errs.NewErrUnknownColumn(column)
+			// 嵌套在结构体指针中的列为 NULL，结构体指针已经分配时把字段置为零值
+			if fv, allocated := dst.fd.FieldValue(r.val, false); allocated {
+				fv.SetZero()
+			}
+			continue
 		}
-		r.val.FieldByName(fd.GoName).Set(reflect.ValueOf(vals[i]).Elem())
+		fv, _ := dst.fd.FieldValue(r.val, true)
+		fv.Set(val)
 	}
 
 	return nil
 }
 
+// GetFieldValue 字段所在的结构体指针为 nil 时返回 nil，写入数据库时为 NULL
 func (r reflectValue) GetFieldValue(name string) (any, error) {
-	res := r.val.FieldByName(name)
-	if !res.IsValid() {
+	fd, ok := r.meta.FieldMap[name]
+	if !ok {
 		return nil, errs.NewErrUnknownField(name)
 	}
-
+	res, ok := fd.FieldValue(r.val, false)
+	if !ok {
+		return nil, nil
+	}
 	return res.Interface(), nil
 }
+
+func (r reflectValue) SetRelationValue(name string, val any) error {
+	if _, ok := r.meta.Relations[name]; !ok {
+		return errs.NewErrUnknownRelation(name)
+	}
+	r.val.FieldByName(name).Set(reflect.ValueOf(val))
+	return nil
+}
@@ -35,21 +35,47 @@ func (u unsafeValue) SetColumns(rows *sql.Rows) error {
 	}
 
 	colValues := make([]any, len(colNames))
+	// nested 嵌套在结构体指针中的字段，先读取到临时变量中
+	var nested []*columnDst
 	for i, colName := range colNames {
 		column, ok := u.meta.ColumnMap[colName]
 		if !ok {
 			return errs.NewErrUnknownColumn(colName)
 		}
+		if len(column.Indirects) > 0 {
+			dst := newColumnDst(column)
+			nested = append(nested, dst)
+			colValues[i] = dst.target()
+			continue
+		}
 
 		// NewAt返回的是指针
 		val := reflect.NewAt(column.Type, unsafe.Pointer(uintptr(u.addr)+column.Offset))
-		colValues[i] = val.Interface()
+		colValues[i] = scanTarget(column, val.Interface())
 	}
 
 	// 应为这里这里的val指向的是字段地址在写入后内容直接在各字段内存中
-	return rows.Scan(colValues...)
+	if err = rows.Scan(colValues...); err != nil {
+		return err
+	}
+	for _, dst := range nested {
+		val, ok := dst.value()
+		// 列为 NULL 时不分配结构体指针，结构体指针已经分配时把字段置为零值
+		addr := u.fieldAddr(dst.fd, ok)
+		if addr == nil {
+			continue
+		}
+		fv := reflect.NewAt(dst.fd.Type, addr).Elem()
+		if ok {
+			fv.Set(val)
+		} else {
+			fv.SetZero()
+		}
+	}
+	return nil
 }
 
+// GetFieldValue 字段所在的结构体指针为 nil 时返回 nil，写入数据库时为 NULL
 func (u unsafeValue) GetFieldValue(name string) (any, error) {
 	field, ok := u.meta.FieldMap[name]
 	if !ok {
@@ -59,9 +85,38 @@ func (u unsafeValue) GetFieldValue(name string) (any, error) {
 	if u.addr == nil {
 		return nil, fmt.Errorf("orm: 获取字段 %s 的值失败，底层指针为 nil", name)
 	}
-	res := reflect.NewAt(field.Type, unsafe.Pointer(uintptr(u.addr)+field.Offset)).Elem()
+	addr := u.fieldAddr(field, false)
+	if addr == nil {
+		return nil, nil
+	}
+	res := reflect.NewAt(field.Type, addr).Elem()
 	if !res.CanAddr() {
 		return nil, fmt.Errorf("orm: 获取字段 %s 的值失败，值不可寻址", name)
 	}
 	return res.Interface(), nil
 }
+
+// fieldAddr 字段的地址。路径上有 nil 的结构体指针时，alloc 为 true 则分配新的结构体，否则返回 nil
+func (u unsafeValue) fieldAddr(fd *model.Field, alloc bool) unsafe.Pointer {
+	base := u.addr
+	for _, ind := range fd.Indirects {
+		ptr := (*unsafe.Pointer)(unsafe.Pointer(uintptr(base) + ind.Offset))
+		if *ptr == nil {
+			if !alloc {
+				return nil
+			}
+			*ptr = reflect.New(ind.Type).UnsafePointer()
+		}
+		base = *ptr
+	}
+	return unsafe.Pointer(uintptr(base) + fd.Offset)
+}
+
+func (u unsafeValue) SetRelationValue(name string, val any) error {
+	rel, ok := u.meta.Relations[name]
+	if !ok {
+		return errs.NewErrUnknownRelation(name)
+	}
+	reflect.NewAt(rel.FieldType, unsafe.Pointer(uintptr(u.addr)+rel.Offset)).Elem().Set(reflect.ValueOf(val))
+	return nil
+}
@@ -3,12 +3,78 @@ package valuer
 import (
 	"Soil/orm/internal/model"
 	"database/sql"
+	"reflect"
 )
 
 // Valuer 处理结果集方法的抽象，比如可以通过unsafe或者reflect来处理
 type Valuer interface {
 	SetColumns(rows *sql.Rows) error
 	GetFieldValue(name string) (any, error)
+	// SetRelationValue 设置关联字段的值，val 的类型必须与关联字段的类型一致，例如 []*OrderItem
+	SetRelationValue(name string, val any) error
 }
 
 type Creator func(val any, meta *model.Model) Valuer
+
+// converterScanner 通过字段的 Converter 把列的值写入 dst，dst 是指向字段的指针
+type converterScanner struct {
+	converter model.Converter
+	dst       any
+}
+
+func (c converterScanner) Scan(src any) error {
+	return c.converter.Scan(src, c.dst)
+}
+
+// scanTarget 字段指定了 Converter 时通过 Converter 写入 dst
+func scanTarget(fd *model.Field, dst any) any {
+	if fd.Converter == nil {
+		return dst
+	}
+	return converterScanner{converter: fd.Converter, dst: dst}
+}
+
+// columnDst 嵌套在结构体指针中的字段在 rows.Scan 中的临时目标。
+// 读取之后只有列不是 NULL 时才分配路径上的结构体指针，所有列都是 NULL 时结构体指针保持为 nil
+type columnDst struct {
+	fd *model.Field
+	// ptr 指向临时值的指针。没有 Converter 时为 **T，由 database/sql 处理 NULL
+	ptr reflect.Value
+	// null 有 Converter 时记录列是否为 NULL
+	null bool
+}
+
+func newColumnDst(fd *model.Field) *columnDst {
+	if len(fd.Indirects) > 0 && fd.Converter == nil {
+		return &columnDst{fd: fd, ptr: reflect.New(reflect.PointerTo(fd.Type))}
+	}
+	return &columnDst{fd: fd, ptr: reflect.New(fd.Type)}
+}
+
+// target 传给 rows.Scan 的目标
+func (c *columnDst) target() any {
+	if c.fd.Converter != nil {
+		return c
+	}
+	return c.ptr.Interface()
+}
+
+func (c *columnDst) Scan(src any) error {
+	if src == nil && len(c.fd.Indirects) > 0 {
+		c.null = true
+		return nil
+	}
+	return c.fd.Converter.Scan(src, c.ptr.Interface())
+}
+
+// value 读取到的字段的值，嵌套在结构体指针中的字段的列为 NULL 时返回 false
+func (c *columnDst) value() (reflect.Value, bool) {
+	if len(c.fd.Indirects) > 0 && c.fd.Converter == nil {
+		p := c.ptr.Elem()
+		if p.IsNil() {
+			return reflect.Value{}, false
+		}
+		return p.Elem(), true
+	}
+	return c.ptr.Elem(), !c.null
+}
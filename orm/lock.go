@@ -0,0 +1,70 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+	"context"
+)
+
+// rowLock SELECT 末尾的悲观锁子句，e.g. FOR UPDATE SKIP LOCKED
+type rowLock struct {
+	// strength FOR UPDATE 或者 FOR SHARE，空字符串表示不加锁
+	strength string
+	// wait 获取不到行锁时的行为：SKIP LOCKED 跳过被锁住的行，NOWAIT 立即返回错误，空字符串表示等待
+	wait string
+}
+
+const (
+	lockForUpdate  = "FOR UPDATE"
+	lockForShare   = "FOR SHARE"
+	lockSkipLocked = "SKIP LOCKED"
+	lockNoWait     = "NOWAIT"
+)
+
+// ForUpdate SELECT ... FOR UPDATE，对查询到的行加排他锁，只能在事务中使用，
+// e.g. NewSelector[Job](tx).Where(...).Limit(10).ForUpdate().SkipLocked().GetMulti(ctx)
+func (s *Selector[T]) ForUpdate() *Selector[T] {
+	s.lock.strength = lockForUpdate
+	return s
+}
+
+// ForShare SELECT ... FOR SHARE，对查询到的行加共享锁，只能在事务中使用
+func (s *Selector[T]) ForShare() *Selector[T] {
+	s.lock.strength = lockForShare
+	return s
+}
+
+// SkipLocked 跳过已经被其他事务锁住的行，需要与 ForUpdate 或 ForShare 一起使用
+func (s *Selector[T]) SkipLocked() *Selector[T] {
+	s.lock.wait = lockSkipLocked
+	return s
+}
+
+// NoWait 有行已经被其他事务锁住时立即返回错误，需要与 ForUpdate 或 ForShare 一起使用
+func (s *Selector[T]) NoWait() *Selector[T] {
+	s.lock.wait = lockNoWait
+	return s
+}
+
+// buildLock 校验并生成锁子句。行锁在事务结束时释放，session 是 *DB 时加锁没有意义，直接拒绝；
+// 只有在执行时 checkLock 确认 ctx 中有该 DB 开启的事务（e.g. db.DoTx）时才允许
+func (s *Selector[T]) buildLock() error {
+	if s.lock.strength == "" {
+		if s.lock.wait != "" {
+			return errs.NewErrUnsupportedFeature(s.lock.wait + " 需要与 FOR UPDATE 或 FOR SHARE 一起使用")
+		}
+		return nil
+	}
+	if _, ok := s.session.(*DB); ok && !s.lockInTx {
+		return errs.ErrLockOutsideTx
+	}
+	return s.dialect.buildLock(&s.builder, s.lock)
+}
+
+// checkLock 在执行时校验是否在事务中：session 是 *Tx，或者 ctx 中有 session 开启的事务
+func (s *Selector[T]) checkLock(ctx context.Context) error {
+	s.lockInTx = sessionTx(ctx, s.session) != nil
+	if s.lock.strength != "" && !s.lockInTx {
+		return errs.ErrLockOutsideTx
+	}
+	return nil
+}
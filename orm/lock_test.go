@@ -0,0 +1,125 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelector_Lock(t *testing.T) {
+	newTx := func(dialect Dialect) (*DB, *Tx) {
+		mockDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		mock.ExpectBegin()
+		db, err := OpenDB(mockDB, DBWithDialect(dialect))
+		require.NoError(t, err)
+		tx, err := db.BeginTx(context.Background(), nil)
+		require.NoError(t, err)
+		return db, tx
+	}
+	mysqlDB, mysql := newTx(MySQL)
+	_, postgres := newTx(Postgres)
+	_, sqlite := newTx(SQLite)
+
+	testCases := []struct {
+		name      string
+		builder   QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "mysql for update skip locked",
+			builder: NewSelector[TestModel](mysql).Where(Col("Age").GT(18)).Limit(10).ForUpdate().SkipLocked(),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `age` > ? LIMIT ? FOR UPDATE SKIP LOCKED;",
+				Args: []any{18, 10},
+			},
+		},
+		{
+			name:    "mysql for share",
+			builder: NewSelector[TestModel](mysql).ForShare(),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` LOCK IN SHARE MODE;",
+			},
+		},
+		{
+			name:    "mysql for share nowait",
+			builder: NewSelector[TestModel](mysql).ForShare().NoWait(),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` FOR SHARE NOWAIT;",
+			},
+		},
+		{
+			name:    "postgres for share",
+			builder: NewSelector[TestModel](postgres).Where(Col("Id").EQ(1)).ForShare(),
+			wantQuery: &Query{
+				SQL:  `SELECT * FROM "test_model" WHERE "id" = $1 FOR SHARE;`,
+				Args: []any{1},
+			},
+		},
+		{
+			name:    "postgres for update nowait",
+			builder: NewSelector[TestModel](postgres).ForUpdate().NoWait(),
+			wantQuery: &Query{
+				SQL: `SELECT * FROM "test_model" FOR UPDATE NOWAIT;`,
+			},
+		},
+		{
+			name:    "sqlite",
+			builder: NewSelector[TestModel](sqlite).ForUpdate(),
+			wantErr: errs.NewErrUnsupportedFeature("FOR UPDATE"),
+		},
+		{
+			name:    "outside tx",
+			builder: NewSelector[TestModel](mysqlDB).ForUpdate(),
+			wantErr: errs.ErrLockOutsideTx,
+		},
+		{
+			name:    "skip locked without lock",
+			builder: NewSelector[TestModel](mysql).SkipLocked(),
+			wantErr: errs.NewErrUnsupportedFeature("SKIP LOCKED 需要与 FOR UPDATE 或 FOR SHARE 一起使用"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err == nil {
+				assert.Equal(t, tc.wantQuery, q)
+			}
+		})
+	}
+}
+
+func TestSelector_LockTx(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	// 不在事务中执行
+	_, err = NewSelector[TestModel](db).ForUpdate().GetMulti(context.Background())
+	assert.Equal(t, errs.ErrLockOutsideTx, err)
+	_, err = NewSelector[TestModel](db).ForUpdate().Get(context.Background())
+	assert.Equal(t, errs.ErrLockOutsideTx, err)
+	_, err = NewSelector[TestModel](db).ForUpdate().Iter(context.Background())
+	assert.Equal(t, errs.ErrLockOutsideTx, err)
+
+	// 使用 DB 构造的查询在 DoTx 中执行
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT * FROM `test_model` WHERE `id` = ? FOR UPDATE;").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	err = db.DoTx(context.Background(), func(ctx context.Context, tx *Tx) error {
+		_, err := NewSelector[TestModel](db).Where(Col("Id").EQ(1)).ForUpdate().Get(ctx)
+		return err
+	}, nil)
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -10,6 +10,14 @@ type QueryContext struct {
 
 	QueryBuilder QueryBuilder
 	Model        *model.Model
+	// Tx 语句所在的事务，不在事务中执行时为 nil
+	Tx *Tx
+	// Cacheable 结果是 Selector.Get 返回的 *T 或者 GetMulti 返回的 []*T，
+	// 可以被中间件缓存。Iter 和 Preload 的查询结果不能缓存
+	Cacheable bool
+	// Multi 结果是 GetMulti 返回的 []*T，否则是 Get 返回的 *T。
+	// 相同的 SQL 可能由 Get 和 GetMulti 执行，缓存时需要区分
+	Multi bool
 }
 
 type QueryResult struct {
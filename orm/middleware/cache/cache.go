@@ -0,0 +1,237 @@
+// Package cache 缓存 SELECT 的查询结果。
+//
+// 只有通过 WithCache 标记的查询才会使用缓存，e.g.
+//
+//	ctx = cache.WithCache(ctx, time.Minute)
+//	users, err := orm.NewSelector[User](db).Where(...).GetMulti(ctx)
+//
+// 缓存的 key 由表名、表的版本号、SQL 和参数组成。同一个 DB 上的 Inserter、Updater、Deleter
+// 执行之后会更新表的版本号，之前缓存的结果不会再被命中，等待过期即可。
+// 表的版本号也保存在缓存中，因此使用 Redis 时多个进程之间的失效也是一致的。
+// 失效只按模型对应的表处理，联表或者子查询涉及的其它表发生变化时不会失效。
+//
+// 只缓存 Selector.Get 和 GetMulti 的结果，Iter 和 Preload 的查询直接访问数据库。
+// 事务中的查询不使用缓存；事务中的写操作在事务提交之后才让缓存失效，
+// 否则提交之前其它查询可能把旧的数据缓存到新的版本号下。
+//
+// 命中缓存时后续的中间件和查询都不会执行，模型的 BeforeQuery 和 AfterQuery 钩子也不会被调用。
+// 缓存的是 AfterQuery 执行之后的结果，钩子中对结果的修改会保留在缓存中。
+package cache
+
+import (
+	soilcache "Soil/cache"
+	"Soil/orm"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+type MiddlewareBuilder struct {
+	cache      soilcache.Cache
+	expiration time.Duration
+	prefix     string
+	group      *singleflight.Group
+}
+
+// NewMiddlewareBuilder expiration 是缓存结果的默认过期时间
+func NewMiddlewareBuilder(c soilcache.Cache, expiration time.Duration) *MiddlewareBuilder {
+	return &MiddlewareBuilder{
+		cache:      c,
+		expiration: expiration,
+		prefix:     "orm",
+		group:      &singleflight.Group{},
+	}
+}
+
+// Prefix 缓存 key 的前缀，多个应用共用一个 Redis 时用来区分，默认为 orm
+func (m *MiddlewareBuilder) Prefix(prefix string) *MiddlewareBuilder {
+	m.prefix = prefix
+	return m
+}
+
+type cacheOption struct {
+	expiration time.Duration
+}
+
+type cacheOptionKey struct{}
+
+// WithCache 缓存 ctx 中执行的 SELECT 的结果，expiration 为 0 时使用 MiddlewareBuilder 的默认过期时间
+func WithCache(ctx context.Context, expiration time.Duration) context.Context {
+	return context.WithValue(ctx, cacheOptionKey{}, cacheOption{expiration: expiration})
+}
+
+func (m *MiddlewareBuilder) Build() orm.Middleware {
+	return func(next orm.Handler) orm.Handler {
+		return func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
+			if qc.Model == nil {
+				return next(ctx, qc)
+			}
+			switch qc.Type {
+			case "SELECT":
+				opt, ok := ctx.Value(cacheOptionKey{}).(cacheOption)
+				if !ok || !qc.Cacheable || qc.Tx != nil {
+					return next(ctx, qc)
+				}
+				return m.query(ctx, qc, next, opt)
+			case "INSERT", "UPDATE", "DELETE":
+				res := next(ctx, qc)
+				table := qc.Model.TableName
+				if qc.Tx != nil {
+					// 提交之后 ctx 可能已经被取消
+					qc.Tx.OnCommit(func() {
+						m.invalidate(context.Background(), table)
+					})
+					return res
+				}
+				// 执行失败时语句也可能已经部分生效，总是让缓存失效
+				m.invalidate(ctx, table)
+				return res
+			default:
+				return next(ctx, qc)
+			}
+		}
+	}
+}
+
+// query 先查缓存，没有命中时同一个 key 的并发查询只有一个会访问数据库。
+// 缓存中保存的是序列化之后的结果，每个调用方都反序列化出自己的实例，不会共享同一个对象。
+// 缓存不可用时直接查询数据库
+func (m *MiddlewareBuilder) query(ctx context.Context, qc *orm.QueryContext,
+	next orm.Handler, opt cacheOption) *orm.QueryResult {
+	key, err := m.key(ctx, qc)
+	if err != nil {
+		return next(ctx, qc)
+	}
+	if val, err := m.cache.Get(ctx, key); err == nil {
+		if res, err := decodeResult(qc, val); err == nil {
+			return res
+		}
+	}
+
+	expiration := opt.expiration
+	if expiration == 0 {
+		expiration = m.expiration
+	}
+	val, err, shared := m.group.Do(key, func() (any, error) {
+		res := next(ctx, qc)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		data, err := encode(qc.Model, res.Result)
+		if err != nil {
+			// 结果不能编码时不缓存，直接返回给发起查询的调用方
+			return res, nil
+		}
+		_ = m.cache.Set(ctx, key, data, expiration)
+		return data, nil
+	})
+	if err != nil {
+		return &orm.QueryResult{Error: err}
+	}
+	if res, ok := val.(*orm.QueryResult); ok {
+		if shared {
+			// 结果不能复制，等待的调用方各自查询，避免共享同一个对象
+			return next(ctx, qc)
+		}
+		return res
+	}
+	res, err := decodeResult(qc, val)
+	if err != nil {
+		return &orm.QueryResult{Error: err}
+	}
+	return res
+}
+
+// key e.g. orm:user:<表的版本号>:multi:<SQL 和参数的 sha256>。
+// Get 和 GetMulti 可能执行相同的 SQL，结果的类型不同，使用 one 和 multi 区分
+func (m *MiddlewareBuilder) key(ctx context.Context, qc *orm.QueryContext) (string, error) {
+	q, err := qc.QueryBuilder.Build()
+	if err != nil {
+		return "", err
+	}
+	version, err := m.version(ctx, qc.Model.TableName)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(q.SQL))
+	// %#v 带有参数的类型，1 与 "1" 会得到不同的 key
+	_, _ = fmt.Fprintf(h, "%#v", q.Args)
+	shape := "one"
+	if qc.Multi {
+		shape = "multi"
+	}
+	return m.prefix + ":" + qc.Model.TableName + ":" + version + ":" + shape + ":" +
+		hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (m *MiddlewareBuilder) versionKey(table string) string {
+	return m.prefix + ":version:" + table
+}
+
+// version 表的版本号，没有版本号时生成一个新的版本号。
+// 并发查询同一个表时只生成一次，否则它们会使用不同的 key，无法合并成一次查询
+func (m *MiddlewareBuilder) version(ctx context.Context, table string) (string, error) {
+	key := m.versionKey(table)
+	if val, err := m.cache.Get(ctx, key); err == nil {
+		return toString(val), nil
+	}
+	val, err, _ := m.group.Do(key, func() (any, error) {
+		// 等待的过程中其它调用可能已经生成了版本号
+		if val, err := m.cache.Get(ctx, key); err == nil {
+			return toString(val), nil
+		}
+		return m.newVersion(ctx, table)
+	})
+	if err != nil {
+		return "", err
+	}
+	return val.(string), nil
+}
+
+// newVersion 生成新的版本号，使用时间戳保证不会与之前的版本号重复
+func (m *MiddlewareBuilder) newVersion(ctx context.Context, table string) (string, error) {
+	version := strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := m.cache.Set(ctx, m.versionKey(table), version, 0); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+func (m *MiddlewareBuilder) invalidate(ctx context.Context, table string) {
+	_, _ = m.newVersion(ctx, table)
+}
+
+// decodeResult 还原缓存的结果：Get 的结果是 *T，GetMulti 的结果是 []*T
+func decodeResult(qc *orm.QueryContext, val any) (*orm.QueryResult, error) {
+	var data []byte
+	switch v := val.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return nil, fmt.Errorf("orm: 缓存的查询结果类型 %T 错误", val)
+	}
+	res, err := decode(qc.Model, qc.Multi, data)
+	if err != nil {
+		return nil, err
+	}
+	return &orm.QueryResult{Result: res}, nil
+}
+
+func toString(val any) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,268 @@
+package cache
+
+import (
+	soilcache "Soil/cache"
+	"Soil/orm"
+	"Soil/orm/internal/errs"
+	"context"
+	"database/sql/driver"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type TestModel struct {
+	Id        int64
+	FirstName string
+	Age       int8
+}
+
+func newDB(t *testing.T) (*orm.DB, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	c := soilcache.NewBuildInMapCache(time.Minute, 1<<20)
+	db, err := orm.OpenDB(mockDB, orm.DBWithMiddlewares(NewMiddlewareBuilder(c, time.Minute).Build()))
+	require.NoError(t, err)
+	return db, mock
+}
+
+func TestMiddlewareBuilder_Cache(t *testing.T) {
+	db, mock := newDB(t)
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "first_name", "age"}).AddRow(1, "Tom", 18).AddRow(2, "Jerry", 20)
+	}
+	const query = "SELECT * FROM `test_model` WHERE `age` > ?;"
+	// 第一次查询数据库，第二次命中缓存
+	mock.ExpectQuery(query).WithArgs(10).WillReturnRows(rows())
+	// 没有 WithCache 的查询不使用缓存
+	mock.ExpectQuery(query).WithArgs(10).WillReturnRows(rows())
+	// 更新之后缓存失效
+	mock.ExpectExec("UPDATE `test_model` SET `age`=? WHERE `id` = ?;").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(query).WithArgs(10).WillReturnRows(rows())
+
+	ctx := WithCache(context.Background(), 0)
+	first, err := orm.NewSelector[TestModel](db).Where(orm.Col("Age").GT(10)).GetMulti(ctx)
+	require.NoError(t, err)
+	second, err := orm.NewSelector[TestModel](db).Where(orm.Col("Age").GT(10)).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	// 每次命中缓存都是新的实例
+	assert.NotSame(t, first[0], second[0])
+
+	_, err = orm.NewSelector[TestModel](db).Where(orm.Col("Age").GT(10)).GetMulti(context.Background())
+	require.NoError(t, err)
+
+	res := orm.NewUpdater[TestModel](db).Set(orm.Assign("Age", 19)).Where(orm.Col("Id").EQ(1)).Exec(context.Background())
+	require.NoError(t, res.Err())
+	_, err = orm.NewSelector[TestModel](db).Where(orm.Col("Age").GT(10)).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMiddlewareBuilder_Get(t *testing.T) {
+	db, mock := newDB(t)
+	mock.ExpectQuery("SELECT * FROM `test_model` WHERE `id` = ?;").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "age"}).AddRow(1, "Tom", 18))
+	// 没有数据的结果不缓存
+	mock.ExpectQuery("SELECT * FROM `test_model` WHERE `id` = ?;").WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "age"}))
+	mock.ExpectQuery("SELECT * FROM `test_model` WHERE `id` = ?;").WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "age"}))
+
+	ctx := WithCache(context.Background(), time.Second)
+	for i := 0; i < 2; i++ {
+		val, err := orm.NewSelector[TestModel](db).Where(orm.Col("Id").EQ(1)).Get(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, &TestModel{Id: 1, FirstName: "Tom", Age: 18}, val)
+	}
+	for i := 0; i < 2; i++ {
+		_, err := orm.NewSelector[TestModel](db).Where(orm.Col("Id").EQ(2)).Get(ctx)
+		assert.Equal(t, errs.ErrNoRows, err)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMiddlewareBuilder_GetThenGetMulti(t *testing.T) {
+	db, mock := newDB(t)
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "first_name", "age"}).AddRow(1, "Tom", 18)
+	}
+	// Get 和 GetMulti 执行相同的 SQL，各自查询一次数据库，之后分别命中自己的缓存
+	mock.ExpectQuery("SELECT * FROM `test_model` WHERE `id` = ?;").WithArgs(1).WillReturnRows(rows())
+	mock.ExpectQuery("SELECT * FROM `test_model` WHERE `id` = ?;").WithArgs(1).WillReturnRows(rows())
+
+	ctx := WithCache(context.Background(), 0)
+	for i := 0; i < 2; i++ {
+		val, err := orm.NewSelector[TestModel](db).Where(orm.Col("Id").EQ(1)).Get(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, &TestModel{Id: 1, FirstName: "Tom", Age: 18}, val)
+		vals, err := orm.NewSelector[TestModel](db).Where(orm.Col("Id").EQ(1)).GetMulti(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []*TestModel{{Id: 1, FirstName: "Tom", Age: 18}}, vals)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMiddlewareBuilder_Singleflight(t *testing.T) {
+	db, mock := newDB(t)
+	mock.ExpectQuery("SELECT * FROM `test_model`;").
+		WillDelayFor(100 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "age"}).AddRow(1, "Tom", 18))
+
+	ctx := WithCache(context.Background(), 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vals, err := orm.NewSelector[TestModel](db).GetMulti(ctx)
+			assert.NoError(t, err)
+			assert.Len(t, vals, 1)
+		}()
+	}
+	wg.Wait()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type CacheUser struct {
+	Id     int64
+	Name   string
+	Orders []*CacheOrder `orm:"has_many(foreign_key=UserId)"`
+}
+
+type CacheOrder struct {
+	Id     int64
+	UserId int64
+}
+
+func TestMiddlewareBuilder_NotCacheable(t *testing.T) {
+	db, mock := newDB(t)
+	ctx := WithCache(context.Background(), 0)
+
+	// Iter 的结果是 *sql.Rows，不缓存
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery("SELECT * FROM `test_model`;").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "age"}).AddRow(1, "Tom", 18))
+	}
+	for i := 0; i < 2; i++ {
+		rows, err := orm.NewSelector[TestModel](db).Iter(ctx)
+		require.NoError(t, err)
+		require.True(t, rows.Next())
+		assert.Equal(t, &TestModel{Id: 1, FirstName: "Tom", Age: 18}, rows.Value())
+		require.NoError(t, rows.Close())
+	}
+
+	// Preload 的查询不缓存，关联的数据不会丢失。第二次查询 cache_user 命中缓存
+	mock.ExpectQuery("SELECT * FROM `cache_user`;").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Tom"))
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery("SELECT * FROM `cache_order` WHERE `user_id` IN (?);").WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow(10, 1))
+	}
+	for i := 0; i < 2; i++ {
+		users, err := orm.NewSelector[CacheUser](db).Preload("Orders").GetMulti(ctx)
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, []*CacheOrder{{Id: 10, UserId: 1}}, users[0].Orders)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type Audit struct {
+	Operator string
+}
+
+type CodecModel struct {
+	Id       int64
+	Nickname string `json:"-"`
+	Email    string `json:"mail"`
+	remark   string
+	Bio      *string
+	Tags     []string `orm:"serializer(json)"`
+	*Audit
+}
+
+func TestMiddlewareBuilder_Codec(t *testing.T) {
+	db, mock := newDB(t)
+	ctx := WithCache(context.Background(), 0)
+	bio := "hello"
+	testCases := []struct {
+		name string
+		row  []driver.Value
+		want *CodecModel
+	}{
+		{
+			name: "all",
+			row:  []driver.Value{1, "Tom", "tom@mail", "vip", "hello", `["a","b"]`, "admin"},
+			want: &CodecModel{Id: 1, Nickname: "Tom", Email: "tom@mail", remark: "vip",
+				Bio: &bio, Tags: []string{"a", "b"}, Audit: &Audit{Operator: "admin"}},
+		},
+		{
+			name: "null",
+			row:  []driver.Value{2, "", "", "", nil, nil, nil},
+			want: &CodecModel{Id: 2},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mock.ExpectQuery("SELECT * FROM `codec_model` WHERE `id` = ?;").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "nickname", "email", "remark", "bio", "tags", "operator"}).
+					AddRow(tc.row...))
+			for i := 0; i < 2; i++ {
+				val, err := orm.NewSelector[CodecModel](db).Where(orm.Col("Id").EQ(tc.want.Id)).Get(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, val)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMiddlewareBuilder_Tx(t *testing.T) {
+	db, mock := newDB(t)
+	ctx := WithCache(context.Background(), 0)
+	const query = "SELECT * FROM `test_model`;"
+	rows := func(age int) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "first_name", "age"}).AddRow(1, "Tom", age)
+	}
+	mock.ExpectQuery(query).WillReturnRows(rows(18))
+	mock.ExpectBegin()
+	// 事务中的查询不使用缓存
+	mock.ExpectQuery(query).WillReturnRows(rows(18))
+	mock.ExpectExec("UPDATE `test_model` SET `age`=?;").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(query).WillReturnRows(rows(19))
+	mock.ExpectCommit()
+	mock.ExpectQuery(query).WillReturnRows(rows(19))
+
+	vals, err := orm.NewSelector[TestModel](db).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int8(18), vals[0].Age)
+
+	err = db.DoTx(ctx, func(ctx context.Context, tx *orm.Tx) error {
+		vals, err := orm.NewSelector[TestModel](db).GetMulti(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int8(18), vals[0].Age)
+		if res := orm.NewUpdater[TestModel](tx).Set(orm.Assign("Age", 19)).Exec(ctx); res.Err() != nil {
+			return res.Err()
+		}
+		vals, err = orm.NewSelector[TestModel](tx).GetMulti(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int8(19), vals[0].Age)
+		// 提交之前其它查询仍然命中提交之前的缓存
+		vals, err = orm.NewSelector[TestModel](db).GetMulti(WithCache(context.Background(), 0))
+		require.NoError(t, err)
+		assert.Equal(t, int8(18), vals[0].Age)
+		return nil
+	}, nil)
+	require.NoError(t, err)
+
+	// 提交之后缓存失效
+	vals, err = orm.NewSelector[TestModel](db).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int8(19), vals[0].Age)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
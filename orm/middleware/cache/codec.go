@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"Soil/orm/internal/model"
+	"bytes"
+	"database/sql/driver"
+	"encoding/gob"
+	"fmt"
+	"reflect"
+	"time"
+	"unsafe"
+)
+
+func init() {
+	// Converter 返回的 driver.Value 作为 interface 编码，time.Time 需要注册
+	gob.Register(time.Time{})
+}
+
+// encode 按照模型的字段逐个编码查询结果，res 必须是 *T 或者 []*T。
+// 与 JSON 不同，未导出的字段、json:"-" 和重命名的字段都能够还原，
+// nil 的嵌入指针和 nil 的指针字段在还原之后仍然是 nil。
+// 通过 orm:"serializer()" 指定了转换器的字段按照写入数据库的值编码，与从数据库中读取时一致
+func encode(m *model.Model, res any) ([]byte, error) {
+	rv := reflect.ValueOf(res)
+	ptrTyp := reflect.PointerTo(m.Type)
+	var rows []reflect.Value
+	multi := false
+	switch rv.Type() {
+	case ptrTyp:
+		rows = []reflect.Value{rv}
+	case reflect.SliceOf(ptrTyp):
+		multi = true
+		for i := 0; i < rv.Len(); i++ {
+			rows = append(rows, rv.Index(i))
+		}
+	default:
+		return nil, fmt.Errorf("orm: 查询结果类型 %T 不能缓存", res)
+	}
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(multi); err != nil {
+		return nil, err
+	}
+	if err := enc.Encode(len(rows)); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if row.IsNil() {
+			return nil, fmt.Errorf("orm: 查询结果中有 nil")
+		}
+		for _, fd := range m.Fields {
+			if err := encodeField(enc, fd, row.Elem()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeField 先写入字段是否有值：路径上的结构体指针为 nil、字段是 nil 的指针时没有值，
+// 有值时再写入字段的值
+func encodeField(enc *gob.Encoder, fd *model.Field, v reflect.Value) error {
+	fv, ok := fd.FieldValue(v, false)
+	if ok {
+		fv = exported(fv)
+		ok = fv.Kind() != reflect.Ptr || !fv.IsNil()
+	}
+	var dv driver.Value
+	if ok && fd.Converter != nil {
+		var err error
+		if dv, err = fd.Converter.Value(fv.Interface()); err != nil {
+			return err
+		}
+		ok = dv != nil
+	}
+	if err := enc.Encode(ok); err != nil || !ok {
+		return err
+	}
+	if fd.Converter != nil {
+		return enc.Encode(&dv)
+	}
+	return enc.EncodeValue(fv)
+}
+
+// decode 还原 encode 编码的查询结果：Get 的结果是 *T，GetMulti 的结果是 []*T。
+// 缓存的结果类型与 multi 不一致时返回错误，不会把 *T 返回给 GetMulti
+func decode(m *model.Model, multi bool, data []byte) (any, error) {
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	var (
+		cachedMulti bool
+		cnt         int
+	)
+	if err := dec.Decode(&cachedMulti); err != nil {
+		return nil, err
+	}
+	if cachedMulti != multi {
+		return nil, fmt.Errorf("orm: 缓存的查询结果类型错误，multi 应该为 %t", multi)
+	}
+	if err := dec.Decode(&cnt); err != nil {
+		return nil, err
+	}
+	rows := reflect.MakeSlice(reflect.SliceOf(reflect.PointerTo(m.Type)), 0, cnt)
+	for i := 0; i < cnt; i++ {
+		row := reflect.New(m.Type)
+		for _, fd := range m.Fields {
+			if err := decodeField(dec, fd, row.Elem()); err != nil {
+				return nil, err
+			}
+		}
+		rows = reflect.Append(rows, row)
+	}
+	if multi {
+		return rows.Interface(), nil
+	}
+	if cnt != 1 {
+		return nil, fmt.Errorf("orm: 缓存的查询结果行数 %d 错误", cnt)
+	}
+	return rows.Index(0).Interface(), nil
+}
+
+func decodeField(dec *gob.Decoder, fd *model.Field, v reflect.Value) error {
+	var ok bool
+	if err := dec.Decode(&ok); err != nil || !ok {
+		return err
+	}
+	fv, _ := fd.FieldValue(v, true)
+	fv = exported(fv)
+	if fd.Converter != nil {
+		var dv driver.Value
+		if err := dec.Decode(&dv); err != nil {
+			return err
+		}
+		return fd.Converter.Scan(dv, fv.Addr().Interface())
+	}
+	return dec.DecodeValue(fv.Addr())
+}
+
+// exported 返回可以读写的字段，未导出的字段通过 unsafe 访问，与 unsafe 的 Valuer 一致
+func exported(fv reflect.Value) reflect.Value {
+	if fv.CanInterface() {
+		return fv
+	}
+	return reflect.NewAt(fv.Type(), unsafe.Pointer(fv.UnsafeAddr())).Elem()
+}
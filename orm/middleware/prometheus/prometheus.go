@@ -0,0 +1,113 @@
+// Package prometheus 统计查询的耗时和错误数。
+//
+// 指标的 label 为 type（SELECT、INSERT 等）、table（模型对应的表名）和 outcome（查询的结果）。
+// outcome 取值为 ok、no_rows 和 error，Get 没有找到数据不算作错误。
+package prometheus
+
+import (
+	"Soil/orm"
+	"Soil/orm/internal/errs"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	outcomeOK     = "ok"
+	outcomeNoRows = "no_rows"
+	outcomeError  = "error"
+
+	unknownTable = "unknown"
+)
+
+type MiddlewareBuilder struct {
+	namespace  string
+	subsystem  string
+	buckets    []float64
+	registerer prometheus.Registerer
+}
+
+// NewMiddlewareBuilder 指标名为 <namespace>_<subsystem>_query_duration_seconds
+// 和 <namespace>_<subsystem>_query_errors_total
+func NewMiddlewareBuilder(namespace, subsystem string) *MiddlewareBuilder {
+	return &MiddlewareBuilder{
+		namespace:  namespace,
+		subsystem:  subsystem,
+		buckets:    prometheus.DefBuckets,
+		registerer: prometheus.DefaultRegisterer,
+	}
+}
+
+// Buckets 耗时直方图的分桶，单位为秒，默认为 prometheus.DefBuckets
+func (m *MiddlewareBuilder) Buckets(buckets []float64) *MiddlewareBuilder {
+	m.buckets = buckets
+	return m
+}
+
+// Registerer 注册指标使用的 Registerer，默认为 prometheus.DefaultRegisterer
+func (m *MiddlewareBuilder) Registerer(registerer prometheus.Registerer) *MiddlewareBuilder {
+	m.registerer = registerer
+	return m
+}
+
+// Build 多个 DB 使用相同的配置注册到同一个 Registerer 时共用已经注册的指标，
+// 同名指标的 label 或者分桶不一致时 panic，与 prometheus.MustRegister 的行为一致
+func (m *MiddlewareBuilder) Build() orm.Middleware {
+	duration := register(m.registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: m.namespace,
+		Subsystem: m.subsystem,
+		Name:      "query_duration_seconds",
+		Help:      "查询的耗时",
+		Buckets:   m.buckets,
+	}, []string{"type", "table", "outcome"}))
+	errCnt := register(m.registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: m.namespace,
+		Subsystem: m.subsystem,
+		Name:      "query_errors_total",
+		Help:      "查询的错误数",
+	}, []string{"type", "table"}))
+
+	return func(next orm.Handler) orm.Handler {
+		return func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
+			table := unknownTable
+			if qc.Model != nil {
+				table = qc.Model.TableName
+			}
+			startTime := time.Now()
+			res := next(ctx, qc)
+			outcome := outcomeOf(res)
+			duration.WithLabelValues(qc.Type, table, outcome).Observe(time.Since(startTime).Seconds())
+			if outcome == outcomeError {
+				errCnt.WithLabelValues(qc.Type, table).Inc()
+			}
+			return res
+		}
+	}
+}
+
+func outcomeOf(res *orm.QueryResult) string {
+	if res == nil || res.Error == nil {
+		return outcomeOK
+	}
+	if errors.Is(res.Error, errs.ErrNoRows) {
+		return outcomeNoRows
+	}
+	return outcomeError
+}
+
+// register 注册 c，已经注册过相同的指标时返回已经注册的指标
+func register[C prometheus.Collector](registerer prometheus.Registerer, c C) C {
+	err := registerer.Register(c)
+	if err == nil {
+		return c
+	}
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(C); ok {
+			return existing
+		}
+	}
+	panic(err)
+}
@@ -0,0 +1,95 @@
+package prometheus
+
+import (
+	"Soil/orm"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type TestModel struct {
+	Id        int64
+	FirstName string
+	Age       int8
+}
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := orm.OpenDB(mockDB, orm.DBWithMiddlewares(
+		NewMiddlewareBuilder("soil", "orm").Registerer(registry).Buckets([]float64{0.1, 1}).Build()))
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT * FROM `test_model` WHERE `id` = ? LIMIT ?;").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "age"}).AddRow(1, "Tom", 18))
+	mock.ExpectQuery("SELECT * FROM `test_model` WHERE `id` = ? LIMIT ?;").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "age"}))
+	mock.ExpectExec("DELETE FROM `test_model` WHERE `id` = ?;").
+		WillReturnError(errors.New("mock error"))
+
+	ctx := context.Background()
+	_, err = orm.NewSelector[TestModel](db).Where(orm.Col("Id").EQ(1)).Limit(1).Get(ctx)
+	require.NoError(t, err)
+	_, err = orm.NewSelector[TestModel](db).Where(orm.Col("Id").EQ(2)).Limit(1).Get(ctx)
+	require.Error(t, err)
+	res := orm.NewDeleter[TestModel](db).Where(orm.Col("Id").EQ(1)).Exec(ctx)
+	require.Error(t, res.Err())
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, 3, testutil.CollectAndCount(registry, "soil_orm_query_duration_seconds"))
+	errCnt, err := registry.Gather()
+	require.NoError(t, err)
+	for _, mf := range errCnt {
+		if mf.GetName() != "soil_orm_query_errors_total" {
+			continue
+		}
+		// 没有数据不算作错误
+		require.Len(t, mf.GetMetric(), 1)
+		assert.Equal(t, float64(1), mf.GetMetric()[0].GetCounter().GetValue())
+		labels := map[string]string{}
+		for _, l := range mf.GetMetric()[0].GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+		assert.Equal(t, map[string]string{"type": "DELETE", "table": "test_model"}, labels)
+	}
+}
+
+func TestMiddlewareBuilder_SharedRegistry(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	first := NewMiddlewareBuilder("soil", "orm").Registerer(registry).Build()
+	// 多个 DB 共用同一个 Registerer 时不会重复注册
+	second := NewMiddlewareBuilder("soil", "orm").Registerer(registry).Build()
+
+	next := func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
+		return &orm.QueryResult{Error: errors.New("mock error")}
+	}
+	qc := &orm.QueryContext{Type: "RAW"}
+	first(next)(context.Background(), qc)
+	second(next)(context.Background(), qc)
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(
+		counterVec(registry).WithLabelValues("RAW", "unknown")))
+
+	// 同名指标的 label 不一致时 panic
+	conflict := prometheus.NewRegistry()
+	conflict.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "soil", Subsystem: "orm", Name: "query_errors_total", Help: "查询的错误数"}))
+	assert.Panics(t, func() {
+		NewMiddlewareBuilder("soil", "orm").Registerer(conflict).Build()
+	})
+}
+
+// counterVec 取出 registry 中已经注册的错误计数
+func counterVec(registry *prometheus.Registry) *prometheus.CounterVec {
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "soil", Subsystem: "orm", Name: "query_errors_total", Help: "查询的错误数",
+	}, []string{"type", "table"})
+	return register(registry, c)
+}
@@ -0,0 +1,104 @@
+// Package safety 在执行之前检查语句，拒绝可能影响整个表的语句。
+//
+// 只检查实现了 orm.Inspector 的 Selector、Updater 和 Deleter，原生 SQL 和 Inserter 不检查。
+// 确实需要执行被拒绝的语句时使用 AllowUnsafe 标记 ctx，e.g.
+//
+//	res := orm.NewDeleter[User](db).Exec(safety.AllowUnsafe(ctx))
+package safety
+
+import (
+	"Soil/orm"
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoWhere UPDATE 或者 DELETE 没有 WHERE
+	ErrNoWhere = errors.New("safety: UPDATE/DELETE 没有 WHERE")
+	// ErrNoLimit 要求 LIMIT 的表的 SELECT 没有 LIMIT
+	ErrNoLimit = errors.New("safety: SELECT 没有 LIMIT")
+	// ErrTooManyInValues IN 的值过多
+	ErrTooManyInValues = errors.New("safety: IN 的值过多")
+)
+
+type MiddlewareBuilder struct {
+	// limitTables SELECT 必须带有 LIMIT 的表
+	limitTables map[string]struct{}
+	// maxInValues IN 的值的个数上限，0 表示不限制
+	maxInValues int
+}
+
+// NewMiddlewareBuilder 默认只拒绝没有 WHERE 的 UPDATE 和 DELETE，
+// 软删除模型的 DELETE 也会被拒绝
+func NewMiddlewareBuilder() *MiddlewareBuilder {
+	return &MiddlewareBuilder{
+		limitTables: map[string]struct{}{},
+	}
+}
+
+// RequireLimit 这些表的 SELECT 必须带有 LIMIT，表名为模型对应的表名
+func (m *MiddlewareBuilder) RequireLimit(tables ...string) *MiddlewareBuilder {
+	for _, table := range tables {
+		m.limitTables[table] = struct{}{}
+	}
+	return m
+}
+
+// MaxInValues IN 的值的个数上限，0 表示不限制
+func (m *MiddlewareBuilder) MaxInValues(n int) *MiddlewareBuilder {
+	m.maxInValues = n
+	return m
+}
+
+type unsafeKey struct{}
+
+// AllowUnsafe 跳过 ctx 中执行的语句的所有检查
+func AllowUnsafe(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unsafeKey{}, true)
+}
+
+func (m *MiddlewareBuilder) Build() orm.Middleware {
+	return func(next orm.Handler) orm.Handler {
+		return func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
+			if unsafe, _ := ctx.Value(unsafeKey{}).(bool); unsafe {
+				return next(ctx, qc)
+			}
+			if err := m.check(qc); err != nil {
+				return &orm.QueryResult{Error: err}
+			}
+			return next(ctx, qc)
+		}
+	}
+}
+
+func (m *MiddlewareBuilder) check(qc *orm.QueryContext) error {
+	inspector, ok := qc.QueryBuilder.(orm.Inspector)
+	if !ok {
+		return nil
+	}
+	info, err := inspector.Inspect()
+	if err != nil {
+		// 语句本身有错误，交给后续构造 SQL 的时候返回
+		return nil
+	}
+	var table string
+	if qc.Model != nil {
+		table = qc.Model.TableName
+	}
+
+	switch qc.Type {
+	case "UPDATE", "DELETE":
+		if !info.Where {
+			return fmt.Errorf("%w: %s %s", ErrNoWhere, qc.Type, table)
+		}
+	case "SELECT":
+		if _, ok := m.limitTables[table]; ok && !info.Limit {
+			return fmt.Errorf("%w: %s", ErrNoLimit, table)
+		}
+	}
+	if m.maxInValues > 0 && info.MaxInValues > m.maxInValues {
+		return fmt.Errorf("%w: %d 超过上限 %d", ErrTooManyInValues, info.MaxInValues, m.maxInValues)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package safety
+
+import (
+	"Soil/orm"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type TestModel struct {
+	Id   int64
+	Name string
+}
+
+// SoftDeleteModel 软删除的 DELETE 改写成了 UPDATE ... WHERE deleted_at IS NULL，同样需要拒绝
+type SoftDeleteModel struct {
+	Id        int64
+	DeletedAt *time.Time
+}
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	mb := NewMiddlewareBuilder().RequireLimit("test_model").MaxInValues(2)
+	db, err := orm.OpenDB(mockDB, orm.DBWithMiddlewares(mb.Build()))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		exec    func(ctx context.Context) error
+		mock    func()
+		unsafe  bool
+		wantErr error
+	}{
+		{
+			name: "delete without where",
+			exec: func(ctx context.Context) error {
+				return orm.NewDeleter[TestModel](db).Exec(ctx).Err()
+			},
+			wantErr: ErrNoWhere,
+		},
+		{
+			name: "soft delete without where",
+			exec: func(ctx context.Context) error {
+				return orm.NewDeleter[SoftDeleteModel](db).Exec(ctx).Err()
+			},
+			wantErr: ErrNoWhere,
+		},
+		{
+			name: "delete by instances",
+			exec: func(ctx context.Context) error {
+				return orm.NewDeleter[TestModel](db).Delete(&TestModel{Id: 1}).Exec(ctx).Err()
+			},
+			mock: func() {
+				mock.ExpectExec("DELETE FROM `test_model` WHERE `id` IN (?);").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "update without where",
+			exec: func(ctx context.Context) error {
+				return orm.NewUpdater[TestModel](db).Set(orm.Assign("Name", "Tom")).Exec(ctx).Err()
+			},
+			wantErr: ErrNoWhere,
+		},
+		{
+			name: "update without where unsafe",
+			exec: func(ctx context.Context) error {
+				return orm.NewUpdater[TestModel](db).Set(orm.Assign("Name", "Tom")).Exec(ctx).Err()
+			},
+			unsafe: true,
+			mock: func() {
+				mock.ExpectExec("UPDATE `test_model` SET `name`=?;").WillReturnResult(sqlmock.NewResult(0, 10))
+			},
+		},
+		{
+			name: "select without limit",
+			exec: func(ctx context.Context) error {
+				_, err := orm.NewSelector[TestModel](db).Where(orm.Col("Id").GT(1)).GetMulti(ctx)
+				return err
+			},
+			wantErr: ErrNoLimit,
+		},
+		{
+			name: "select without limit on other table",
+			exec: func(ctx context.Context) error {
+				_, err := orm.NewSelector[SoftDeleteModel](db).GetMulti(ctx)
+				return err
+			},
+			mock: func() {
+				mock.ExpectQuery("SELECT * FROM `soft_delete_model` WHERE `deleted_at` IS NULL;").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+		},
+		{
+			name: "too many in values",
+			exec: func(ctx context.Context) error {
+				_, err := orm.NewSelector[TestModel](db).
+					Where(orm.Col("Id").GT(0).And(orm.Col("Id").In(1, 2, 3))).Limit(10).GetMulti(ctx)
+				return err
+			},
+			wantErr: ErrTooManyInValues,
+		},
+		{
+			name: "too many primary keys",
+			exec: func(ctx context.Context) error {
+				return orm.NewDeleter[TestModel](db).
+					Delete(&TestModel{Id: 1}, &TestModel{Id: 2}, &TestModel{Id: 3}).Exec(ctx).Err()
+			},
+			wantErr: ErrTooManyInValues,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.mock != nil {
+				tc.mock()
+			}
+			ctx := context.Background()
+			if tc.unsafe {
+				ctx = AllowUnsafe(ctx)
+			}
+			err := tc.exec(ctx)
+			assert.True(t, errors.Is(err, tc.wantErr), "err: %v", err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,579 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+	"Soil/orm/internal/model"
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 字段类型归一化之后的种类，各方言按种类映射到自己的列类型
+const (
+	kindBool    = "bool"
+	kindInt8    = "int8"
+	kindInt16   = "int16"
+	kindInt32   = "int32"
+	kindInt64   = "int64"
+	kindUint8   = "uint8"
+	kindUint16  = "uint16"
+	kindUint32  = "uint32"
+	kindUint64  = "uint64"
+	kindFloat32 = "float32"
+	kindFloat64 = "float64"
+	kindString  = "string"
+	kindBytes   = "bytes"
+	kindTime    = "time"
+)
+
+// defaultStringSize 没有通过 orm:"size()" 指定长度时字符串列的长度
+const defaultStringSize = 255
+
+const defaultHistoryTable = "schema_migrations"
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	bytesType      = reflect.TypeOf([]byte(nil))
+	nullColumnType = map[reflect.Type]reflect.Type{
+		reflect.TypeOf(sql.NullString{}):  reflect.TypeOf(""),
+		reflect.TypeOf(sql.NullBool{}):    reflect.TypeOf(false),
+		reflect.TypeOf(sql.NullByte{}):    reflect.TypeOf(byte(0)),
+		reflect.TypeOf(sql.NullInt16{}):   reflect.TypeOf(int16(0)),
+		reflect.TypeOf(sql.NullInt32{}):   reflect.TypeOf(int32(0)),
+		reflect.TypeOf(sql.NullInt64{}):   reflect.TypeOf(int64(0)),
+		reflect.TypeOf(sql.NullFloat64{}): reflect.TypeOf(float64(0)),
+		reflect.TypeOf(sql.NullTime{}):    timeType,
+	}
+)
+
+// columnKind 将字段类型归一化为列类型的种类。指针与 sql.NullXXX 对应的列允许 NULL
+func columnKind(typ reflect.Type) (kind string, nullable bool, ok bool) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+		nullable = true
+	}
+	if t, isNull := nullColumnType[typ]; isNull {
+		typ = t
+		nullable = true
+	}
+	switch {
+	case typ == timeType:
+		return kindTime, nullable, true
+	case typ == bytesType:
+		return kindBytes, nullable, true
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return kindBool, nullable, true
+	case reflect.Int8:
+		return kindInt8, nullable, true
+	case reflect.Int16:
+		return kindInt16, nullable, true
+	case reflect.Int32:
+		return kindInt32, nullable, true
+	case reflect.Int, reflect.Int64:
+		return kindInt64, nullable, true
+	case reflect.Uint8:
+		return kindUint8, nullable, true
+	case reflect.Uint16:
+		return kindUint16, nullable, true
+	case reflect.Uint32:
+		return kindUint32, nullable, true
+	case reflect.Uint, reflect.Uint64:
+		return kindUint64, nullable, true
+	case reflect.Float32:
+		return kindFloat32, nullable, true
+	case reflect.Float64:
+		return kindFloat64, nullable, true
+	case reflect.String:
+		return kindString, nullable, true
+	}
+	return "", nullable, false
+}
+
+// columnTypeOf 按方言的类型表生成列类型。orm:"type()" 指定的类型优先；
+// 其它无法推断的类型（例如实现了 sql.Scanner 的自定义类型、指定了 serializer 的字段）必须通过 orm:"type()" 指定
+func columnTypeOf(f *model.Field, types map[string]string) (string, error) {
+	if f.SQLType != "" {
+		return f.SQLType, nil
+	}
+	// 指定了 serializer 的字段保存的是转换之后的值，列类型与字段类型无关
+	kind, _, ok := columnKind(f.Type)
+	if !ok || f.Converter != nil {
+		return "", errs.NewErrUnsupportedColumnType(f.GoName, f.Type)
+	}
+	typ := types[kind]
+	if strings.Contains(typ, "%d") {
+		size := f.Size
+		if size == 0 {
+			size = defaultStringSize
+		}
+		typ = fmt.Sprintf(typ, size)
+	}
+	return typ, nil
+}
+
+type MigratorOption func(m *Migrator)
+
+// Migrator 根据注册的模型维护数据表结构：
+//   - Plan/AutoMigrate 将模型与数据库中已有的表结构比较，生成 CREATE TABLE、ALTER TABLE ADD COLUMN
+//     与 CREATE INDEX 语句。只会新增表、列和索引，不会删除或修改已有的列
+//   - Up/Down 执行版本化的迁移，已经执行的版本记录在历史表中
+type Migrator struct {
+	db           *DB
+	historyTable string
+}
+
+func NewMigrator(db *DB, opts ...MigratorOption) *Migrator {
+	res := &Migrator{
+		db:           db,
+		historyTable: defaultHistoryTable,
+	}
+	for _, opt := range opts {
+		opt(res)
+	}
+	return res
+}
+
+// MigratorWithHistoryTable 指定记录已执行版本的历史表，默认为 schema_migrations
+func MigratorWithHistoryTable(table string) MigratorOption {
+	return func(m *Migrator) {
+		m.historyTable = table
+	}
+}
+
+// Plan 是 AutoMigrate 的 dry-run 版本，只返回需要执行的语句而不执行。
+// models 为指向结构体的指针，e.g. Plan(ctx, &User{}, &Order{})；
+// 不传 models 时使用 DB 的 registry 中已经缓存的所有模型
+func (m *Migrator) Plan(ctx context.Context, models ...any) ([]string, error) {
+	metas, err := m.models(models)
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, meta := range metas {
+		stmts, err := m.planTable(ctx, meta)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, stmts...)
+	}
+	return res, nil
+}
+
+// AutoMigrate 执行 Plan 生成的语句
+func (m *Migrator) AutoMigrate(ctx context.Context, models ...any) error {
+	stmts, err := m.Plan(ctx, models...)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmts {
+		if _, err = m.db.execContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) models(models []any) ([]*model.Model, error) {
+	if len(models) == 0 {
+		return m.db.r.Models(), nil
+	}
+	res := make([]*model.Model, 0, len(models))
+	for _, val := range models {
+		meta, err := m.db.r.Get(val)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, meta)
+	}
+	return res, nil
+}
+
+func (m *Migrator) planTable(ctx context.Context, meta *model.Model) ([]string, error) {
+	columns, err := m.queryNames(ctx, m.db.dialect.tableColumnsQuery(meta.TableName))
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return m.createTable(meta)
+	}
+
+	var res []string
+	for _, field := range meta.Fields {
+		if _, ok := columns[field.ColName]; ok {
+			continue
+		}
+		// 已有数据的表新增列时不加 NOT NULL，避免已有的行没有默认值导致失败
+		b := m.newBuilder()
+		b.sqlStrBuilder.WriteString("ALTER TABLE ")
+		b.quote(meta.TableName)
+		b.sqlStrBuilder.WriteString(" ADD COLUMN ")
+		if err = m.buildColumnDef(&b, field, false); err != nil {
+			return nil, err
+		}
+		b.sqlStrBuilder.WriteByte(';')
+		res = append(res, b.sqlStrBuilder.String())
+	}
+
+	indexes, err := m.queryNames(ctx, m.db.dialect.tableIndexesQuery(meta.TableName))
+	if err != nil {
+		return nil, err
+	}
+	for _, idx := range meta.Indexes {
+		if _, ok := indexes[idx.Name]; !ok {
+			res = append(res, m.createIndex(meta, idx))
+		}
+	}
+	return res, nil
+}
+
+func (m *Migrator) createTable(meta *model.Model) ([]string, error) {
+	b := m.newBuilder()
+	b.sqlStrBuilder.WriteString("CREATE TABLE ")
+	b.quote(meta.TableName)
+	b.sqlStrBuilder.WriteString(" (")
+	inlinePK := false
+	for idx, field := range meta.Fields {
+		if idx > 0 {
+			b.sqlStrBuilder.WriteString(", ")
+		}
+		if err := m.buildColumnDef(&b, field, true); err != nil {
+			return nil, err
+		}
+		if field.AutoIncrement {
+			var clause string
+			clause, inlinePK = m.db.dialect.autoIncrement()
+			if inlinePK && len(meta.PrimaryKeys) > 1 {
+				return nil, errs.NewErrUnsupportedFeature("联合主键中的自增列")
+			}
+			b.sqlStrBuilder.WriteString(clause)
+		}
+	}
+	// 主键，e.g. PRIMARY KEY (`tenant_id`, `code`)
+	if len(meta.PrimaryKeys) > 0 && !inlinePK {
+		b.sqlStrBuilder.WriteString(", PRIMARY KEY (")
+		for idx, pk := range meta.PrimaryKeys {
+			if idx > 0 {
+				b.sqlStrBuilder.WriteString(", ")
+			}
+			b.quote(pk.ColName)
+		}
+		b.sqlStrBuilder.WriteByte(')')
+	}
+	b.sqlStrBuilder.WriteString(");")
+
+	res := make([]string, 0, len(meta.Indexes)+1)
+	res = append(res, b.sqlStrBuilder.String())
+	for _, idx := range meta.Indexes {
+		res = append(res, m.createIndex(meta, idx))
+	}
+	return res, nil
+}
+
+// buildColumnDef e.g. `name` VARCHAR(255) NOT NULL，notNull 为 false 时不加 NOT NULL
+func (m *Migrator) buildColumnDef(b *builder, field *model.Field, notNull bool) error {
+	typ, err := m.db.dialect.columnType(field)
+	if err != nil {
+		return err
+	}
+	b.quote(field.ColName)
+	b.sqlStrBuilder.WriteString(" " + typ)
+	if notNull && !nullable(field) {
+		b.sqlStrBuilder.WriteString(" NOT NULL")
+	}
+	return nil
+}
+
+// nullable 列可以是 NULL：字段的类型可以表示 NULL；
+// 或者字段的路径上有结构体指针，指针为 nil 时写入 NULL；
+// 或者字段指定了 serializer，转换器对 nil 和零值会写入 NULL
+func nullable(field *model.Field) bool {
+	if len(field.Indirects) > 0 || field.Converter != nil {
+		return true
+	}
+	_, res, _ := columnKind(field.Type)
+	return res
+}
+
+func (m *Migrator) createIndex(meta *model.Model, idx *model.Index) string {
+	b := m.newBuilder()
+	b.sqlStrBuilder.WriteString("CREATE ")
+	if idx.Unique {
+		b.sqlStrBuilder.WriteString("UNIQUE ")
+	}
+	b.sqlStrBuilder.WriteString("INDEX ")
+	b.quote(idx.Name)
+	b.sqlStrBuilder.WriteString(" ON ")
+	b.quote(meta.TableName)
+	b.sqlStrBuilder.WriteString(" (")
+	for i, col := range idx.Columns {
+		if i > 0 {
+			b.sqlStrBuilder.WriteString(", ")
+		}
+		b.quote(col)
+	}
+	b.sqlStrBuilder.WriteString(");")
+	return b.sqlStrBuilder.String()
+}
+
+// queryNames 执行只返回一列名字的查询，例如列名、索引名、已执行的版本号
+func (m *Migrator) queryNames(ctx context.Context, q *Query) (map[string]struct{}, error) {
+	rows, err := m.db.queryContext(ctx, m.db.dialect.rebind(q.SQL), q.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make(map[string]struct{})
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		res[name] = struct{}{}
+	}
+	return res, rows.Err()
+}
+
+func (m *Migrator) newBuilder() builder {
+	return builder{
+		core:   m.db.core,
+		quoter: m.db.dialect.quoter(),
+	}
+}
+
+// Migration 一个版本的迁移，Up/Down 中的每个元素是一条语句
+type Migration struct {
+	// Version 版本号，按字符串顺序执行，建议使用固定长度的时间戳，例如 20240601120000
+	Version string
+	Name    string
+	Up      []string
+	Down    []string
+}
+
+// LoadMigrations 从 fsys 的根目录读取迁移文件，文件名的格式为 <version>_<name>.up.sql 与
+// <version>_<name>.down.sql，文件中的多条语句用分号分隔。返回的迁移按版本号排序。
+// 读取子目录可以使用 fs.Sub，e.g. LoadMigrations(os.DirFS("migrations"))
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	migrations := make(map[string]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		fileName := entry.Name()
+		var up bool
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			up = true
+		case strings.HasSuffix(fileName, ".down.sql"):
+		default:
+			continue
+		}
+		base := strings.TrimSuffix(strings.TrimSuffix(fileName, ".up.sql"), ".down.sql")
+		version, name, _ := strings.Cut(base, "_")
+		if version == "" {
+			return nil, errs.NewErrInvalidMigrationFile(fileName)
+		}
+		content, err := fs.ReadFile(fsys, fileName)
+		if err != nil {
+			return nil, err
+		}
+		mg, ok := migrations[version]
+		if !ok {
+			mg = &Migration{Version: version, Name: name}
+			migrations[version] = mg
+		}
+		if up {
+			mg.Up = splitStatements(string(content))
+		} else {
+			mg.Down = splitStatements(string(content))
+		}
+	}
+
+	res := make([]Migration, 0, len(migrations))
+	for _, mg := range migrations {
+		res = append(res, *mg)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Version < res[j].Version
+	})
+	return res, nil
+}
+
+// splitStatements 按分号拆分语句，引号内的分号不作为分隔符，空语句会被忽略
+func splitStatements(content string) []string {
+	var (
+		res   []string
+		quote byte
+		start int
+	)
+	appendStmt := func(stmt string) {
+		stmt = strings.TrimSpace(stmt)
+		if stmt != "" {
+			res = append(res, stmt)
+		}
+	}
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == ';':
+			appendStmt(content[start:i])
+			start = i + 1
+		}
+	}
+	appendStmt(content[start:])
+	return res
+}
+
+// Applied 返回已经执行的版本号，按版本号排序
+func (m *Migrator) Applied(ctx context.Context) ([]string, error) {
+	if err := m.ensureHistoryTable(ctx); err != nil {
+		return nil, err
+	}
+	b := m.newBuilder()
+	b.sqlStrBuilder.WriteString("SELECT ")
+	b.quote("version")
+	b.sqlStrBuilder.WriteString(" FROM ")
+	b.quote(m.historyTable)
+	b.sqlStrBuilder.WriteByte(';')
+	versions, err := m.queryNames(ctx, &Query{SQL: b.sqlStrBuilder.String()})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, 0, len(versions))
+	for v := range versions {
+		res = append(res, v)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// Up 按版本号顺序执行所有尚未执行的迁移。每个版本的语句与历史记录在同一个事务中执行，
+// 注意 MySQL 的 DDL 会隐式提交事务，失败时已经执行的 DDL 不会回滚
+func (m *Migrator) Up(ctx context.Context, migrations []Migration) error {
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return err
+	}
+	done := make(map[string]struct{}, len(applied))
+	for _, v := range applied {
+		done[v] = struct{}{}
+	}
+
+	pending := make([]Migration, 0, len(migrations))
+	for _, mg := range migrations {
+		if _, ok := done[mg.Version]; !ok {
+			pending = append(pending, mg)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Version < pending[j].Version
+	})
+
+	for _, mg := range pending {
+		mg := mg
+		err = m.db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+			for _, stmt := range mg.Up {
+				if _, err := tx.execContext(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			b := m.newBuilder()
+			b.sqlStrBuilder.WriteString("INSERT INTO ")
+			b.quote(m.historyTable)
+			b.sqlStrBuilder.WriteByte('(')
+			b.quote("version")
+			b.sqlStrBuilder.WriteByte(',')
+			b.quote("name")
+			b.sqlStrBuilder.WriteByte(',')
+			b.quote("applied_at")
+			b.sqlStrBuilder.WriteString(") VALUES (?,?,?);")
+			b.args = []any{mg.Version, mg.Name, time.Now()}
+			q := b.buildQuery()
+			_, err := tx.execContext(ctx, q.SQL, q.Args...)
+			return err
+		}, nil)
+		if err != nil {
+			return fmt.Errorf("orm: 执行迁移 %s 失败: %w", mg.Version, err)
+		}
+	}
+	return nil
+}
+
+// Down 按版本号从新到旧回滚最近执行的 steps 个迁移
+func (m *Migrator) Down(ctx context.Context, migrations []Migration, steps int) error {
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return err
+	}
+	byVersion := make(map[string]Migration, len(migrations))
+	for _, mg := range migrations {
+		byVersion[mg.Version] = mg
+	}
+
+	for i := len(applied) - 1; i >= 0 && steps > 0; i, steps = i-1, steps-1 {
+		mg, ok := byVersion[applied[i]]
+		if !ok {
+			return errs.NewErrUnknownMigration(applied[i])
+		}
+		err = m.db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+			for _, stmt := range mg.Down {
+				if _, err := tx.execContext(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			b := m.newBuilder()
+			b.sqlStrBuilder.WriteString("DELETE FROM ")
+			b.quote(m.historyTable)
+			b.sqlStrBuilder.WriteString(" WHERE ")
+			b.quote("version")
+			b.sqlStrBuilder.WriteString(" = ?;")
+			b.args = []any{mg.Version}
+			q := b.buildQuery()
+			_, err := tx.execContext(ctx, q.SQL, q.Args...)
+			return err
+		}, nil)
+		if err != nil {
+			return fmt.Errorf("orm: 回滚迁移 %s 失败: %w", mg.Version, err)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) ensureHistoryTable(ctx context.Context) error {
+	versionType, err := m.db.dialect.columnType(&model.Field{Type: reflect.TypeOf("")})
+	if err != nil {
+		return err
+	}
+	timeColType, err := m.db.dialect.columnType(&model.Field{Type: timeType})
+	if err != nil {
+		return err
+	}
+	b := m.newBuilder()
+	b.sqlStrBuilder.WriteString("CREATE TABLE IF NOT EXISTS ")
+	b.quote(m.historyTable)
+	b.sqlStrBuilder.WriteString(" (")
+	b.quote("version")
+	b.sqlStrBuilder.WriteString(" " + versionType + " NOT NULL PRIMARY KEY, ")
+	b.quote("name")
+	b.sqlStrBuilder.WriteString(" " + versionType + " NOT NULL, ")
+	b.quote("applied_at")
+	b.sqlStrBuilder.WriteString(" " + timeColType + " NOT NULL);")
+	_, err = m.db.execContext(ctx, b.sqlStrBuilder.String())
+	return err
+}
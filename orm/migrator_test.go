@@ -0,0 +1,330 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MigrateConverterModel struct {
+	Id    int64
+	Tags  []string  `orm:"serializer(json);type(TEXT)"`
+	Codes []int     `orm:"serializer(csv);type(TEXT)"`
+	Birth time.Time `orm:"serializer(unixtime);type(BIGINT)"`
+}
+
+type MigrateUser struct {
+	Id        int64
+	Email     string `orm:"size(64);unique()"`
+	Nickname  *string
+	Age       uint8  `orm:"index()"`
+	Bio       string `orm:"type(TEXT)"`
+	Balance   sql.NullFloat64
+	CreatedAt time.Time
+}
+
+func TestMigrator_Plan(t *testing.T) {
+	testCases := []struct {
+		name      string
+		dialect   Dialect
+		mock      func(mock sqlmock.Sqlmock)
+		wantStmts []string
+		wantErr   error
+	}{
+		{
+			name:    "mysql create table",
+			dialect: MySQL,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?").
+					WithArgs("migrate_user").
+					WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}))
+			},
+			wantStmts: []string{
+				"CREATE TABLE `migrate_user` (`id` BIGINT NOT NULL, `email` VARCHAR(64) NOT NULL, " +
+					"`nickname` VARCHAR(255), `age` TINYINT UNSIGNED NOT NULL, `bio` TEXT NOT NULL, " +
+					"`balance` DOUBLE, `created_at` DATETIME NOT NULL, PRIMARY KEY (`id`));",
+				"CREATE UNIQUE INDEX `uk_migrate_user_email` ON `migrate_user` (`email`);",
+				"CREATE INDEX `idx_migrate_user_age` ON `migrate_user` (`age`);",
+			},
+		},
+		{
+			name:    "sqlite add column and index",
+			dialect: SQLite,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT name FROM pragma_table_info(?)").
+					WithArgs("migrate_user").
+					WillReturnRows(sqlmock.NewRows([]string{"name"}).
+						AddRow("id").AddRow("email").AddRow("nickname").AddRow("age").AddRow("bio"))
+				mock.ExpectQuery("SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ?").
+					WithArgs("migrate_user").
+					WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("uk_migrate_user_email"))
+			},
+			wantStmts: []string{
+				"ALTER TABLE `migrate_user` ADD COLUMN `balance` REAL;",
+				"ALTER TABLE `migrate_user` ADD COLUMN `created_at` DATETIME;",
+				"CREATE INDEX `idx_migrate_user_age` ON `migrate_user` (`age`);",
+			},
+		},
+		{
+			name:    "postgres up to date",
+			dialect: Postgres,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT column_name FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1").
+					WithArgs("migrate_user").
+					WillReturnRows(sqlmock.NewRows([]string{"column_name"}).
+						AddRow("id").AddRow("email").AddRow("nickname").AddRow("age").
+						AddRow("bio").AddRow("balance").AddRow("created_at"))
+				mock.ExpectQuery("SELECT indexname FROM pg_indexes WHERE schemaname = current_schema() AND tablename = $1").
+					WithArgs("migrate_user").
+					WillReturnRows(sqlmock.NewRows([]string{"indexname"}).
+						AddRow("uk_migrate_user_email").AddRow("idx_migrate_user_age"))
+			},
+		},
+		{
+			name:    "query error",
+			dialect: MySQL,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?").
+					WillReturnError(errors.New("mock error"))
+			},
+			wantErr: errors.New("mock error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+			db, err := OpenDB(mockDB, DBWithDialect(tc.dialect))
+			require.NoError(t, err)
+			tc.mock(mock)
+
+			stmts, err := NewMigrator(db).Plan(context.Background(), &MigrateUser{})
+			assert.Equal(t, tc.wantErr, err)
+			if err == nil {
+				assert.Equal(t, tc.wantStmts, stmts)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMigrator_PrimaryKey(t *testing.T) {
+	testCases := []struct {
+		name     string
+		dialect  Dialect
+		model    any
+		columns  string
+		wantStmt string
+	}{
+		{
+			name:     "mysql auto increment",
+			dialect:  MySQL,
+			model:    &PKAutoModel{},
+			columns:  "SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?",
+			wantStmt: "CREATE TABLE `pk_auto_model` (`id` BIGINT NOT NULL AUTO_INCREMENT, `name` VARCHAR(255) NOT NULL, PRIMARY KEY (`id`));",
+		},
+		{
+			name:     "sqlite auto increment",
+			dialect:  SQLite,
+			model:    &PKAutoModel{},
+			columns:  "SELECT name FROM pragma_table_info(?)",
+			wantStmt: "CREATE TABLE `pk_auto_model` (`id` INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT, `name` TEXT NOT NULL);",
+		},
+		{
+			name:    "postgres auto increment",
+			dialect: Postgres,
+			model:   &PKAutoModel{},
+			columns: "SELECT column_name FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1",
+			wantStmt: `CREATE TABLE "pk_auto_model" ("id" BIGINT NOT NULL GENERATED BY DEFAULT AS IDENTITY, ` +
+				`"name" VARCHAR(255) NOT NULL, PRIMARY KEY ("id"));`,
+		},
+		{
+			name:    "composite",
+			dialect: MySQL,
+			model:   &CompositePKModel{},
+			columns: "SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?",
+			wantStmt: "CREATE TABLE `composite_pk_model` (`tenant_id` BIGINT NOT NULL, `code` VARCHAR(255) NOT NULL, " +
+				"`name` VARCHAR(255) NOT NULL, PRIMARY KEY (`tenant_id`, `code`));",
+		},
+		{
+			name:    "nested pointer",
+			dialect: MySQL,
+			model:   &NestedModel{},
+			columns: "SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?",
+			wantStmt: "CREATE TABLE `nested_model` (`id` BIGINT NOT NULL, `addr_city` VARCHAR(255) NOT NULL, " +
+				"`ship_city` VARCHAR(255), PRIMARY KEY (`id`));",
+		},
+		{
+			name:    "converter",
+			dialect: MySQL,
+			model:   &MigrateConverterModel{},
+			columns: "SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?",
+			wantStmt: "CREATE TABLE `migrate_converter_model` (`id` BIGINT NOT NULL, `tags` TEXT, " +
+				"`codes` TEXT, `birth` BIGINT, PRIMARY KEY (`id`));",
+		},
+		{
+			name:     "no primary key",
+			dialect:  MySQL,
+			model:    &NoPKModel{},
+			columns:  "SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?",
+			wantStmt: "CREATE TABLE `no_pk_model` (`name` VARCHAR(255) NOT NULL);",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+			db, err := OpenDB(mockDB, DBWithDialect(tc.dialect))
+			require.NoError(t, err)
+			mock.ExpectQuery(tc.columns).WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+			stmts, err := NewMigrator(db).Plan(context.Background(), tc.model)
+			require.NoError(t, err)
+			assert.Equal(t, []string{tc.wantStmt}, stmts)
+		})
+	}
+}
+
+func TestMigrator_UnsupportedColumnType(t *testing.T) {
+	type Invalid struct {
+		Id   int64
+		Tags []string
+	}
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+	mock.ExpectQuery("SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?").
+		WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}))
+
+	_, err = NewMigrator(db).Plan(context.Background(), &Invalid{})
+	assert.Equal(t, errs.NewErrUnsupportedColumnType("Tags", "[]string"), err)
+
+	// 指定了 serializer 的字段保存的是转换之后的值，同样需要通过 orm:"type()" 指定列类型
+	type Serialized struct {
+		Id    int64
+		Birth time.Time `orm:"serializer(unixtime)"`
+	}
+	mock.ExpectQuery("SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?").
+		WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}))
+	_, err = NewMigrator(db).Plan(context.Background(), &Serialized{})
+	assert.Equal(t, errs.NewErrUnsupportedColumnType("Birth", "time.Time"), err)
+}
+
+func TestMigrator_AutoMigrate(t *testing.T) {
+	type MigrateTag struct {
+		Id   int64
+		Name string
+	}
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?").
+		WithArgs("migrate_tag").
+		WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}))
+	mock.ExpectExec("CREATE TABLE `migrate_tag` (`id` BIGINT NOT NULL, `name` VARCHAR(255) NOT NULL, PRIMARY KEY (`id`));").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = NewMigrator(db).AutoMigrate(context.Background(), &MigrateTag{})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"20240102000000_add_age.up.sql":       {Data: []byte("ALTER TABLE `user` ADD COLUMN `age` INT;")},
+		"20240102000000_add_age.down.sql":     {Data: []byte("ALTER TABLE `user` DROP COLUMN `age`;")},
+		"20240101000000_create_user.up.sql":   {Data: []byte("CREATE TABLE `user` (`id` BIGINT);\n INSERT INTO `user` VALUES (';');\n")},
+		"20240101000000_create_user.down.sql": {Data: []byte("DROP TABLE `user`")},
+		"README.md":                           {Data: []byte("ignored")},
+	}
+	migrations, err := LoadMigrations(fsys)
+	require.NoError(t, err)
+	assert.Equal(t, []Migration{
+		{
+			Version: "20240101000000",
+			Name:    "create_user",
+			Up:      []string{"CREATE TABLE `user` (`id` BIGINT)", "INSERT INTO `user` VALUES (';')"},
+			Down:    []string{"DROP TABLE `user`"},
+		},
+		{
+			Version: "20240102000000",
+			Name:    "add_age",
+			Up:      []string{"ALTER TABLE `user` ADD COLUMN `age` INT"},
+			Down:    []string{"ALTER TABLE `user` DROP COLUMN `age`"},
+		},
+	}, migrations)
+
+	_, err = LoadMigrations(fstest.MapFS{"_x.up.sql": {}})
+	assert.Equal(t, errs.NewErrInvalidMigrationFile("_x.up.sql"), err)
+}
+
+func TestMigrator_UpDown(t *testing.T) {
+	migrations := []Migration{
+		{Version: "2", Name: "add_age", Up: []string{"ALTER TABLE `user` ADD COLUMN `age` INT"},
+			Down: []string{"ALTER TABLE `user` DROP COLUMN `age`"}},
+		{Version: "1", Name: "create_user", Up: []string{"CREATE TABLE `user` (`id` BIGINT)"},
+			Down: []string{"DROP TABLE `user`"}},
+		{Version: "3", Name: "add_name", Up: []string{"ALTER TABLE `user` ADD COLUMN `name` TEXT"},
+			Down: []string{"ALTER TABLE `user` DROP COLUMN `name`"}},
+	}
+	const createHistory = "CREATE TABLE IF NOT EXISTS `schema_migrations` (`version` VARCHAR(255) NOT NULL PRIMARY KEY, " +
+		"`name` VARCHAR(255) NOT NULL, `applied_at` DATETIME NOT NULL);"
+	const selectHistory = "SELECT `version` FROM `schema_migrations`;"
+
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+	m := NewMigrator(db)
+
+	// Up：版本 1 已执行，按版本号顺序执行 2、3
+	mock.ExpectExec(createHistory).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(selectHistory).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("1"))
+	for _, mg := range []Migration{migrations[0], migrations[2]} {
+		mock.ExpectBegin()
+		mock.ExpectExec(mg.Up[0]).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO `schema_migrations`(`version`,`name`,`applied_at`) VALUES (?,?,?);").
+			WithArgs(mg.Version, mg.Name, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+	require.NoError(t, m.Up(context.Background(), migrations))
+
+	// Down：回滚最近的两个版本 3、2
+	mock.ExpectExec(createHistory).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(selectHistory).WillReturnRows(sqlmock.NewRows([]string{"version"}).
+		AddRow("3").AddRow("1").AddRow("2"))
+	for _, mg := range []Migration{migrations[2], migrations[0]} {
+		mock.ExpectBegin()
+		mock.ExpectExec(mg.Down[0]).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM `schema_migrations` WHERE `version` = ?;").
+			WithArgs(mg.Version).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+	require.NoError(t, m.Down(context.Background(), migrations, 2))
+
+	// Up 失败时回滚事务，不记录历史
+	mock.ExpectExec(createHistory).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(selectHistory).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("1"))
+	mock.ExpectBegin()
+	mock.ExpectExec(migrations[0].Up[0]).WillReturnError(errors.New("mock error"))
+	mock.ExpectRollback()
+	err = m.Up(context.Background(), migrations)
+	assert.ErrorContains(t, err, "mock error")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
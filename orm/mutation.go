@@ -0,0 +1,246 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+)
+
+// mutationStyle UPDATE/DELETE 联表以及使用 ORDER BY/LIMIT 时的写法，由方言决定
+type mutationStyle int
+
+const (
+	// mutationJoin 在目标表后面直接联表，e.g. MySQL 的 UPDATE a JOIN b ON ... SET ... 和 DELETE a FROM a JOIN b ON ...。
+	// 单表时支持 ORDER BY 和 LIMIT，联表时不支持
+	mutationJoin mutationStyle = iota
+	// mutationFrom 联表中的其他表放到 FROM/USING 中，ON 条件并入 WHERE，
+	// e.g. Postgres 的 UPDATE a SET ... FROM b WHERE ... 和 DELETE FROM a USING b WHERE ...。
+	// 只支持内连接，不支持 ORDER BY 和 LIMIT
+	mutationFrom
+	// mutationSubquery 改写为按 rowid 匹配子查询，
+	// e.g. SQLite 的 UPDATE a SET ... WHERE rowid IN (SELECT a.rowid FROM a JOIN b ON ... ORDER BY ... LIMIT ?)
+	mutationSubquery
+)
+
+// mutation UPDATE/DELETE 语句中的联表、ORDER BY 和 LIMIT，由 Updater 和 Deleter 共用
+type mutation struct {
+	table   TableReference
+	orderBy []OrderBy
+	limit   int
+
+	style mutationStyle
+	// target 联表中模型对应的表
+	target Table
+	// others 和 ons 是 mutationFrom 方式下联表中的其他表和联表条件
+	others []TableReference
+	ons    []Predicate
+}
+
+func (m *mutation) joined() bool {
+	_, ok := m.table.(Join)
+	return ok
+}
+
+func (m *mutation) ordered() bool {
+	return len(m.orderBy) > 0 || m.limit > 0
+}
+
+// prepareMutation 拆解联表，并校验方言能否表达 m
+func (b *builder) prepareMutation(m *mutation) error {
+	m.style = b.dialect.mutationStyle()
+	m.others, m.ons = nil, nil
+	switch tbl := m.table.(type) {
+	case nil:
+	case Table:
+		// 单表等价于不调用 From，只能是模型对应的表
+		meta, err := b.r.Get(tbl.entity)
+		if err != nil {
+			return err
+		}
+		if meta != b.model {
+			return errs.NewErrUnsupportedTable(tbl)
+		}
+		m.table = nil
+	case Join:
+		target, ok, err := b.findTarget(tbl)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errs.NewErrUnsupportedFeature("联表中没有 UPDATE/DELETE 的目标表")
+		}
+		m.target = target
+	default:
+		return errs.NewErrUnsupportedTable(tbl)
+	}
+
+	switch m.style {
+	case mutationJoin:
+		if m.joined() && m.ordered() {
+			return errs.NewErrUnsupportedFeature("联表 UPDATE/DELETE 使用 ORDER BY/LIMIT")
+		}
+	case mutationFrom:
+		if m.ordered() {
+			return errs.NewErrUnsupportedFeature("UPDATE/DELETE 使用 ORDER BY/LIMIT")
+		}
+		if m.joined() {
+			found := false
+			return b.flattenJoin(m, m.table, &found)
+		}
+	}
+	return nil
+}
+
+// findTarget 从左到右查找联表中第一个模型对应的表
+func (b *builder) findTarget(table TableReference) (Table, bool, error) {
+	switch tbl := table.(type) {
+	case Table:
+		meta, err := b.r.Get(tbl.entity)
+		if err != nil {
+			return Table{}, false, err
+		}
+		return tbl, meta == b.model, nil
+	case Join:
+		target, ok, err := b.findTarget(tbl.left)
+		if err != nil || ok {
+			return target, ok, err
+		}
+		return b.findTarget(tbl.right)
+	case Subquery:
+		return Table{}, false, nil
+	default:
+		return Table{}, false, errs.NewErrUnsupportedTable(table)
+	}
+}
+
+// flattenJoin 将内连接拆成目标表、其他表和联表条件，外连接和 USING 无法改写为 FROM/USING 子句
+func (b *builder) flattenJoin(m *mutation, table TableReference, found *bool) error {
+	switch tbl := table.(type) {
+	case Table:
+		meta, err := b.r.Get(tbl.entity)
+		if err != nil {
+			return err
+		}
+		if !*found && meta == b.model && tbl.alias == m.target.alias {
+			*found = true
+			return nil
+		}
+		m.others = append(m.others, tbl)
+	case Join:
+		if tbl.typ != "JOIN" || len(tbl.using) > 0 {
+			return errs.NewErrUnsupportedFeature("UPDATE/DELETE 使用 " + tbl.typ + " 或 USING 联表")
+		}
+		if err := b.flattenJoin(m, tbl.left, found); err != nil {
+			return err
+		}
+		if err := b.flattenJoin(m, tbl.right, found); err != nil {
+			return err
+		}
+		m.ons = append(m.ons, tbl.on...)
+	default:
+		m.others = append(m.others, tbl)
+	}
+	return nil
+}
+
+// buildTargetName 写入目标表的名字或者别名，用于 DELETE a FROM ... 以及限定列名
+func (b *builder) buildTargetName(m *mutation) {
+	if m.target.alias != "" {
+		b.quote(m.target.alias)
+		return
+	}
+	b.quoteTable(b.model)
+}
+
+// buildMutationTable 写入 UPDATE 和 DELETE FROM 后面的表，name 是用户通过 Deleter.From 指定的表名
+func (b *builder) buildMutationTable(m *mutation, name string) error {
+	switch {
+	case m.joined() && m.style == mutationJoin:
+		return b.buildTable(m.table)
+	case m.joined() && m.style == mutationFrom:
+		return b.buildTable(m.target)
+	case name != "":
+		b.sqlStrBuilder.WriteString(name)
+	default:
+		b.quoteTable(b.model)
+	}
+	return nil
+}
+
+// setColumn SET 中的列，只有直接联表时需要用目标表限定列名，e.g. `a`.`col`=?
+func (m *mutation) setColumn(name string) Column {
+	if m.joined() && m.style == mutationJoin {
+		return m.target.Col(name)
+	}
+	return Col(name)
+}
+
+// buildMutationFrom mutationFrom 方式下写入联表中的其他表，keyword 是 UPDATE 的 FROM 或者 DELETE 的 USING
+func (b *builder) buildMutationFrom(m *mutation, keyword string) error {
+	if !m.joined() || m.style != mutationFrom {
+		return nil
+	}
+	b.sqlStrBuilder.WriteString(" " + keyword + " ")
+	for idx, tbl := range m.others {
+		if idx > 0 {
+			b.sqlStrBuilder.WriteByte(',')
+		}
+		if err := b.buildTable(tbl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildMutationWhere 写入 WHERE 子句（含软删除过滤），返回是否写入了 WHERE。
+// 联表时软删除列由目标表限定；mutationSubquery 方式下联表、ORDER BY 和 LIMIT 都放到子查询中
+func (b *builder) buildMutationWhere(m *mutation, where []Predicate) (bool, error) {
+	if m.style == mutationSubquery && (m.joined() || m.ordered()) {
+		b.sqlStrBuilder.WriteString(" WHERE rowid IN (SELECT ")
+		if m.joined() {
+			b.buildTargetName(m)
+			b.sqlStrBuilder.WriteByte('.')
+			b.target = m.target
+		}
+		b.sqlStrBuilder.WriteString("rowid FROM ")
+		if err := b.buildTable(m.table); err != nil {
+			return false, err
+		}
+		if err := b.buildWhereWithSoftDelete(where); err != nil {
+			return false, err
+		}
+		b.target = nil
+		if err := b.buildMutationLimit(m, true); err != nil {
+			return false, err
+		}
+		b.sqlStrBuilder.WriteByte(')')
+		return true, nil
+	}
+
+	if m.joined() {
+		b.target = m.target
+	}
+	if len(m.ons) > 0 {
+		where = append(append([]Predicate{}, m.ons...), where...)
+	}
+	if err := b.buildWhereWithSoftDelete(where); err != nil {
+		return false, err
+	}
+	return b.softDeleteFiltered() || len(where) > 0, nil
+}
+
+// buildMutationLimit 写入 ORDER BY 和 LIMIT，subquery 表示是否在 mutationSubquery 的子查询中
+func (b *builder) buildMutationLimit(m *mutation, subquery bool) error {
+	if subquery != (m.style == mutationSubquery) {
+		return nil
+	}
+	if len(m.orderBy) > 0 {
+		b.sqlStrBuilder.WriteString(" ORDER BY ")
+		if err := b.buildOrderBy(m.orderBy); err != nil {
+			return err
+		}
+	}
+	if m.limit > 0 {
+		b.sqlStrBuilder.WriteString(" LIMIT ?")
+		b.args = append(b.args, m.limit)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMutation_Build(t *testing.T) {
+	type Order struct {
+		Id     int64
+		UserId int64
+		Amount int64
+	}
+	type User struct {
+		Id  int64
+		Vip bool
+	}
+
+	newDB := func(dialect Dialect) *DB {
+		mockDB, _, err := sqlmock.New()
+		require.NoError(t, err)
+		db, err := OpenDB(mockDB, DBWithDialect(dialect))
+		require.NoError(t, err)
+		return db
+	}
+	mysql, postgres, sqlite := newDB(MySQL), newDB(Postgres), newDB(SQLite)
+
+	o, u := TableOf(&Order{}), TableOf(&User{}).As("u")
+	join := o.Join(u).On(o.Col("UserId").EQ(u.Col("Id")))
+
+	testCases := []struct {
+		name      string
+		builder   QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "mysql update join",
+			builder: NewUpdater[Order](mysql).From(join).
+				Set(Assign("Amount", 0)).Where(u.Col("Vip").EQ(true)),
+			wantQuery: &Query{
+				SQL:  "UPDATE (`order` JOIN `user` AS `u` ON `order`.`user_id` = `u`.`id`) SET `order`.`amount`=? WHERE `u`.`vip` = ?;",
+				Args: []any{0, true},
+			},
+		},
+		{
+			name: "mysql update order by limit",
+			builder: NewUpdater[Order](mysql).Set(Assign("Amount", 0)).
+				Where(Col("UserId").EQ(1)).OrderBy(Desc("Id")).Limit(10),
+			wantQuery: &Query{
+				SQL:  "UPDATE `order` SET `amount`=? WHERE `user_id` = ? ORDER BY `id` DESC LIMIT ?;",
+				Args: []any{0, 1, 10},
+			},
+		},
+		{
+			name:    "mysql delete join",
+			builder: NewDeleter[Order](mysql).FromTable(join).Where(u.Col("Vip").EQ(false)),
+			wantQuery: &Query{
+				SQL:  "DELETE `order` FROM (`order` JOIN `user` AS `u` ON `order`.`user_id` = `u`.`id`) WHERE `u`.`vip` = ?;",
+				Args: []any{false},
+			},
+		},
+		{
+			name:    "mysql delete limit",
+			builder: NewDeleter[Order](mysql).OrderBy(Asc("Id")).Limit(100),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `order` ORDER BY `id` ASC LIMIT ?;",
+				Args: []any{100},
+			},
+		},
+		{
+			name:    "mysql join with limit",
+			builder: NewDeleter[Order](mysql).FromTable(join).Limit(1),
+			wantErr: errs.NewErrUnsupportedFeature("联表 UPDATE/DELETE 使用 ORDER BY/LIMIT"),
+		},
+		{
+			name: "postgres update from",
+			builder: NewUpdater[Order](postgres).From(join).
+				Set(Assign("Amount", 0)).Where(u.Col("Vip").EQ(true)),
+			wantQuery: &Query{
+				SQL:  `UPDATE "order" SET "amount"=$1 FROM "user" AS "u" WHERE ("order"."user_id" = "u"."id") AND ("u"."vip" = $2);`,
+				Args: []any{0, true},
+			},
+		},
+		{
+			name:    "postgres delete using",
+			builder: NewDeleter[Order](postgres).FromTable(join),
+			wantQuery: &Query{
+				SQL: `DELETE FROM "order" USING "user" AS "u" WHERE "order"."user_id" = "u"."id";`,
+			},
+		},
+		{
+			name:    "postgres left join",
+			builder: NewDeleter[Order](postgres).FromTable(o.LeftJoin(u).On(o.Col("UserId").EQ(u.Col("Id")))),
+			wantErr: errs.NewErrUnsupportedFeature("UPDATE/DELETE 使用 LEFT JOIN 或 USING 联表"),
+		},
+		{
+			name:    "postgres limit",
+			builder: NewUpdater[Order](postgres).Set(Assign("Amount", 0)).Limit(1),
+			wantErr: errs.NewErrUnsupportedFeature("UPDATE/DELETE 使用 ORDER BY/LIMIT"),
+		},
+		{
+			name: "sqlite update join",
+			builder: NewUpdater[Order](sqlite).From(join).
+				Set(Assign("Amount", 0)).Where(u.Col("Vip").EQ(true)).Limit(10),
+			wantQuery: &Query{
+				SQL: "UPDATE `order` SET `amount`=? WHERE rowid IN (SELECT `order`.rowid FROM (`order` JOIN `user` AS `u` ON `order`.`user_id` = `u`.`id`)" +
+					" WHERE `u`.`vip` = ? LIMIT ?);",
+				Args: []any{0, true, 10},
+			},
+		},
+		{
+			name:    "sqlite delete order by limit",
+			builder: NewDeleter[Order](sqlite).Where(Col("UserId").EQ(1)).OrderBy(Asc("Id")).Limit(10),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `order` WHERE rowid IN (SELECT rowid FROM `order` WHERE `user_id` = ? ORDER BY `id` ASC LIMIT ?);",
+				Args: []any{1, 10},
+			},
+		},
+		{
+			name:    "join without target",
+			builder: NewDeleter[Order](mysql).FromTable(u.Join(TableOf(&TestModel{})).Using("Id")),
+			wantErr: errs.NewErrUnsupportedFeature("联表中没有 UPDATE/DELETE 的目标表"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err == nil {
+				assert.Equal(t, tc.wantQuery, q)
+			}
+		})
+	}
+}
+
+func TestMutation_SoftDeleteJoin(t *testing.T) {
+	mockDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	s, m := TableOf(&SoftDeleteModel{}), TableOf(&TestModel{})
+	q, err := NewDeleter[SoftDeleteModel](db).
+		FromTable(s.Join(m).On(s.Col("Id").EQ(m.Col("Id")))).Where(m.Col("Age").GT(18)).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "UPDATE (`soft_delete_model` JOIN `test_model` ON `soft_delete_model`.`id` = `test_model`.`id`)"+
+		" SET `soft_delete_model`.`deleted_at`=? WHERE `soft_delete_model`.`deleted_at` IS NULL AND `test_model`.`age` > ?;", q.SQL)
+	assert.Equal(t, 18, q.Args[1])
+}
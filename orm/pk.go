@@ -0,0 +1,80 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+	"Soil/orm/internal/model"
+	"strings"
+)
+
+// primaryKeys 返回模型的主键字段，没有主键时返回 ErrNoPrimaryKey
+func primaryKeys(m *model.Model) ([]*model.Field, error) {
+	if len(m.PrimaryKeys) == 0 {
+		return nil, errs.ErrNoPrimaryKey
+	}
+	return m.PrimaryKeys, nil
+}
+
+// pkValues 读取 val 的主键值，顺序与 Model.PrimaryKeys 相同
+func (b *builder) pkValues(val any) ([]any, error) {
+	pks, err := primaryKeys(b.model)
+	if err != nil {
+		return nil, err
+	}
+	valDealer := b.valCreator(val, b.model)
+	res := make([]any, 0, len(pks))
+	for _, pk := range pks {
+		v, err := valDealer.GetFieldValue(pk.GoName)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
+
+// pkRow 匹配一行的条件，e.g. `tenant_id` = ? AND `code` = ?
+func pkRow(pks []*model.Field, key []any) Predicate {
+	p := Col(pks[0].GoName).EQ(key[0])
+	for i := 1; i < len(pks); i++ {
+		p = p.And(Col(pks[i].GoName).EQ(key[i]))
+	}
+	return p
+}
+
+// pkPredicate 匹配 keys 中任意一行的条件：单列主键为 `id` IN (...)，
+// 联合主键为 ((`a` = ?) AND (`b` = ?)) OR ...
+func pkPredicate(pks []*model.Field, keys [][]any) Predicate {
+	if len(pks) == 1 {
+		vals := make([]any, 0, len(keys))
+		for _, key := range keys {
+			vals = append(vals, key[0])
+		}
+		return Col(pks[0].GoName).In(vals...)
+	}
+	p := pkRow(pks, keys[0])
+	for _, key := range keys[1:] {
+		p = p.Or(pkRow(pks, key))
+	}
+	return p
+}
+
+// pkKey 将主键值转换为 map 的 key，用于比较不同来源（例如结构体与查询结果）的主键
+func pkKey(key []any) (string, bool) {
+	parts := make([]string, 0, len(key))
+	for _, v := range key {
+		k, ok := relationKey(v)
+		if !ok {
+			return "", false
+		}
+		parts = append(parts, k)
+	}
+	return strings.Join(parts, "\x00"), true
+}
+
+// pkResult 返回给用户的主键：单列主键是主键值本身，联合主键是按主键字段顺序排列的 []any
+func pkResult(key []any) any {
+	if len(key) == 1 {
+		return key[0]
+	}
+	return key
+}
@@ -0,0 +1,197 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// PKAutoModel 自增主键
+type PKAutoModel struct {
+	Id   int64 `orm:"auto_increment()"`
+	Name string
+}
+
+// CompositePKModel 联合主键
+type CompositePKModel struct {
+	TenantId int64  `orm:"primary_key()"`
+	Code     string `orm:"primary_key()"`
+	Name     string
+}
+
+type NoPKModel struct {
+	Name string
+}
+
+func TestSelector_ByPK(t *testing.T) {
+	mockDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		builder   QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "single",
+			builder: NewSelector[TestModel](db).ByPK(int64(1)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` IN (?);",
+				Args: []any{int64(1)},
+			},
+		},
+		{
+			name:    "multiple with where",
+			builder: NewSelector[TestModel](db).ByPK(1, 2).Where(Col("Age").GT(18)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE (`id` IN (?,?)) AND (`age` > ?);",
+				Args: []any{1, 2, 18},
+			},
+		},
+		{
+			name:    "composite",
+			builder: NewSelector[CompositePKModel](db).ByPK(int64(1), "a"),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `composite_pk_model` WHERE (`tenant_id` = ?) AND (`code` = ?);",
+				Args: []any{int64(1), "a"},
+			},
+		},
+		{
+			name:    "composite values count",
+			builder: NewSelector[CompositePKModel](db).ByPK(int64(1)),
+			wantErr: errs.ErrPrimaryKeyValues,
+		},
+		{
+			name:    "no primary key",
+			builder: NewSelector[NoPKModel](db).ByPK(1),
+			wantErr: errs.ErrNoPrimaryKey,
+		},
+		{
+			name:    "delete composite",
+			builder: NewDeleter[CompositePKModel](db).Delete(&CompositePKModel{TenantId: 1, Code: "a"}, &CompositePKModel{TenantId: 1, Code: "b"}),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `composite_pk_model` WHERE ((`tenant_id` = ?) AND (`code` = ?)) OR ((`tenant_id` = ?) AND (`code` = ?));",
+				Args: []any{int64(1), "a", int64(1), "b"},
+			},
+		},
+		{
+			name: "bulk update composite",
+			builder: NewBulkUpdater[CompositePKModel](db).Values(
+				&CompositePKModel{TenantId: 1, Code: "a", Name: "x"},
+				&CompositePKModel{TenantId: 1, Code: "b", Name: "y"},
+			),
+			wantQuery: &Query{
+				SQL: "UPDATE `composite_pk_model` SET `name`=CASE WHEN `tenant_id`=? AND `code`=? THEN ? " +
+					"WHEN `tenant_id`=? AND `code`=? THEN ? END " +
+					"WHERE ((`tenant_id` = ?) AND (`code` = ?)) OR ((`tenant_id` = ?) AND (`code` = ?));",
+				Args: []any{int64(1), "a", "x", int64(1), "b", "y", int64(1), "a", int64(1), "b"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err == nil {
+				assert.Equal(t, tc.wantQuery, q)
+			}
+		})
+	}
+}
+
+func TestInserter_GeneratedID(t *testing.T) {
+	testCases := []struct {
+		name    string
+		dialect Dialect
+		mock    func(mock sqlmock.Sqlmock)
+		wantIds []int64
+	}{
+		{
+			name:    "mysql first id per chunk",
+			dialect: MySQL,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `pk_auto_model`(`name`) VALUES (?),(?);").
+					WithArgs("a", "b").
+					WillReturnResult(sqlmock.NewResult(10, 2))
+				mock.ExpectExec("INSERT INTO `pk_auto_model`(`name`) VALUES (?);").
+					WithArgs("c").
+					WillReturnResult(sqlmock.NewResult(20, 1))
+			},
+			wantIds: []int64{10, 11, 20},
+		},
+		{
+			name:    "sqlite last id per chunk",
+			dialect: SQLite,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `pk_auto_model`(`name`) VALUES (?),(?);").
+					WillReturnResult(sqlmock.NewResult(11, 2))
+				mock.ExpectExec("INSERT INTO `pk_auto_model`(`name`) VALUES (?);").
+					WillReturnResult(sqlmock.NewResult(12, 1))
+			},
+			wantIds: []int64{10, 11, 12},
+		},
+		{
+			name:    "postgres returning",
+			dialect: Postgres,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`INSERT INTO "pk_auto_model"("name") VALUES ($1),($2) RETURNING "id";`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7).AddRow(8))
+				mock.ExpectQuery(`INSERT INTO "pk_auto_model"("name") VALUES ($1) RETURNING "id";`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+			},
+			wantIds: []int64{7, 8, 9},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+			db, err := OpenDB(mockDB, DBWithDialect(tc.dialect))
+			require.NoError(t, err)
+			tc.mock(mock)
+
+			vals := []*PKAutoModel{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+			res := NewInserter[PKAutoModel](db).Values(vals...).ChunkSize(2).Exec(context.Background())
+			require.NoError(t, res.Err())
+			ids := make([]int64, 0, len(vals))
+			for _, val := range vals {
+				ids = append(ids, val.Id)
+			}
+			assert.Equal(t, tc.wantIds, ids)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSave(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	mock.ExpectExec("INSERT INTO `pk_auto_model`(`name`) VALUES (?);").
+		WithArgs("Tom").
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectExec("UPDATE `pk_auto_model` SET `id`=?,`name`=? WHERE `id` = ?;").
+		WithArgs(int64(5), "Jerry", int64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	val := &PKAutoModel{Name: "Tom"}
+	require.NoError(t, Save[PKAutoModel](context.Background(), db, val).Err())
+	assert.Equal(t, int64(5), val.Id)
+
+	val.Name = "Jerry"
+	require.NoError(t, Save[PKAutoModel](context.Background(), db, val).Err())
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, errs.ErrNoPrimaryKey, Save[NoPKModel](context.Background(), db, &NoPKModel{}).Err())
+}
@@ -1,18 +1,33 @@
 package orm
 
+import "reflect"
+
 type op string
 
 const (
-	opEQ    = "="
-	opAnd   = "AND"
-	opOr    = "OR"
-	opNOT   = "NOT"
-	opLT    = "<"
-	opGT    = ">"
-	opAdd   = "+"
-	opSub   = "-"
-	opMulti = "*"
-	opDiv   = "/"
+	opEQ         = "="
+	opNE         = "!="
+	opAnd        = "AND"
+	opOr         = "OR"
+	opNOT        = "NOT"
+	opLT         = "<"
+	opLE         = "<="
+	opGT         = ">"
+	opGE         = ">="
+	opIn         = "IN"
+	opNotIn      = "NOT IN"
+	opLike       = "LIKE"
+	opNotLike    = "NOT LIKE"
+	opBetween    = "BETWEEN"
+	opNotBetween = "NOT BETWEEN"
+	opIsNull     = "IS NULL"
+	opIsNotNull  = "IS NOT NULL"
+	opExists     = "EXISTS"
+	opNotExists  = "NOT EXISTS"
+	opAdd        = "+"
+	opSub        = "-"
+	opMulti      = "*"
+	opDiv        = "/"
 )
 
 func (o op) String() string {
@@ -56,3 +71,29 @@ func (l Predicate) Or(r Predicate) Predicate {
 		right: r,
 	}
 }
+
+// inOperand 将 In/NotIn 的参数转换为右操作数：
+//   - 只传入一个 QueryBuilder（例如 *Selector）或者 Subquery 时作为子查询，e.g. In(NewSelector[Order](db).Select(Col("UserId")))
+//   - 只传入一个切片（[]byte 除外）时展开切片元素，e.g. In([]int{1, 2, 3})
+//   - 其它情况下每个参数对应一个占位符，e.g. In(1, 2, 3)
+func inOperand(vals []any) Expression {
+	if len(vals) == 1 {
+		switch v := vals[0].(type) {
+		case QueryBuilder:
+			return subqueryExpression{qb: v}
+		case Subquery:
+			return v
+		case []byte:
+			return valuesExpression{vals: vals}
+		}
+		rv := reflect.ValueOf(vals[0])
+		if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			expanded := make([]any, 0, rv.Len())
+			for i := 0; i < rv.Len(); i++ {
+				expanded = append(expanded, rv.Index(i).Interface())
+			}
+			return valuesExpression{vals: expanded}
+		}
+	}
+	return valuesExpression{vals: vals}
+}
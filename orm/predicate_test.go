@@ -0,0 +1,189 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPredicate_Operators(t *testing.T) {
+	type Order struct {
+		Id     int64
+		UserId int64
+	}
+
+	mockDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		builder   QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "not equal",
+			builder: NewSelector[TestModel](db).Where(Col("Age").NE(18)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `age` != ?;",
+				Args: []any{18},
+			},
+		},
+		{
+			name:    "greater equal and less equal",
+			builder: NewSelector[TestModel](db).Where(Col("Age").GE(18), Col("Age").LE(30)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE (`age` >= ?) AND (`age` <= ?);",
+				Args: []any{18, 30},
+			},
+		},
+		{
+			name:    "in variadic",
+			builder: NewSelector[TestModel](db).Where(Col("Id").In(1, 2, 3)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` IN (?,?,?);",
+				Args: []any{1, 2, 3},
+			},
+		},
+		{
+			name:    "in slice",
+			builder: NewSelector[TestModel](db).Where(Col("Id").In([]int64{1, 2})),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` IN (?,?);",
+				Args: []any{int64(1), int64(2)},
+			},
+		},
+		{
+			name:    "in single value",
+			builder: NewSelector[TestModel](db).Where(Col("Id").In(1)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` IN (?);",
+				Args: []any{1},
+			},
+		},
+		{
+			name:    "in empty",
+			builder: NewSelector[TestModel](db).Where(Col("Id").In([]int{})),
+			wantErr: errs.ErrEmptyInValues,
+		},
+		{
+			name:    "not in",
+			builder: NewSelector[TestModel](db).Where(Col("FirstName").NotIn("Tom", "Jerry")),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `first_name` NOT IN (?,?);",
+				Args: []any{"Tom", "Jerry"},
+			},
+		},
+		{
+			name: "in subquery",
+			builder: NewSelector[TestModel](db).Where(Col("Id").In(
+				NewSelector[Order](db).Select(Col("UserId")).Where(Col("Id").GT(10)))),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` IN (SELECT `user_id` FROM `order` WHERE `id` > ?);",
+				Args: []any{10},
+			},
+		},
+		{
+			name: "in subquery keeps args order",
+			builder: NewSelector[TestModel](db).Where(Col("Age").GT(18), Col("Id").NotIn(
+				NewSelector[Order](db).Select(Col("UserId")).Where(Col("Id").LT(5))), Col("Age").LT(60)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE ((`age` > ?) AND (`id` NOT IN (SELECT `user_id` FROM `order` WHERE `id` < ?))) AND (`age` < ?);",
+				Args: []any{18, 5, 60},
+			},
+		},
+		{
+			name:    "like",
+			builder: NewSelector[TestModel](db).Where(Col("FirstName").Like("To%")),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `first_name` LIKE ?;",
+				Args: []any{"To%"},
+			},
+		},
+		{
+			name:    "not like",
+			builder: NewSelector[TestModel](db).Where(Col("FirstName").NotLike("%om")),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `first_name` NOT LIKE ?;",
+				Args: []any{"%om"},
+			},
+		},
+		{
+			name:    "between",
+			builder: NewSelector[TestModel](db).Where(Col("Age").Between(18, 30)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `age` BETWEEN ? AND ?;",
+				Args: []any{18, 30},
+			},
+		},
+		{
+			name:    "not between",
+			builder: NewSelector[TestModel](db).Where(Col("Age").NotBetween(18, 30)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `age` NOT BETWEEN ? AND ?;",
+				Args: []any{18, 30},
+			},
+		},
+		{
+			name:    "is null",
+			builder: NewSelector[TestModel](db).Where(Col("LastName").IsNull()),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` WHERE `last_name` IS NULL;",
+			},
+		},
+		{
+			name:    "is not null and",
+			builder: NewSelector[TestModel](db).Where(Col("LastName").IsNotNull().And(Col("Age").EQ(18))),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE (`last_name` IS NOT NULL) AND (`age` = ?);",
+				Args: []any{18},
+			},
+		},
+		{
+			name:    "unknown field",
+			builder: NewSelector[TestModel](db).Where(Col("Invalid").In(1, 2)),
+			wantErr: errs.NewErrUnknownField("Invalid"),
+		},
+		{
+			name: "having aggregate",
+			builder: NewSelector[TestModel](db).Select(Col("Age")).GroupBy(Col("Age")).
+				Having(Count("Id").Between(1, 10), Avg("Age").In(18, 20), Max("Age").IsNotNull()),
+			wantQuery: &Query{
+				SQL:  "SELECT `age` FROM `test_model` GROUP BY `age` HAVING ((COUNT(`id`) BETWEEN ? AND ?) AND (AVG(`age`) IN (?,?))) AND (MAX(`age`) IS NOT NULL);",
+				Args: []any{1, 10, 18, 20},
+			},
+		},
+		{
+			name:    "updater where",
+			builder: NewUpdater[TestModel](db).Set(Assign("Age", 18)).Where(Col("Id").In(1, 2)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `test_model` SET `age`=? WHERE `id` IN (?,?);",
+				Args: []any{18, 1, 2},
+			},
+		},
+		{
+			name:    "deleter where",
+			builder: NewDeleter[TestModel](db).Where(Col("LastName").Like("%x"), Col("Age").LE(3)),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `test_model` WHERE (`last_name` LIKE ?) AND (`age` <= ?);",
+				Args: []any{"%x", 3},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
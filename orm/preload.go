@@ -0,0 +1,374 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+	"Soil/orm/internal/model"
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+)
+
+// Preload 在查询主模型之后批量加载关联字段，参数为关联字段的 Go 字段名，e.g.
+// NewSelector[Order](db).Preload("Items", "Tags").GetMulti(ctx)。
+// 每个关联额外发起一次 IN 查询（多对多为两次：先查中间表，再查关联表），不会按行逐条查询。
+// 关联键超过 DBWithPreloadBatchSize 设置的个数时分成多条 IN 查询，结果合并之后再赋值。
+// Get 和 GetMulti 支持预加载，Iter 不支持。
+func (s *Selector[T]) Preload(relations ...string) *Selector[T] {
+	s.preloads = append(s.preloads, relations...)
+	return s
+}
+
+// defaultPreloadBatchSize 预加载时每条 IN 查询默认最多包含的键的个数，
+// 避免语句过长或者超过数据库对参数个数的限制
+const defaultPreloadBatchSize = 1000
+
+// DBWithPreloadBatchSize 设置预加载时每条 IN 查询最多包含的键的个数，默认为 1000
+func DBWithPreloadBatchSize(size int) DBOption {
+	return func(db *DB) {
+		db.preloadBatchSize = size
+	}
+}
+
+// preloadBatches 按照 c.preloadBatchSize 将 keys 分批
+func preloadBatches(c core, keys []any) [][]any {
+	size := c.preloadBatchSize
+	if size <= 0 {
+		size = defaultPreloadBatchSize
+	}
+	res := make([][]any, 0, (len(keys)+size-1)/size)
+	for len(keys) > size {
+		res = append(res, keys[:size:size])
+		keys = keys[size:]
+	}
+	return append(res, keys)
+}
+
+// preload 为 owners（均为指向 ownerModel 对应结构体的指针）加载 names 指定的关联字段
+func preload(ctx context.Context, session Session, c core, ownerModel *model.Model, owners []any, names []string) error {
+	if len(owners) == 0 {
+		return nil
+	}
+	for _, name := range names {
+		rel, ok := ownerModel.Relations[name]
+		if !ok {
+			return errs.NewErrUnknownRelation(name)
+		}
+		if err := preloadRelation(ctx, session, c, ownerModel, rel, owners); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func preloadRelation(ctx context.Context, session Session, c core,
+	ownerModel *model.Model, rel *model.Relation, owners []any) error {
+	targetModel, err := c.r.Get(reflect.New(rel.Elem).Interface())
+	if err != nil {
+		return err
+	}
+	if _, ok := targetModel.FieldMap[rel.TargetKey]; !ok {
+		return errs.NewErrUnknownField(rel.TargetKey)
+	}
+
+	// 收集当前模型上的关联键，去重后作为 IN 的参数
+	ownerKeys := make([]string, len(owners))
+	keys := make([]any, 0, len(owners))
+	seen := make(map[string]struct{}, len(owners))
+	for idx, owner := range owners {
+		v, err := c.valCreator(owner, ownerModel).GetFieldValue(rel.OwnerKey)
+		if err != nil {
+			return err
+		}
+		k, ok := relationKey(v)
+		if !ok {
+			continue
+		}
+		ownerKeys[idx] = k
+		if _, ok = seen[k]; !ok {
+			seen[k] = struct{}{}
+			keys = append(keys, v)
+		}
+	}
+
+	// groups key: 当前模型上的关联键
+	groups := make(map[string][]reflect.Value, len(keys))
+	if len(keys) > 0 {
+		switch rel.Type {
+		case model.ManyToMany:
+			groups, err = loadManyToMany(ctx, session, c, rel, targetModel, keys)
+		default:
+			groups, err = loadByKeys(ctx, session, c, rel, targetModel, rel.TargetKey, keys)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for idx, owner := range owners {
+		var targets []reflect.Value
+		if ownerKeys[idx] != "" {
+			targets = groups[ownerKeys[idx]]
+		}
+		if err = c.valCreator(owner, ownerModel).SetRelationValue(rel.GoName, relationValue(rel, targets).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadByKeys 查询 targetModel 中 field IN keys 的行，并按 field 的值分组
+func loadByKeys(ctx context.Context, session Session, c core, rel *model.Relation,
+	targetModel *model.Model, field string, keys []any) (map[string][]reflect.Value, error) {
+	targets, err := loadTargets(ctx, session, c, rel, targetModel, field, keys)
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[string][]reflect.Value, len(keys))
+	for _, target := range targets {
+		v, err := c.valCreator(target.Interface(), targetModel).GetFieldValue(field)
+		if err != nil {
+			return nil, err
+		}
+		if k, ok := relationKey(v); ok {
+			groups[k] = append(groups[k], target)
+		}
+	}
+	return groups, nil
+}
+
+// loadManyToMany 先从中间表查出 (当前模型的键, 关联模型的键)，再批量查询关联模型
+func loadManyToMany(ctx context.Context, session Session, c core, rel *model.Relation,
+	targetModel *model.Model, keys []any) (map[string][]reflect.Value, error) {
+	var pairs [][2]any
+	for _, batch := range preloadBatches(c, keys) {
+		batchPairs, err := loadJoinPairs(ctx, session, c, rel, batch)
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, batchPairs...)
+	}
+
+	targetKeys := make([]any, 0, len(pairs))
+	seen := make(map[string]struct{}, len(pairs))
+	for _, pair := range pairs {
+		if k, ok := relationKey(pair[1]); ok {
+			if _, ok = seen[k]; !ok {
+				seen[k] = struct{}{}
+				targetKeys = append(targetKeys, pair[1])
+			}
+		}
+	}
+	groups := make(map[string][]reflect.Value, len(keys))
+	if len(targetKeys) == 0 {
+		return groups, nil
+	}
+	byTarget, err := loadByKeys(ctx, session, c, rel, targetModel, rel.TargetKey, targetKeys)
+	if err != nil {
+		return nil, err
+	}
+	for _, pair := range pairs {
+		ownerKey, ok := relationKey(pair[0])
+		if !ok {
+			continue
+		}
+		targetKey, ok := relationKey(pair[1])
+		if !ok {
+			continue
+		}
+		groups[ownerKey] = append(groups[ownerKey], byTarget[targetKey]...)
+	}
+	return groups, nil
+}
+
+// loadJoinPairs 查询中间表中当前模型的键 IN keys 的 (当前模型的键, 关联模型的键)
+func loadJoinPairs(ctx context.Context, session Session, c core, rel *model.Relation, keys []any) ([][2]any, error) {
+	pq := newPreloadQuery(c, nil, rel.JoinTable, rel.JoinOwnerColumn, keys)
+	pq.columns = []string{rel.JoinOwnerColumn, rel.JoinTargetColumn}
+	res := query(ctx, c, session, &QueryContext{
+		Type:         "SELECT",
+		QueryBuilder: pq,
+	}, func(ctx context.Context, qc *QueryContext) *QueryResult {
+		q, err := qc.QueryBuilder.Build()
+		if err != nil {
+			return &QueryResult{Error: err}
+		}
+		rows, err := session.readQueryContext(ctx, q.SQL, q.Args...)
+		if err != nil {
+			return &QueryResult{Error: err}
+		}
+		defer rows.Close()
+		var pairs [][2]any
+		for rows.Next() {
+			var pair [2]any
+			if err = rows.Scan(&pair[0], &pair[1]); err != nil {
+				return &QueryResult{Error: err}
+			}
+			pairs = append(pairs, pair)
+		}
+		return &QueryResult{Result: pairs, Error: rows.Err()}
+	})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	pairs, ok := res.Result.([][2]any)
+	if !ok && res.Result != nil {
+		return nil, errs.NewErrUnexpectedResult(res.Result)
+	}
+	return pairs, nil
+}
+
+// loadTargets 查询关联模型中 field IN keys 的行，返回指向关联结构体的指针
+func loadTargets(ctx context.Context, session Session, c core, rel *model.Relation,
+	targetModel *model.Model, field string, keys []any) ([]reflect.Value, error) {
+	var res []reflect.Value
+	for _, batch := range preloadBatches(c, keys) {
+		targets, err := loadTargetBatch(ctx, session, c, rel, targetModel, field, batch)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, targets...)
+	}
+	return res, nil
+}
+
+func loadTargetBatch(ctx context.Context, session Session, c core, rel *model.Relation,
+	targetModel *model.Model, field string, keys []any) ([]reflect.Value, error) {
+	res := query(ctx, c, session, &QueryContext{
+		Type:         "SELECT",
+		QueryBuilder: newPreloadQuery(c, targetModel, targetModel.TableName, field, keys),
+		Model:        targetModel,
+	}, func(ctx context.Context, qc *QueryContext) *QueryResult {
+		q, err := qc.QueryBuilder.Build()
+		if err != nil {
+			return &QueryResult{Error: err}
+		}
+		rows, err := session.readQueryContext(ctx, q.SQL, q.Args...)
+		if err != nil {
+			return &QueryResult{Error: err}
+		}
+		defer rows.Close()
+		var targets []reflect.Value
+		for rows.Next() {
+			target := reflect.New(rel.Elem)
+			if err = c.valCreator(target.Interface(), targetModel).SetColumns(rows); err != nil {
+				return &QueryResult{Error: err}
+			}
+			targets = append(targets, target)
+		}
+		return &QueryResult{Result: targets, Error: rows.Err()}
+	})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	targets, ok := res.Result.([]reflect.Value)
+	if !ok && res.Result != nil {
+		return nil, errs.NewErrUnexpectedResult(res.Result)
+	}
+	return targets, nil
+}
+
+// relationValue 将查到的关联数据转换为关联字段的类型：
+// has_one/belongs_to 取第一个（没有时为 nil），has_many/many_to_many 转换为切片（没有时为空切片）
+func relationValue(rel *model.Relation, targets []reflect.Value) reflect.Value {
+	switch rel.Type {
+	case model.HasOne, model.BelongsTo:
+		if len(targets) == 0 {
+			return reflect.Zero(rel.FieldType)
+		}
+		return targets[0]
+	default:
+		res := reflect.MakeSlice(rel.FieldType, 0, len(targets))
+		isPtr := rel.FieldType.Elem().Kind() == reflect.Ptr
+		for _, target := range targets {
+			if isPtr {
+				res = reflect.Append(res, target)
+			} else {
+				res = reflect.Append(res, target.Elem())
+			}
+		}
+		return res
+	}
+}
+
+// relationKey 将关联键的值转换为可比较的字符串，用于匹配不同类型的相同值，
+// 例如 int64(1) 与驱动返回的 []byte("1")。值为 NULL 时返回 false
+func relationKey(val any) (string, bool) {
+	if v, ok := val.(driver.Valuer); ok {
+		dv, err := v.Value()
+		if err != nil {
+			return "", false
+		}
+		val = dv
+	}
+	rv := reflect.ValueOf(val)
+	for rv.IsValid() && rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return "", false
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return "", false
+	}
+	if b, ok := rv.Interface().([]byte); ok {
+		return string(b), true
+	}
+	return fmt.Sprint(rv.Interface()), true
+}
+
+// preloadQuery 预加载时使用的 SELECT ... WHERE key IN (...) 查询。
+// model 不为 nil 时 key 为 Go 字段名，并按模型处理软删除；
+// model 为 nil 时（多对多的中间表）key 与 columns 均为列名
+type preloadQuery struct {
+	builder
+	table   string
+	columns []string
+	key     string
+	keys    []any
+}
+
+func newPreloadQuery(c core, m *model.Model, table string, key string, keys []any) *preloadQuery {
+	c.model = m
+	return &preloadQuery{
+		builder: builder{
+			core:   c,
+			quoter: c.dialect.quoter(),
+		},
+		table: table,
+		key:   key,
+		keys:  keys,
+	}
+}
+
+func (p *preloadQuery) Build() (*Query, error) {
+	p.reset()
+	p.sqlStrBuilder.WriteString("SELECT ")
+	if len(p.columns) == 0 {
+		p.sqlStrBuilder.WriteByte('*')
+	}
+	for idx, col := range p.columns {
+		if idx > 0 {
+			p.sqlStrBuilder.WriteByte(',')
+		}
+		p.quote(col)
+	}
+	p.sqlStrBuilder.WriteString(" FROM ")
+	p.quote(p.table)
+
+	if p.model != nil {
+		if err := p.buildWhereWithSoftDelete([]Predicate{Col(p.key).In(p.keys)}); err != nil {
+			return nil, err
+		}
+	} else {
+		p.sqlStrBuilder.WriteString(" WHERE ")
+		p.quote(p.key)
+		p.sqlStrBuilder.WriteString(" IN ")
+		if err := p.buildValues(valuesExpression{vals: p.keys}); err != nil {
+			return nil, err
+		}
+	}
+
+	p.sqlStrBuilder.WriteByte(';')
+	return p.buildQuery(), nil
+}
@@ -0,0 +1,252 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type PreloadUser struct {
+	Id      int64
+	Name    string
+	Profile *PreloadProfile `orm:"has_one(foreign_key=UserId)"`
+	Orders  []*PreloadOrder `orm:"has_many(foreign_key=UserId)"`
+}
+
+type PreloadProfile struct {
+	Id     int64
+	UserId int64
+	Bio    string
+}
+
+type PreloadOrder struct {
+	Id     int64
+	UserId int64
+	User   *PreloadUser  `orm:"belongs_to()"`
+	Items  []PreloadItem `orm:"has_many(foreign_key=OrderId)"`
+	Tags   []*PreloadTag `orm:"many_to_many(join_table=order_tag,join_foreign_key=order_id,join_references=tag_id)"`
+}
+
+type PreloadItem struct {
+	Id      int64
+	OrderId int64
+	Name    string
+}
+
+type PreloadTag struct {
+	Id   int64
+	Name string
+}
+
+func TestSelector_Preload(t *testing.T) {
+	testCases := []struct {
+		name    string
+		opts    []DBOption
+		mock    func(mock sqlmock.Sqlmock)
+		query   func(db *DB) (any, error)
+		wantVal any
+		wantErr error
+	}{
+		{
+			name: "has many and has one",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM `preload_user`;").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+						AddRow(1, "Tom").AddRow(2, "Jerry").AddRow(3, "Spike"))
+				mock.ExpectQuery("SELECT * FROM `preload_profile` WHERE `user_id` IN (?,?,?);").
+					WithArgs(int64(1), int64(2), int64(3)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "bio"}).
+						AddRow(10, 2, "mouse"))
+				mock.ExpectQuery("SELECT * FROM `preload_order` WHERE `user_id` IN (?,?,?);").
+					WithArgs(int64(1), int64(2), int64(3)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).
+						AddRow(100, 1).AddRow(101, 2).AddRow(102, 1))
+			},
+			query: func(db *DB) (any, error) {
+				return NewSelector[PreloadUser](db).Preload("Profile", "Orders").GetMulti(context.Background())
+			},
+			wantVal: []*PreloadUser{
+				{Id: 1, Name: "Tom", Orders: []*PreloadOrder{{Id: 100, UserId: 1}, {Id: 102, UserId: 1}}},
+				{Id: 2, Name: "Jerry", Profile: &PreloadProfile{Id: 10, UserId: 2, Bio: "mouse"},
+					Orders: []*PreloadOrder{{Id: 101, UserId: 2}}},
+				{Id: 3, Name: "Spike", Orders: []*PreloadOrder{}},
+			},
+		},
+		{
+			name: "belongs to dedup keys with reflect valuer",
+			opts: []DBOption{DBUseReflect()},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM `preload_order`;").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).
+						AddRow(100, 1).AddRow(101, 2).AddRow(102, 1))
+				mock.ExpectQuery("SELECT * FROM `preload_user` WHERE `id` IN (?,?);").
+					WithArgs(int64(1), int64(2)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+						AddRow([]byte("1"), "Tom").AddRow([]byte("2"), "Jerry"))
+			},
+			query: func(db *DB) (any, error) {
+				return NewSelector[PreloadOrder](db).Preload("User").GetMulti(context.Background())
+			},
+			wantVal: []*PreloadOrder{
+				{Id: 100, UserId: 1, User: &PreloadUser{Id: 1, Name: "Tom"}},
+				{Id: 101, UserId: 2, User: &PreloadUser{Id: 2, Name: "Jerry"}},
+				{Id: 102, UserId: 1, User: &PreloadUser{Id: 1, Name: "Tom"}},
+			},
+		},
+		{
+			name: "get with has many of struct values and many to many",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM `preload_order` WHERE `id` = ?;").
+					WithArgs(100).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow(100, 1))
+				mock.ExpectQuery("SELECT * FROM `preload_item` WHERE `order_id` IN (?);").
+					WithArgs(int64(100)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "name"}).
+						AddRow(1, 100, "apple").AddRow(2, 100, "banana"))
+				mock.ExpectQuery("SELECT `order_id`,`tag_id` FROM `order_tag` WHERE `order_id` IN (?);").
+					WithArgs(int64(100)).
+					WillReturnRows(sqlmock.NewRows([]string{"order_id", "tag_id"}).
+						AddRow([]byte("100"), []byte("7")).AddRow([]byte("100"), []byte("8")))
+				mock.ExpectQuery("SELECT * FROM `preload_tag` WHERE `id` IN (?,?);").
+					WithArgs([]byte("7"), []byte("8")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+						AddRow(8, "fresh").AddRow(7, "fruit"))
+			},
+			query: func(db *DB) (any, error) {
+				return NewSelector[PreloadOrder](db).Where(Col("Id").EQ(100)).
+					Preload("Items", "Tags").Get(context.Background())
+			},
+			wantVal: &PreloadOrder{
+				Id:     100,
+				UserId: 1,
+				Items:  []PreloadItem{{Id: 1, OrderId: 100, Name: "apple"}, {Id: 2, OrderId: 100, Name: "banana"}},
+				Tags:   []*PreloadTag{{Id: 7, Name: "fruit"}, {Id: 8, Name: "fresh"}},
+			},
+		},
+		{
+			name: "batch keys",
+			opts: []DBOption{DBWithPreloadBatchSize(2)},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM `preload_user`;").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+						AddRow(1, "Tom").AddRow(2, "Jerry").AddRow(3, "Spike"))
+				mock.ExpectQuery("SELECT * FROM `preload_order` WHERE `user_id` IN (?,?);").
+					WithArgs(int64(1), int64(2)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).
+						AddRow(100, 1).AddRow(101, 2))
+				mock.ExpectQuery("SELECT * FROM `preload_order` WHERE `user_id` IN (?);").
+					WithArgs(int64(3)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).
+						AddRow(102, 3).AddRow(103, 3))
+			},
+			query: func(db *DB) (any, error) {
+				return NewSelector[PreloadUser](db).Preload("Orders").GetMulti(context.Background())
+			},
+			wantVal: []*PreloadUser{
+				{Id: 1, Name: "Tom", Orders: []*PreloadOrder{{Id: 100, UserId: 1}}},
+				{Id: 2, Name: "Jerry", Orders: []*PreloadOrder{{Id: 101, UserId: 2}}},
+				{Id: 3, Name: "Spike", Orders: []*PreloadOrder{{Id: 102, UserId: 3}, {Id: 103, UserId: 3}}},
+			},
+		},
+		{
+			name: "batch keys of many to many",
+			opts: []DBOption{DBWithPreloadBatchSize(1)},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM `preload_order`;").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).
+						AddRow(100, 1).AddRow(101, 1))
+				mock.ExpectQuery("SELECT `order_id`,`tag_id` FROM `order_tag` WHERE `order_id` IN (?);").
+					WithArgs(int64(100)).
+					WillReturnRows(sqlmock.NewRows([]string{"order_id", "tag_id"}).AddRow(100, 7))
+				mock.ExpectQuery("SELECT `order_id`,`tag_id` FROM `order_tag` WHERE `order_id` IN (?);").
+					WithArgs(int64(101)).
+					WillReturnRows(sqlmock.NewRows([]string{"order_id", "tag_id"}).
+						AddRow(101, 7).AddRow(101, 8))
+				mock.ExpectQuery("SELECT * FROM `preload_tag` WHERE `id` IN (?);").
+					WithArgs(int64(7)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(7, "fruit"))
+				mock.ExpectQuery("SELECT * FROM `preload_tag` WHERE `id` IN (?);").
+					WithArgs(int64(8)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(8, "fresh"))
+			},
+			query: func(db *DB) (any, error) {
+				return NewSelector[PreloadOrder](db).Preload("Tags").GetMulti(context.Background())
+			},
+			wantVal: []*PreloadOrder{
+				{Id: 100, UserId: 1, Tags: []*PreloadTag{{Id: 7, Name: "fruit"}}},
+				{Id: 101, UserId: 1, Tags: []*PreloadTag{{Id: 7, Name: "fruit"}, {Id: 8, Name: "fresh"}}},
+			},
+		},
+		{
+			name: "unknown relation",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM `preload_user`;").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Tom"))
+			},
+			query: func(db *DB) (any, error) {
+				return NewSelector[PreloadUser](db).Preload("Invalid").GetMulti(context.Background())
+			},
+			wantErr: errs.NewErrUnknownRelation("Invalid"),
+		},
+		{
+			name: "no rows skips preload",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM `preload_user`;").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+			},
+			query: func(db *DB) (any, error) {
+				return NewSelector[PreloadUser](db).Preload("Orders").GetMulti(context.Background())
+			},
+			wantVal: []*PreloadUser{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+			db, err := OpenDB(mockDB, tc.opts...)
+			require.NoError(t, err)
+			tc.mock(mock)
+
+			res, err := tc.query(db)
+			assert.Equal(t, tc.wantErr, err)
+			if err == nil {
+				assert.Equal(t, tc.wantVal, res)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPreloadBatches(t *testing.T) {
+	keys := make([]any, 2500)
+	var sizes []int
+	for _, batch := range preloadBatches(core{}, keys) {
+		sizes = append(sizes, len(batch))
+	}
+	// 默认每批 1000 个键
+	assert.Equal(t, []int{1000, 1000, 500}, sizes)
+
+	batches := preloadBatches(core{preloadBatchSize: 2}, []any{1, 2, 3, 4})
+	assert.Equal(t, [][]any{{1, 2}, {3, 4}}, batches)
+}
+
+// TestInserter_IgnoresRelationFields 验证关联字段不参与 INSERT
+func TestInserter_IgnoresRelationFields(t *testing.T) {
+	mockDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	q, err := NewInserter[PreloadOrder](db).Values(&PreloadOrder{Id: 1, UserId: 2}).Build()
+	require.NoError(t, err)
+	assert.Equal(t, &Query{
+		SQL:  "INSERT INTO `preload_order`(`id`,`user_id`) VALUES (?,?);",
+		Args: []any{int64(1), int64(2)},
+	}, q)
+}
@@ -0,0 +1,227 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Replica 只读副本。副本由健康检查维护可用状态，并记录查询与 Ping 的平均耗时
+type Replica struct {
+	db      *sql.DB
+	healthy atomic.Bool
+	// latency 耗时的指数加权移动平均值，单位纳秒
+	latency atomic.Int64
+}
+
+func newReplica(db *sql.DB) *Replica {
+	res := &Replica{db: db}
+	res.healthy.Store(true)
+	return res
+}
+
+func (r *Replica) DB() *sql.DB {
+	return r.db
+}
+
+// Latency 查询与 Ping 的平均耗时，还没有样本时为 0
+func (r *Replica) Latency() time.Duration {
+	return time.Duration(r.latency.Load())
+}
+
+// observe 记录一次耗时，新样本的权重为 1/5
+func (r *Replica) observe(d time.Duration) {
+	old := r.latency.Load()
+	if old == 0 {
+		r.latency.Store(int64(d))
+		return
+	}
+	r.latency.Store(old + (int64(d)-old)/5)
+}
+
+// ReplicaPolicy 副本的选择策略，replicas 只包含健康的副本且不为空
+type ReplicaPolicy interface {
+	Select(replicas []*Replica) *Replica
+}
+
+// ReplicaRoundRobin 轮询
+func ReplicaRoundRobin() ReplicaPolicy {
+	return &roundRobinPolicy{}
+}
+
+// ReplicaRandom 随机
+func ReplicaRandom() ReplicaPolicy {
+	return randomPolicy{}
+}
+
+// ReplicaLeastLatency 选择平均耗时最小的副本，没有耗时样本的副本优先
+func ReplicaLeastLatency() ReplicaPolicy {
+	return leastLatencyPolicy{}
+}
+
+type roundRobinPolicy struct {
+	cnt atomic.Uint64
+}
+
+func (p *roundRobinPolicy) Select(replicas []*Replica) *Replica {
+	idx := p.cnt.Add(1) - 1
+	return replicas[idx%uint64(len(replicas))]
+}
+
+type randomPolicy struct{}
+
+func (p randomPolicy) Select(replicas []*Replica) *Replica {
+	return replicas[rand.IntN(len(replicas))]
+}
+
+type leastLatencyPolicy struct{}
+
+func (p leastLatencyPolicy) Select(replicas []*Replica) *Replica {
+	res := replicas[0]
+	for _, r := range replicas[1:] {
+		if r.Latency() < res.Latency() {
+			res = r
+		}
+	}
+	return res
+}
+
+// replicaGroup 管理所有副本，并按策略从健康的副本中选择一个
+type replicaGroup struct {
+	replicas []*Replica
+	policy   ReplicaPolicy
+
+	// 健康检查
+	interval time.Duration
+	timeout  time.Duration
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// pick 没有健康的副本时返回 nil，调用方回退到主库
+func (g *replicaGroup) pick() *Replica {
+	healthy := make([]*Replica, 0, len(g.replicas))
+	for _, r := range g.replicas {
+		if r.healthy.Load() {
+			healthy = append(healthy, r)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+	return g.policy.Select(healthy)
+}
+
+func (g *replicaGroup) queryContext(ctx context.Context, r *Replica, query string, args ...any) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err == nil {
+		r.observe(time.Since(start))
+	}
+	return rows, err
+}
+
+// checkHealth Ping 所有副本，失败的副本被剔除，恢复之后重新加入
+func (g *replicaGroup) checkHealth() {
+	for _, r := range g.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+		start := time.Now()
+		err := r.db.PingContext(ctx)
+		cancel()
+		if err != nil {
+			r.healthy.Store(false)
+			continue
+		}
+		r.observe(time.Since(start))
+		r.healthy.Store(true)
+	}
+}
+
+func (g *replicaGroup) startHealthCheck() {
+	if g.interval <= 0 {
+		return
+	}
+	g.stop = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(g.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				g.checkHealth()
+			case <-g.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (g *replicaGroup) close() error {
+	g.stopOnce.Do(func() {
+		if g.stop != nil {
+			close(g.stop)
+		}
+	})
+	var err error
+	for _, r := range g.replicas {
+		if e := r.db.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// DBWithReplicas 设置只读副本与选择策略。Selector 的查询默认发往副本，以下情况使用主库：
+//   - 在事务中执行
+//   - ctx 经过 UsePrimary 处理
+//   - 所有副本都被健康检查剔除
+//
+// Inserter/Updater/Deleter、Migrator 与 Raw 总是使用主库
+func DBWithReplicas(policy ReplicaPolicy, replicas ...*sql.DB) DBOption {
+	return func(db *DB) {
+		if policy == nil {
+			policy = ReplicaRoundRobin()
+		}
+		group := &replicaGroup{
+			replicas: make([]*Replica, 0, len(replicas)),
+			policy:   policy,
+		}
+		if db.replicas != nil {
+			group.interval, group.timeout = db.replicas.interval, db.replicas.timeout
+		}
+		for _, r := range replicas {
+			group.replicas = append(group.replicas, newReplica(r))
+		}
+		db.replicas = group
+	}
+}
+
+// DBWithHealthCheck 每隔 interval 对副本执行一次 Ping，超时时间为 timeout，
+// Ping 失败的副本不再接收查询，直到再次 Ping 成功。需要与 DBWithReplicas 一起使用。
+// timeout 小于等于 0 时使用 interval/2，否则所有的 Ping 都会立刻超时，副本全部被剔除
+func DBWithHealthCheck(interval time.Duration, timeout time.Duration) DBOption {
+	return func(db *DB) {
+		if db.replicas == nil {
+			db.replicas = &replicaGroup{}
+		}
+		if timeout <= 0 {
+			timeout = interval / 2
+		}
+		db.replicas.interval, db.replicas.timeout = interval, timeout
+	}
+}
+
+type primaryKey struct{}
+
+// UsePrimary 强制使用该 ctx 的查询发往主库，例如写入之后需要立刻读到最新数据
+func UsePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func usePrimary(ctx context.Context) bool {
+	v, _ := ctx.Value(primaryKey{}).(bool)
+	return v
+}
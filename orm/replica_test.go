@@ -0,0 +1,121 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReplicaMock(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual),
+		sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	return db, mock
+}
+
+func TestDB_Replicas(t *testing.T) {
+	primaryDB, primary := newReplicaMock(t)
+	replicaDB1, replica1 := newReplicaMock(t)
+	replicaDB2, replica2 := newReplicaMock(t)
+	db, err := OpenDB(primaryDB, DBWithReplicas(ReplicaRoundRobin(), replicaDB1, replicaDB2))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// 轮询两个副本
+	replica1.ExpectQuery("SELECT * FROM `test_model`;").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	replica2.ExpectQuery("SELECT * FROM `test_model`;").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	for _, id := range []int64{1, 2} {
+		res, err := NewSelector[TestModel](db).Get(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, id, res.Id)
+	}
+
+	// 写操作、UsePrimary 与事务中的查询使用主库
+	primary.ExpectExec("DELETE FROM `test_model`;").WillReturnResult(sqlmock.NewResult(0, 1))
+	primary.ExpectQuery("SELECT * FROM `test_model`;").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	primary.ExpectBegin()
+	primary.ExpectQuery("SELECT * FROM `test_model`;").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	primary.ExpectCommit()
+
+	require.NoError(t, NewDeleter[TestModel](db).Exec(ctx).Err())
+	res, err := NewSelector[TestModel](db).Get(UsePrimary(ctx))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), res.Id)
+	err = db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+		res, err := NewSelector[TestModel](db).Get(ctx)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, int64(4), res.Id)
+		return nil
+	}, nil)
+	require.NoError(t, err)
+
+	// 副本 1 Ping 失败被剔除，查询都发往副本 2；所有副本都不可用时回退到主库
+	replica1.ExpectPing().WillReturnError(errors.New("mock error"))
+	replica2.ExpectPing()
+	db.replicas.timeout = time.Second
+	db.replicas.checkHealth()
+	replica2.ExpectQuery("SELECT * FROM `test_model`;").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	replica2.ExpectQuery("SELECT * FROM `test_model`;").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(6))
+	for _, id := range []int64{5, 6} {
+		res, err = NewSelector[TestModel](db).Get(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, id, res.Id)
+	}
+
+	replica1.ExpectPing().WillReturnError(errors.New("mock error"))
+	replica2.ExpectPing().WillReturnError(errors.New("mock error"))
+	db.replicas.checkHealth()
+	primary.ExpectQuery("SELECT * FROM `test_model`;").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	res, err = NewSelector[TestModel](db).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), res.Id)
+
+	for _, mock := range []sqlmock.Sqlmock{primary, replica1, replica2} {
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
+
+func TestDBWithHealthCheck(t *testing.T) {
+	primaryDB, _ := newReplicaMock(t)
+	replicaDB, replica := newReplicaMock(t)
+	// interval 足够长，只由测试调用 checkHealth
+	db, err := OpenDB(primaryDB, DBWithReplicas(nil, replicaDB), DBWithHealthCheck(time.Hour, 0))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	assert.Equal(t, 30*time.Minute, db.replicas.timeout)
+
+	// timeout 为 0 时 Ping 成功的副本不会被剔除
+	replica.ExpectPing()
+	db.replicas.checkHealth()
+	assert.True(t, db.replicas.replicas[0].healthy.Load())
+	assert.NoError(t, replica.ExpectationsWereMet())
+}
+
+func TestReplicaLeastLatency(t *testing.T) {
+	r1, r2, r3 := newReplica(nil), newReplica(nil), newReplica(nil)
+	r1.observe(30 * time.Millisecond)
+	r2.observe(10 * time.Millisecond)
+	r3.observe(20 * time.Millisecond)
+	policy := ReplicaLeastLatency()
+	assert.Equal(t, r2, policy.Select([]*Replica{r1, r2, r3}))
+
+	// 新样本按 1/5 的权重计入平均值：10 + (110-10)/5 = 30
+	r2.observe(110 * time.Millisecond)
+	assert.Equal(t, 30*time.Millisecond, r2.Latency())
+	assert.Equal(t, r3, policy.Select([]*Replica{r1, r2, r3}))
+}
@@ -0,0 +1,71 @@
+package orm
+
+import (
+	"Soil/orm/internal/model"
+	"Soil/orm/internal/valuer"
+	"context"
+	"database/sql"
+)
+
+// Rows 是 Selector.Iter 返回的流式结果集，用法与 *sql.Rows 类似：
+//
+//	rows, err := NewSelector[User](db).Iter(ctx)
+//	if err != nil { ... }
+//	defer rows.Close()
+//	for rows.Next() {
+//		u := rows.Value()
+//	}
+//	if err = rows.Err(); err != nil { ... }
+type Rows[T any] struct {
+	ctx        context.Context
+	rows       *sql.Rows
+	valCreator valuer.Creator
+	model      *model.Model
+
+	cur *T
+	err error
+}
+
+// Next 扫描下一行到一个新的 T 实例中并调用 AfterQuery 钩子，
+// 没有更多数据或者发生错误时返回 false，错误通过 Err 获取
+func (r *Rows[T]) Next() bool {
+	if r.err != nil || !r.rows.Next() {
+		return false
+	}
+	r.cur, r.err = scanRow[T](r.ctx, r.rows, r.valCreator, r.model)
+	if r.err != nil {
+		_ = r.rows.Close()
+		return false
+	}
+	return true
+}
+
+// Value 返回 Next 扫描得到的当前行
+func (r *Rows[T]) Value() *T {
+	return r.cur
+}
+
+func (r *Rows[T]) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.rows.Err()
+}
+
+func (r *Rows[T]) Close() error {
+	return r.rows.Close()
+}
+
+// scanRow 将 rows 的当前行扫描到一个新的 T 实例中，并在成功后调用 AfterQuery 钩子
+func scanRow[T any](ctx context.Context, rows *sql.Rows, creator valuer.Creator, meta *model.Model) (*T, error) {
+	val := new(T)
+	if err := creator(val, meta).SetColumns(rows); err != nil {
+		return nil, err
+	}
+	if h, ok := any(val).(AfterQuery); ok {
+		if err := h.AfterQuery(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return val, nil
+}
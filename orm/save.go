@@ -0,0 +1,36 @@
+package orm
+
+import (
+	"context"
+)
+
+// Save 主键没有设置（任意一列主键是零值）时插入 val，数据库生成的自增主键会写回 val；
+// 否则按主键更新 val 的所有字段，模型有版本字段时版本号不匹配返回 ErrOptimisticLock。
+// 是否插入只取决于主键是否为零值，主键由调用方生成的模型插入时应当使用 Inserter
+func Save[T any](ctx context.Context, session Session, val *T) Result {
+	c := session.getCore()
+	m, err := c.r.Get(val)
+	if err != nil {
+		return Result{err: err}
+	}
+	pks, err := primaryKeys(m)
+	if err != nil {
+		return Result{err: err}
+	}
+	for _, pk := range pks {
+		if isFieldZero(val, pk) {
+			return NewInserter[T](session).Values(val).Exec(ctx)
+		}
+	}
+
+	key := make([]any, 0, len(pks))
+	valDealer := c.valCreator(val, m)
+	for _, pk := range pks {
+		v, err := valDealer.GetFieldValue(pk.GoName)
+		if err != nil {
+			return Result{err: err}
+		}
+		key = append(key, v)
+	}
+	return NewUpdater[T](session).Update(val).Where(pkRow(pks, key)).Exec(ctx)
+}
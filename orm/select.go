@@ -3,51 +3,91 @@ package orm
 import (
 	"Soil/orm/internal/errs"
 	"context"
+	"database/sql"
+	"io"
 )
 
 type Selector[T any] struct {
 	builder
-	table   TableReference
-	where   []Predicate
+	table TableReference
+	where []Predicate
+	// pk ByPK 传入的主键值，与 where 同时生效
+	pk      []any
 	columns []Selectable
 	groupBy []Column
 	having  []Predicate
 	orderBy []OrderBy
 	offset  int
 	limit   int
-
-	session Session
+	// distinct SELECT DISTINCT
+	distinct bool
+	unions   []union
+	lock     rowLock
+	// lockInTx 执行时 ctx 中有 session 开启的事务，由 checkLock 设置
+	lockInTx bool
+
+	preloads []string
+	session  Session
 }
 
 // Build 生成sql语句和获得参数
 func (s *Selector[T]) Build() (*Query, error) {
+	if err := s.build(); err != nil {
+		return nil, err
+	}
+	return s.buildQuery(), nil
+}
+
+// buildSubquery 作为子查询嵌入外层语句时使用，占位符留给外层语句统一改写
+func (s *Selector[T]) buildSubquery() (*Query, error) {
+	if err := s.build(); err != nil {
+		return nil, err
+	}
+	return &Query{
+		SQL:  s.sqlStrBuilder.String(),
+		Args: s.args,
+	}, nil
+}
+
+func (s *Selector[T]) build() error {
 	var (
 		t   T
 		err error
 	)
+	s.reset()
 
 	s.model, err = s.r.Get(&t)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	where, err := s.predicates()
+	if err != nil {
+		return err
+	}
+	if err = s.resolveShard(where); err != nil {
+		return err
 	}
 
 	s.sqlStrBuilder.WriteString("SELECT ")
+	if s.distinct {
+		s.sqlStrBuilder.WriteString("DISTINCT ")
+	}
 
 	// 处理SELECT后面跟着的列
 	if err = s.buildColumns(); err != nil {
-		return nil, err
+		return err
 	}
 
 	// 处理from
 	s.sqlStrBuilder.WriteString(" FROM ")
 	err = s.buildTable(s.table)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// 处理where之后的条件（含软删除过滤）
-	if err = s.buildWhereWithSoftDelete(s.where); err != nil {
-		return nil, err
+	if err = s.buildWhereWithSoftDelete(where); err != nil {
+		return err
 	}
 
 	// 处理GroupBy数据
@@ -58,7 +98,7 @@ func (s *Selector[T]) Build() (*Query, error) {
 				s.sqlStrBuilder.WriteByte(',')
 			}
 			if err = s.buildColumn(groupCol); err != nil {
-				return nil, err
+				return err
 			}
 		}
 	}
@@ -67,24 +107,32 @@ func (s *Selector[T]) Build() (*Query, error) {
 	if len(s.having) > 0 {
 		s.sqlStrBuilder.WriteString(" HAVING ")
 		if err = s.buildPredicates(s.having); err != nil {
-			return nil, err
+			return err
+		}
+	}
+
+	// 处理UNION，之后的 ORDER BY、LIMIT 和 OFFSET 作用于合并之后的结果
+	for _, u := range s.unions {
+		s.sqlStrBuilder.WriteString(" " + u.typ + " ")
+		if err = s.embedQuery(u.q); err != nil {
+			return err
 		}
 	}
 
 	// 处理Order BY
 	if len(s.orderBy) > 0 {
 		s.sqlStrBuilder.WriteString(" ORDER BY ")
-		if err = s.buildOrderBy(); err != nil {
-			return nil, err
+		if err = s.buildOrderBy(s.orderBy); err != nil {
+			return err
 		}
 	}
 
 	if s.limit > 0 {
 		s.sqlStrBuilder.WriteString(" LIMIT ?")
 		s.args = append(s.args, s.limit)
-	} else if s.offset > 0 {
-		// MySQL 不允许 OFFSET 不带 LIMIT，这里补充一个极大值 LIMIT 表示无限制
-		s.sqlStrBuilder.WriteString(" LIMIT 18446744073709551615")
+	} else if lim := s.dialect.unboundedLimit(); s.offset > 0 && lim != "" {
+		// 部分方言（例如 MySQL）不允许 OFFSET 不带 LIMIT，由方言补充一个表示无限制的 LIMIT
+		s.sqlStrBuilder.WriteString(" LIMIT " + lim)
 	}
 
 	if s.offset > 0 {
@@ -92,11 +140,12 @@ func (s *Selector[T]) Build() (*Query, error) {
 		s.args = append(s.args, s.offset)
 	}
 
+	if err = s.buildLock(); err != nil {
+		return err
+	}
+
 	s.sqlStrBuilder.WriteByte(';')
-	return &Query{
-		SQL:  s.sqlStrBuilder.String(),
-		Args: s.args,
-	}, nil
+	return nil
 }
 
 func (s *Selector[T]) From(tbl TableReference) *Selector[T] {
@@ -111,6 +160,81 @@ func (s *Selector[T]) Where(p ...Predicate) *Selector[T] {
 	return s
 }
 
+// ByPK 按主键查询，e.g. NewSelector[User](db).ByPK(1).Get(ctx)。
+// 单列主键可以传入多个值，生成 WHERE id IN (...)；联合主键按主键字段的声明顺序传入一行的值，
+// 数量不一致时返回 ErrPrimaryKeyValues。与 Where 一起使用时两者同时生效
+func (s *Selector[T]) ByPK(vals ...any) *Selector[T] {
+	s.pk = vals
+	return s
+}
+
+// predicates 返回 WHERE 条件，包括 ByPK 生成的主键条件
+func (s *Selector[T]) predicates() ([]Predicate, error) {
+	if len(s.pk) == 0 {
+		return s.where, nil
+	}
+	pks, err := primaryKeys(s.model)
+	if err != nil {
+		return nil, err
+	}
+	var keys [][]any
+	if len(pks) == 1 {
+		for _, v := range s.pk {
+			keys = append(keys, []any{v})
+		}
+	} else {
+		if len(s.pk) != len(pks) {
+			return nil, errs.ErrPrimaryKeyValues
+		}
+		keys = [][]any{s.pk}
+	}
+	where := make([]Predicate, 0, len(s.where)+1)
+	where = append(where, pkPredicate(pks, keys))
+	return append(where, s.where...), nil
+}
+
+// WithTrashed 查询结果包含已经软删除的行
+func (s *Selector[T]) WithTrashed() *Selector[T] {
+	s.scope = scopeWithTrashed
+	return s
+}
+
+// OnlyTrashed 只查询已经软删除的行
+func (s *Selector[T]) OnlyTrashed() *Selector[T] {
+	s.scope = scopeOnlyTrashed
+	return s
+}
+
+// Distinct SELECT DISTINCT，去掉重复的行
+func (s *Selector[T]) Distinct() *Selector[T] {
+	s.distinct = true
+	return s
+}
+
+// union UNION 或者 UNION ALL 合并的查询
+type union struct {
+	typ string
+	q   QueryBuilder
+}
+
+// Union 使用 UNION 合并 qs 的结果并去重，e.g.
+// NewSelector[User](db).Where(...).Union(NewSelector[User](db).Where(...)).OrderBy(Asc("Id"))。
+// qs 中不能使用 ORDER BY、LIMIT 和 OFFSET，当前 Selector 的 ORDER BY、LIMIT 和 OFFSET 作用于合并之后的结果
+func (s *Selector[T]) Union(qs ...QueryBuilder) *Selector[T] {
+	for _, q := range qs {
+		s.unions = append(s.unions, union{typ: "UNION", q: q})
+	}
+	return s
+}
+
+// UnionAll 与 Union 相同，但是保留重复的行
+func (s *Selector[T]) UnionAll(qs ...QueryBuilder) *Selector[T] {
+	for _, q := range qs {
+		s.unions = append(s.unions, union{typ: "UNION ALL", q: q})
+	}
+	return s
+}
+
 // Select 参数传入结构体字段名
 func (s *Selector[T]) Select(cols ...Selectable) *Selector[T] {
 	s.columns = cols
@@ -124,22 +248,161 @@ func (s *Selector[T]) Get(ctx context.Context) (*T, error) {
 	if err != nil {
 		return nil, err
 	}
-	res := get[T](ctx, s.session, s.core, &QueryContext{
+	if err = s.checkLock(ctx); err != nil {
+		return nil, err
+	}
+	where, err := s.predicates()
+	if err != nil {
+		return nil, err
+	}
+	dsts, err := s.shardingDsts(where)
+	if err != nil {
+		return nil, err
+	}
+	var val *T
+	if dsts != nil && len(dsts) != 1 {
+		// 跨分片：合并所有分片的结果，与单表一样要求只有一行
+		vals, err := s.scatter(ctx, dsts)
+		if err != nil {
+			return nil, err
+		}
+		switch len(vals) {
+		case 0:
+			return nil, errs.ErrNoRows
+		case 1:
+			val = vals[0]
+		default:
+			return nil, errs.ErrTooManyRows
+		}
+	} else {
+		res := get[T](ctx, s.session, s.core, &QueryContext{
+			Type:         "SELECT",
+			QueryBuilder: s,
+			Model:        s.model,
+			Cacheable:    true,
+		})
+		if res.Error != nil || res.Result == nil {
+			return nil, res.Error
+		}
+		var ok bool
+		if val, ok = res.Result.(*T); !ok {
+			return nil, errs.NewErrUnexpectedResult(res.Result)
+		}
+	}
+	if len(s.preloads) > 0 {
+		if err = preload(ctx, s.session, s.core, s.model, []any{val}, s.preloads); err != nil {
+			return nil, err
+		}
+	}
+	return val, nil
+}
+
+// GetMulti 返回所有满足条件的行，没有数据时返回空切片而不是 ErrNoRows
+func (s *Selector[T]) GetMulti(ctx context.Context) ([]*T, error) {
+	var err error
+	s.model, err = s.r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkLock(ctx); err != nil {
+		return nil, err
+	}
+	where, err := s.predicates()
+	if err != nil {
+		return nil, err
+	}
+	dsts, err := s.shardingDsts(where)
+	if err != nil {
+		return nil, err
+	}
+	var vals []*T
+	if dsts != nil && len(dsts) != 1 {
+		vals, err = s.scatter(ctx, dsts)
+	} else {
+		vals, err = s.getMulti(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(s.preloads) > 0 {
+		owners := make([]any, 0, len(vals))
+		for _, val := range vals {
+			owners = append(owners, val)
+		}
+		if err = preload(ctx, s.session, s.core, s.model, owners, s.preloads); err != nil {
+			return nil, err
+		}
+	}
+	return vals, nil
+}
+
+// getMulti 执行查询，不处理预加载
+func (s *Selector[T]) getMulti(ctx context.Context) ([]*T, error) {
+	var err error
+	s.model, err = s.r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	res := query(ctx, s.core, s.session, &QueryContext{
 		Type:         "SELECT",
 		QueryBuilder: s,
 		Model:        s.model,
+		Cacheable:    true,
+		Multi:        true,
+	}, func(ctx context.Context, qc *QueryContext) *QueryResult {
+		return getMultiHandler[T](ctx, s.session, s.core, qc)
 	})
-	if res.Result != nil {
-		return res.Result.(*T), res.Error
+	if res.Error != nil {
+		return nil, res.Error
 	}
-	return nil, res.Error
+	vals, ok := res.Result.([]*T)
+	if !ok {
+		return nil, errs.NewErrUnexpectedResult(res.Result)
+	}
+	return vals, nil
 }
 
-func get[T any](ctx context.Context, session Session, c core, qc *QueryContext) *QueryResult {
-	var root Handler = func(ctx context.Context, queryCtx *QueryContext) *QueryResult {
-		return getHandler[T](ctx, session, c, qc)
+// Iter 以流式的方式读取结果集，每次调用 Rows.Next 只扫描一行，适合导出大表等无法一次性加载到内存的场景。
+// 调用方需要在使用完毕后调用 Rows.Close。
+func (s *Selector[T]) Iter(ctx context.Context) (*Rows[T], error) {
+	var err error
+	s.model, err = s.r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkLock(ctx); err != nil {
+		return nil, err
 	}
+	res := query(ctx, s.core, s.session, &QueryContext{
+		Type:         "SELECT",
+		QueryBuilder: s,
+		Model:        s.model,
+	}, func(ctx context.Context, qc *QueryContext) *QueryResult {
+		return iterHandler[T](ctx, s.session, qc)
+	})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	rows, ok := res.Result.(*sql.Rows)
+	if !ok {
+		// 中间件替换了结果，关闭它避免泄露连接
+		if c, ok := res.Result.(io.Closer); ok {
+			_ = c.Close()
+		}
+		return nil, errs.NewErrUnexpectedResult(res.Result)
+	}
+	return &Rows[T]{
+		ctx:        ctx,
+		rows:       rows,
+		valCreator: s.valCreator,
+		model:      s.model,
+	}, nil
+}
 
+// query 将中间件链起来，handler 是处理 SELECT 的最内层 Handler
+func query(ctx context.Context, c core, session Session, qc *QueryContext, handler Handler) *QueryResult {
+	qc.Tx = sessionTx(ctx, session)
+	root := handler
 	for i := len(c.middlewares) - 1; i >= 0; i-- {
 		root = c.middlewares[i](root)
 	}
@@ -147,6 +410,69 @@ func get[T any](ctx context.Context, session Session, c core, qc *QueryContext)
 	return root(ctx, qc)
 }
 
+// beforeQuery 在执行查询前对一个新建的 T 实例调用 BeforeQuery 钩子，用于 GetMulti 和 Iter
+func beforeQuery[T any](ctx context.Context) error {
+	if h, ok := any(new(T)).(BeforeQuery); ok {
+		return h.BeforeQuery(ctx)
+	}
+	return nil
+}
+
+func getMultiHandler[T any](ctx context.Context, session Session, c core, qc *QueryContext) *QueryResult {
+	if err := beforeQuery[T](ctx); err != nil {
+		return &QueryResult{Error: err}
+	}
+
+	q, err := qc.QueryBuilder.Build()
+	if err != nil {
+		return &QueryResult{Error: err}
+	}
+
+	rows, err := session.readQueryContext(ctx, q.SQL, q.Args...)
+	if err != nil {
+		return &QueryResult{Error: err}
+	}
+	defer rows.Close()
+
+	res := make([]*T, 0, 8)
+	for rows.Next() {
+		val, err := scanRow[T](ctx, rows, c.valCreator, c.model)
+		if err != nil {
+			return &QueryResult{Error: err}
+		}
+		res = append(res, val)
+	}
+	if err = rows.Err(); err != nil {
+		return &QueryResult{Error: err}
+	}
+
+	return &QueryResult{Result: res}
+}
+
+// iterHandler 只执行查询，结果集 *sql.Rows 交给 Rows 逐行扫描
+func iterHandler[T any](ctx context.Context, session Session, qc *QueryContext) *QueryResult {
+	if err := beforeQuery[T](ctx); err != nil {
+		return &QueryResult{Error: err}
+	}
+
+	q, err := qc.QueryBuilder.Build()
+	if err != nil {
+		return &QueryResult{Error: err}
+	}
+
+	rows, err := session.readQueryContext(ctx, q.SQL, q.Args...)
+	if err != nil {
+		return &QueryResult{Error: err}
+	}
+	return &QueryResult{Result: rows}
+}
+
+func get[T any](ctx context.Context, session Session, c core, qc *QueryContext) *QueryResult {
+	return query(ctx, c, session, qc, func(ctx context.Context, queryCtx *QueryContext) *QueryResult {
+		return getHandler[T](ctx, session, c, queryCtx)
+	})
+}
+
 func getHandler[T any](ctx context.Context, session Session, c core, qc *QueryContext) *QueryResult {
 	// 提前创建结果实例，便于在其上调用 BeforeQuery 钩子。
 	retVal := new(T)
@@ -164,7 +490,7 @@ func getHandler[T any](ctx context.Context, session Session, c core, qc *QueryCo
 	}
 
 	//执行sql查询语句,
-	rows, err := session.queryContext(ctx, query.SQL, query.Args...)
+	rows, err := session.readQueryContext(ctx, query.SQL, query.Args...)
 	if err != nil {
 		return &QueryResult{Error: err}
 	}
@@ -272,20 +598,6 @@ func (s *Selector[T]) OrderBy(OrderBys ...OrderBy) *Selector[T] {
 	return s
 }
 
-func (s *Selector[T]) buildOrderBy() error {
-	for idx, o := range s.orderBy {
-		if idx > 0 {
-			s.sqlStrBuilder.WriteByte(',')
-		}
-		if err := s.buildColumn(Col(o.col)); err != nil {
-			return err
-		}
-		s.sqlStrBuilder.WriteString(" " + o.order)
-	}
-
-	return nil
-}
-
 func (s *Selector[T]) Offset(offset int) *Selector[T] {
 	s.offset = offset
 	return s
@@ -310,59 +622,3 @@ func (s *Selector[T]) Paginate(page, size int) *Selector[T] {
 	s.offset = (page - 1) * size
 	return s
 }
-
-func (s *Selector[T]) buildTable(table TableReference) error {
-	switch typ := table.(type) {
-	case nil:
-		s.quote(s.model.TableName)
-	case Table:
-		meta, err := s.r.Get(typ.entity)
-		if err != nil {
-			return err
-		}
-		s.quote(meta.TableName)
-		if typ.alias != "" {
-			s.sqlStrBuilder.WriteString(" AS ")
-			s.quote(typ.alias)
-		}
-	case Join:
-		s.sqlStrBuilder.WriteByte('(')
-		// 处理左节点
-		if err := s.buildTable(typ.left); err != nil {
-			return err
-		}
-		// 处理操作
-		s.sqlStrBuilder.WriteString(" " + typ.typ + " ")
-		// 处理右节点
-		if err := s.buildTable(typ.right); err != nil {
-			return err
-		}
-		// 处理Using
-		if len(typ.using) > 0 {
-			s.sqlStrBuilder.WriteString(" USING (")
-			for idx, u := range typ.using {
-				if idx > 0 {
-					s.sqlStrBuilder.WriteString(", ")
-				}
-				if err := s.buildColumn(Col(u)); err != nil {
-					return err
-				}
-			}
-			s.sqlStrBuilder.WriteByte(')') // using的右括号
-		}
-
-		// 处理on部分
-		if len(typ.on) > 0 {
-			s.sqlStrBuilder.WriteString(" ON ")
-			if err := s.buildPredicates(typ.on); err != nil {
-				return err
-			}
-		}
-
-		s.sqlStrBuilder.WriteByte(')') //join的右括号
-	default:
-		return errs.NewErrUnsupportedTable(table)
-	}
-
-	return nil
-}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSelector_Build(t *testing.T) {
@@ -510,3 +511,193 @@ func TestQueryResult_Err(t *testing.T) {
 		}
 	})
 }
+
+func TestSelector_GetMulti(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mockRows *sqlmock.Rows
+		mockErr  error
+		wantVal  []*TestModel
+		wantErr  error
+	}{
+		{
+			name:    "query error",
+			mockErr: errors.New("invalid query"),
+			wantErr: errors.New("invalid query"),
+		},
+		{
+			name:     "no row",
+			mockRows: sqlmock.NewRows([]string{"id"}),
+			wantVal:  []*TestModel{},
+		},
+		{
+			name: "multiple rows",
+			mockRows: sqlmock.NewRows([]string{"id", "first_name", "age", "last_name"}).
+				AddRow([]byte("1"), []byte("yang"), []byte("18"), []byte("cheng")).
+				AddRow([]byte("2"), []byte("tom"), []byte("20"), []byte("jerry")),
+			wantVal: []*TestModel{
+				{Id: 1, FirstName: "yang", Age: 18, LastName: "cheng"},
+				{Id: 2, FirstName: "tom", Age: 20, LastName: "jerry"},
+			},
+		},
+		{
+			name:     "unknown column",
+			mockRows: sqlmock.NewRows([]string{"invalid"}).AddRow([]byte("1")),
+			wantErr:  errs.NewErrUnknownColumn("invalid"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			db, err := OpenDB(mockDB)
+			require.NoError(t, err)
+
+			exp := mock.ExpectQuery("SELECT .*")
+			if tc.mockErr != nil {
+				exp.WillReturnError(tc.mockErr)
+			} else {
+				exp.WillReturnRows(tc.mockRows)
+			}
+
+			res, err := NewSelector[TestModel](db).GetMulti(context.Background())
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantVal, res)
+		})
+	}
+}
+
+func TestSelector_Iter(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT \\* FROM `test_model` WHERE `age` > \\?;").
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "age", "last_name"}).
+			AddRow([]byte("1"), []byte("yang"), []byte("18"), []byte("cheng")).
+			AddRow([]byte("2"), []byte("tom"), []byte("20"), []byte("jerry")))
+
+	rows, err := NewSelector[TestModel](db).Where(Col("Age").GT(10)).Iter(context.Background())
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, rows.Close())
+	}()
+
+	var res []*TestModel
+	for rows.Next() {
+		res = append(res, rows.Value())
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []*TestModel{
+		{Id: 1, FirstName: "yang", Age: 18, LastName: "cheng"},
+		{Id: 2, FirstName: "tom", Age: 20, LastName: "jerry"},
+	}, res)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSelector_IterScanError(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"invalid"}).AddRow([]byte("1")))
+
+	rows, err := NewSelector[TestModel](db).Iter(context.Background())
+	require.NoError(t, err)
+	assert.False(t, rows.Next())
+	assert.Equal(t, errs.NewErrUnknownColumn("invalid"), rows.Err())
+}
+
+type closerResult struct {
+	closed bool
+}
+
+func (c *closerResult) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestSelector_IterUnexpectedResult(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	// 中间件查询了数据库但是返回了其它类型的结果
+	res := &closerResult{}
+	db, err := OpenDB(mockDB, DBWithMiddlewares(func(next Handler) Handler {
+		return func(ctx context.Context, qc *QueryContext) *QueryResult {
+			if r := next(ctx, qc); r.Error != nil {
+				return r
+			}
+			return &QueryResult{Result: res}
+		}
+	}))
+	require.NoError(t, err)
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	_, err = NewSelector[TestModel](db).Iter(context.Background())
+	assert.Equal(t, errs.NewErrUnexpectedResult(res), err)
+	assert.True(t, res.closed)
+}
+
+func TestSelector_GetUnexpectedResult(t *testing.T) {
+	mockDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	// 中间件返回了与 Get/GetMulti 不一致的结果类型
+	db, err := OpenDB(mockDB, DBWithMiddlewares(func(next Handler) Handler {
+		return func(ctx context.Context, qc *QueryContext) *QueryResult {
+			if qc.Multi {
+				return &QueryResult{Result: &TestModel{}}
+			}
+			return &QueryResult{Result: []*TestModel{}}
+		}
+	}))
+	require.NoError(t, err)
+
+	_, err = NewSelector[TestModel](db).Get(context.Background())
+	assert.Equal(t, errs.NewErrUnexpectedResult([]*TestModel{}), err)
+	_, err = NewSelector[TestModel](db).GetMulti(context.Background())
+	assert.Equal(t, errs.NewErrUnexpectedResult(&TestModel{}), err)
+}
+
+// TestSelector_MultiMiddleware 验证 GetMulti 与 Iter 都会经过中间件，且中间件中调用 Build 不影响最终执行的 SQL
+func TestSelector_MultiMiddleware(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	var built []string
+	db, err := OpenDB(mockDB, DBWithMiddlewares(func(next Handler) Handler {
+		return func(ctx context.Context, qc *QueryContext) *QueryResult {
+			q, err := qc.QueryBuilder.Build()
+			require.NoError(t, err)
+			built = append(built, qc.Type+" "+q.SQL)
+			return next(ctx, qc)
+		}
+	}))
+	require.NoError(t, err)
+
+	mock.ExpectQuery("^SELECT \\* FROM `test_model`;$").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow([]byte("1")))
+	mock.ExpectQuery("^SELECT \\* FROM `test_model`;$").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow([]byte("2")))
+
+	res, err := NewSelector[TestModel](db).GetMulti(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []*TestModel{{Id: 1}}, res)
+
+	rows, err := NewSelector[TestModel](db).Iter(context.Background())
+	require.NoError(t, err)
+	require.True(t, rows.Next())
+	assert.Equal(t, &TestModel{Id: 2}, rows.Value())
+	require.NoError(t, rows.Close())
+
+	assert.Equal(t, []string{"SELECT SELECT * FROM `test_model`;", "SELECT SELECT * FROM `test_model`;"}, built)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,537 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+	"Soil/orm/internal/model"
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/crc32"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Dst 分片的目标，DB 为空时只改写表名，否则改写为 `DB`.`Table`
+type Dst struct {
+	DB    string
+	Table string
+}
+
+// ShardingAlgorithm 分片算法
+type ShardingAlgorithm interface {
+	// Sharding 根据分片键的值计算目标分片
+	Sharding(val any) (Dst, error)
+	// Broadcast 返回所有分片，无法根据条件确定分片时使用
+	Broadcast() []Dst
+}
+
+// ShardLayout 描述按下标编号的分片：共 Shards 张表，第 i 张表名为 fmt.Sprintf(TableFormat, i)；
+// DBFormat 不为空时分库，第 i 张表位于第 i % DBShards 个库，库名为 fmt.Sprintf(DBFormat, i % DBShards)
+type ShardLayout struct {
+	Shards      int
+	TableFormat string
+	DBFormat    string
+	DBShards    int
+}
+
+// validate Shards 为 0 时计算分片会除以 0，DBShards 为负数时库的下标为负数
+func (l ShardLayout) validate() error {
+	if l.Shards <= 0 || l.DBShards < 0 {
+		return errs.NewErrInvalidShardLayout(l.Shards, l.DBShards)
+	}
+	return nil
+}
+
+func (l ShardLayout) dst(idx int) Dst {
+	res := Dst{Table: fmt.Sprintf(l.TableFormat, idx)}
+	if l.DBFormat != "" && l.DBShards > 0 {
+		res.DB = fmt.Sprintf(l.DBFormat, idx%l.DBShards)
+	}
+	return res
+}
+
+func (l ShardLayout) Broadcast() []Dst {
+	res := make([]Dst, 0, l.Shards)
+	for i := 0; i < l.Shards; i++ {
+		res = append(res, l.dst(i))
+	}
+	return res
+}
+
+// ModSharding 整数分片键对 Shards 取模，e.g. order_00..order_63：
+// ModSharding{ShardLayout{Shards: 64, TableFormat: "order_%02d"}}
+type ModSharding struct {
+	ShardLayout
+}
+
+func (m ModSharding) Sharding(val any) (Dst, error) {
+	v, err := shardingInt(val)
+	if err != nil {
+		return Dst{}, err
+	}
+	n := int64(m.Shards)
+	return m.dst(int(((v % n) + n) % n)), nil
+}
+
+// HashSharding 对分片键的值计算 CRC32 之后对 Shards 取模，适用于字符串等非整数的分片键
+type HashSharding struct {
+	ShardLayout
+}
+
+func (h HashSharding) Sharding(val any) (Dst, error) {
+	k, ok := relationKey(val)
+	if !ok {
+		return Dst{}, errs.NewErrShardNotFound(val)
+	}
+	return h.dst(int(crc32.ChecksumIEEE([]byte(k)) % uint32(h.Shards))), nil
+}
+
+// ShardRange 分片键的值小于 Upper（且不小于上一个区间的 Upper）时路由到 Dst
+type ShardRange struct {
+	Upper int64
+	Dst   Dst
+}
+
+// RangeSharding 按整数分片键的区间分片，Ranges 按 Upper 升序排列，超出最后一个区间的值返回错误
+type RangeSharding struct {
+	Ranges []ShardRange
+}
+
+func (r RangeSharding) Sharding(val any) (Dst, error) {
+	v, err := shardingInt(val)
+	if err != nil {
+		return Dst{}, err
+	}
+	for _, rg := range r.Ranges {
+		if v < rg.Upper {
+			return rg.Dst, nil
+		}
+	}
+	return Dst{}, errs.NewErrShardNotFound(val)
+}
+
+func (r RangeSharding) Broadcast() []Dst {
+	res := make([]Dst, 0, len(r.Ranges))
+	for _, rg := range r.Ranges {
+		res = append(res, rg.Dst)
+	}
+	return res
+}
+
+// shardingInt 将分片键的值转换为整数，支持整数、指针、[]byte 与 driver.Valuer
+func shardingInt(val any) (int64, error) {
+	k, ok := relationKey(val)
+	if !ok {
+		return 0, errs.NewErrShardNotFound(val)
+	}
+	v, err := strconv.ParseInt(k, 10, 64)
+	if err != nil {
+		return 0, errs.NewErrShardNotFound(val)
+	}
+	return v, nil
+}
+
+// shardingRule 模型的分片规则，key 为分片键的 Go 字段名
+type shardingRule struct {
+	entity    any
+	key       string
+	algorithm ShardingAlgorithm
+}
+
+// DBWithSharding 为模型设置分片规则，entity 为指向模型的指针，shardingKey 为分片键的 Go 字段名。
+// Selector/Updater/Deleter 根据 WHERE 中分片键的 EQ/IN 条件（以及它们的 AND/OR 组合）计算分片，
+// 无法确定时广播到所有分片；Inserter 根据每个值的分片键计算分片。
+// 只涉及一个分片时改写表名后按单条语句执行；涉及多个分片时：
+//   - Build 返回 ErrCrossShard
+//   - Get/GetMulti 并发查询所有分片后合并结果，ORDER BY 在内存中归并，LIMIT/OFFSET 在合并之后生效
+//   - Inserter/Updater/Deleter 依次在每个分片上执行，影响行数为各分片之和。不在事务中时不保证原子性
+func DBWithSharding(entity any, shardingKey string, algorithm ShardingAlgorithm) DBOption {
+	return func(db *DB) {
+		db.shardingRules = append(db.shardingRules, &shardingRule{
+			entity:    entity,
+			key:       shardingKey,
+			algorithm: algorithm,
+		})
+	}
+}
+
+// initSharding 在 OpenDB 中按表名索引分片规则，并校验分片键
+func (db *DB) initSharding() error {
+	if len(db.shardingRules) == 0 {
+		return nil
+	}
+	db.shardings = make(map[string]*shardingRule, len(db.shardingRules))
+	for _, rule := range db.shardingRules {
+		m, err := db.r.Get(rule.entity)
+		if err != nil {
+			return err
+		}
+		if _, ok := m.FieldMap[rule.key]; !ok {
+			return errs.NewErrUnknownField(rule.key)
+		}
+		// ModSharding、HashSharding 通过嵌入的 ShardLayout 校验分片数量
+		if v, ok := rule.algorithm.(interface{ validate() error }); ok {
+			if err = v.validate(); err != nil {
+				return err
+			}
+		}
+		db.shardings[m.TableName] = rule
+	}
+	return nil
+}
+
+// route 根据 WHERE 条件计算分片，多个条件之间是 AND 的关系
+func (r *shardingRule) route(where []Predicate) ([]Dst, error) {
+	if len(where) == 0 {
+		return r.algorithm.Broadcast(), nil
+	}
+	p := where[0]
+	for i := 1; i < len(where); i++ {
+		p = p.And(where[i])
+	}
+	dsts, ok, err := r.routePredicate(p)
+	if err != nil || !ok {
+		return r.algorithm.Broadcast(), err
+	}
+	return dsts, nil
+}
+
+// routePredicate 返回 false 表示无法根据该条件确定分片
+func (r *shardingRule) routePredicate(p Predicate) ([]Dst, bool, error) {
+	switch p.op {
+	case opAnd:
+		left, lok, err := r.routeExpression(p.left)
+		if err != nil {
+			return nil, false, err
+		}
+		right, rok, err := r.routeExpression(p.right)
+		if err != nil {
+			return nil, false, err
+		}
+		switch {
+		case lok && rok:
+			return intersectDsts(left, right), true, nil
+		case lok:
+			return left, true, nil
+		default:
+			return right, rok, nil
+		}
+	case opOr:
+		left, lok, err := r.routeExpression(p.left)
+		if err != nil || !lok {
+			return nil, false, err
+		}
+		right, rok, err := r.routeExpression(p.right)
+		if err != nil || !rok {
+			return nil, false, err
+		}
+		return unionDsts(left, right), true, nil
+	case opEQ, opIn:
+		col, ok := p.left.(Column)
+		if !ok || col.name != r.key {
+			return nil, false, nil
+		}
+		var vals []any
+		switch right := p.right.(type) {
+		case value:
+			vals = []any{right.val}
+		case valuesExpression:
+			vals = right.vals
+		default:
+			return nil, false, nil
+		}
+		res := make([]Dst, 0, len(vals))
+		for _, val := range vals {
+			dst, err := r.algorithm.Sharding(val)
+			if err != nil {
+				return nil, false, err
+			}
+			res = unionDsts(res, []Dst{dst})
+		}
+		return res, true, nil
+	}
+	return nil, false, nil
+}
+
+func (r *shardingRule) routeExpression(expr Expression) ([]Dst, bool, error) {
+	if p, ok := expr.(Predicate); ok {
+		return r.routePredicate(p)
+	}
+	return nil, false, nil
+}
+
+// routeValues 计算每个值的分片，groups[i] 为 dsts[i] 对应的值，分片按第一次出现的顺序排列
+func routeValues[T any](r *shardingRule, c core, m *model.Model, vals []*T) ([]Dst, [][]*T, error) {
+	var (
+		dsts   []Dst
+		groups [][]*T
+	)
+	for _, val := range vals {
+		key, err := c.valCreator(val, m).GetFieldValue(r.key)
+		if err != nil {
+			return nil, nil, err
+		}
+		dst, err := r.algorithm.Sharding(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		found := false
+		for i, d := range dsts {
+			if d == dst {
+				groups[i] = append(groups[i], val)
+				found = true
+				break
+			}
+		}
+		if !found {
+			dsts = append(dsts, dst)
+			groups = append(groups, []*T{val})
+		}
+	}
+	return dsts, groups, nil
+}
+
+func unionDsts(left, right []Dst) []Dst {
+	res := append([]Dst(nil), left...)
+	for _, d := range right {
+		if !containsDst(res, d) {
+			res = append(res, d)
+		}
+	}
+	return res
+}
+
+func intersectDsts(left, right []Dst) []Dst {
+	res := make([]Dst, 0, len(left))
+	for _, d := range left {
+		if containsDst(right, d) {
+			res = append(res, d)
+		}
+	}
+	return res
+}
+
+func containsDst(dsts []Dst, dst Dst) bool {
+	for _, d := range dsts {
+		if d == dst {
+			return true
+		}
+	}
+	return false
+}
+
+// shardingDsts 返回 WHERE 涉及的分片，模型没有分片规则或者已经指定了分片时返回 nil
+func (b *builder) shardingDsts(where []Predicate) ([]Dst, error) {
+	if b.shardDst != nil {
+		return nil, nil
+	}
+	rule, ok := b.shardings[b.model.TableName]
+	if !ok {
+		return nil, nil
+	}
+	return rule.route(where)
+}
+
+// resolveShard 在 Build 时确定当前语句使用的分片，涉及多个分片时返回 ErrCrossShard
+func (b *builder) resolveShard(where []Predicate) error {
+	b.dst = b.shardDst
+	dsts, err := b.shardingDsts(where)
+	if err != nil || dsts == nil {
+		return err
+	}
+	if len(dsts) != 1 {
+		return errs.ErrCrossShard
+	}
+	b.dst = &dsts[0]
+	return nil
+}
+
+// quoteTable 写入表名，分片模型改写为分片的表名
+func (b *builder) quoteTable(meta *model.Model) {
+	if b.dst == nil || meta != b.model {
+		b.quote(meta.TableName)
+		return
+	}
+	if b.dst.DB != "" {
+		b.quote(b.dst.DB)
+		b.sqlStrBuilder.WriteByte('.')
+	}
+	b.quote(b.dst.Table)
+}
+
+// execShards 依次在每个分片上执行，汇总影响行数，某个分片失败时停止并返回该错误
+func execShards(dsts []Dst, fn func(idx int, dst *Dst) *QueryResult) *QueryResult {
+	var total int64
+	for idx := range dsts {
+		res := fn(idx, &dsts[idx])
+		if res.Error != nil {
+			return res
+		}
+		if sqlRes, ok := res.Result.(sql.Result); ok && sqlRes != nil {
+			if n, err := sqlRes.RowsAffected(); err == nil {
+				total += n
+			}
+		}
+	}
+	return &QueryResult{Result: Result{res: &aggregatedResult{rowsAffected: total}}}
+}
+
+// scatter 并发查询所有分片并合并结果：每个分片查询前 offset+limit 行，合并后按 ORDER BY 归并再截取。
+// 在事务中时依次查询，同一个事务不能在多个 goroutine 中并发使用
+func (s *Selector[T]) scatter(ctx context.Context, dsts []Dst) ([]*T, error) {
+	if len(s.groupBy) > 0 || len(s.having) > 0 {
+		return nil, errs.NewErrUnsupportedFeature("跨分片的 GROUP BY")
+	}
+	if s.distinct || len(s.unions) > 0 {
+		return nil, errs.NewErrUnsupportedFeature("跨分片的 DISTINCT 与 UNION")
+	}
+	for _, col := range s.columns {
+		if _, ok := col.(Column); !ok {
+			return nil, errs.NewErrUnsupportedFeature("跨分片的聚合函数与原生表达式")
+		}
+	}
+
+	var (
+		wg      sync.WaitGroup
+		results = make([][]*T, len(dsts))
+		errList = make([]error, len(dsts))
+		inTx    = sessionTx(ctx, s.session) != nil
+	)
+	for idx := range dsts {
+		sub := s.shard(&dsts[idx])
+		if s.limit > 0 {
+			sub.limit = s.offset + s.limit
+		}
+		sub.offset = 0
+		if inTx {
+			if results[idx], errList[idx] = sub.getMulti(ctx); errList[idx] != nil {
+				break
+			}
+			continue
+		}
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			results[idx], errList[idx] = sub.getMulti(ctx)
+		}(idx)
+	}
+	wg.Wait()
+
+	var res []*T
+	for idx, err := range errList {
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, results[idx]...)
+	}
+
+	if len(s.orderBy) > 0 {
+		if err := sortByOrder(res, s.model, s.orderBy); err != nil {
+			return nil, err
+		}
+	}
+	if s.offset >= len(res) {
+		return []*T{}, nil
+	}
+	res = res[s.offset:]
+	if s.limit > 0 && s.limit < len(res) {
+		res = res[:s.limit]
+	}
+	return res, nil
+}
+
+// shard 复制一个只查询 dst 分片的 Selector
+func (s *Selector[T]) shard(dst *Dst) *Selector[T] {
+	sub := &Selector[T]{
+		builder: builder{
+			core:     s.core,
+			quoter:   s.quoter,
+			shardDst: dst,
+			scope:    s.scope,
+		},
+		session: s.session,
+	}
+	sub.table, sub.where, sub.pk, sub.columns = s.table, s.where, s.pk, s.columns
+	sub.orderBy, sub.offset, sub.limit = s.orderBy, s.offset, s.limit
+	sub.lock, sub.lockInTx = s.lock, s.lockInTx
+	return sub
+}
+
+// sortByOrder 按 ORDER BY 对合并之后的结果稳定排序
+func sortByOrder[T any](vals []*T, m *model.Model, orderBy []OrderBy) error {
+	var err error
+	sort.SliceStable(vals, func(i, j int) bool {
+		left, right := reflect.ValueOf(vals[i]).Elem(), reflect.ValueOf(vals[j]).Elem()
+		for _, o := range orderBy {
+			fd, ok := m.FieldMap[o.col]
+			if !ok {
+				err = errs.NewErrUnknownField(o.col)
+				return false
+			}
+			c, e := compareValue(orderValue(fd, left), orderValue(fd, right))
+			if e != nil {
+				err = e
+				return false
+			}
+			if c == 0 {
+				continue
+			}
+			if o.order == "DESC" {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+	return err
+}
+
+// orderValue 排序使用的字段的值，字段所在的结构体指针为 nil 时视为零值
+func orderValue(fd *model.Field, val reflect.Value) reflect.Value {
+	if fv, ok := fd.FieldValue(val, false); ok {
+		return fv
+	}
+	return reflect.Zero(fd.Type)
+}
+
+// compareValue 比较两个相同类型的字段值，NULL（nil 指针）最小
+func compareValue(left, right reflect.Value) (int, error) {
+	if left.Kind() == reflect.Ptr {
+		switch {
+		case left.IsNil() && right.IsNil():
+			return 0, nil
+		case left.IsNil():
+			return -1, nil
+		case right.IsNil():
+			return 1, nil
+		}
+		return compareValue(left.Elem(), right.Elem())
+	}
+	switch left.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(left.Int(), right.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmp.Compare(left.Uint(), right.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(left.Float(), right.Float()), nil
+	case reflect.String:
+		return cmp.Compare(left.String(), right.String()), nil
+	case reflect.Bool:
+		return cmp.Compare(boolInt(left.Bool()), boolInt(right.Bool())), nil
+	}
+	if t, ok := left.Interface().(time.Time); ok {
+		return t.Compare(right.Interface().(time.Time)), nil
+	}
+	return 0, errs.NewErrUnsupportedFeature("按 " + left.Type().String() + " 类型的字段归并排序")
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,269 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ShardOrder struct {
+	Id     int64
+	UserId int64
+	Amount int64
+}
+
+func newShardingDB(t *testing.T, algorithm ShardingAlgorithm) (*DB, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithSharding(&ShardOrder{}, "UserId", algorithm))
+	require.NoError(t, err)
+	return db, mock
+}
+
+func TestSharding_Build(t *testing.T) {
+	db, _ := newShardingDB(t, ModSharding{ShardLayout{
+		Shards: 64, TableFormat: "order_%02d", DBFormat: "order_db_%d", DBShards: 4,
+	}})
+
+	testCases := []struct {
+		name      string
+		builder   QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "select single shard",
+			builder: NewSelector[ShardOrder](db).Where(Col("UserId").EQ(67), Col("Amount").GT(10)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `order_db_3`.`order_03` WHERE (`user_id` = ?) AND (`amount` > ?);",
+				Args: []any{67, 10},
+			},
+		},
+		{
+			name: "in values of same shard and qualified column",
+			builder: NewSelector[ShardOrder](db).From(TableOf(&ShardOrder{})).
+				Select(TableOf(&ShardOrder{}).Col("Id")).Where(Col("UserId").In(3, 67)),
+			wantQuery: &Query{
+				SQL:  "SELECT `order_db_3`.`order_03`.`id` FROM `order_db_3`.`order_03` WHERE `user_id` IN (?,?);",
+				Args: []any{3, 67},
+			},
+		},
+		{
+			name:    "and narrows shards",
+			builder: NewDeleter[ShardOrder](db).Where(Col("UserId").In(1, 2).And(Col("UserId").EQ(2))),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `order_db_2`.`order_02` WHERE (`user_id` IN (?,?)) AND (`user_id` = ?);",
+				Args: []any{1, 2, 2},
+			},
+		},
+		{
+			name:    "update single shard",
+			builder: NewUpdater[ShardOrder](db).Set(Assign("Amount", 1)).Where(Col("UserId").EQ(1)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `order_db_1`.`order_01` SET `amount`=? WHERE `user_id` = ?;",
+				Args: []any{1, 1},
+			},
+		},
+		{
+			name:    "insert single shard",
+			builder: NewInserter[ShardOrder](db).Values(&ShardOrder{Id: 1, UserId: 65}),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `order_db_1`.`order_01`(`id`,`user_id`,`amount`) VALUES (?,?,?);",
+				Args: []any{int64(1), int64(65), int64(0)},
+			},
+		},
+		{
+			name:    "or across shards",
+			builder: NewSelector[ShardOrder](db).Where(Col("UserId").EQ(1).Or(Col("UserId").EQ(2))),
+			wantErr: errs.ErrCrossShard,
+		},
+		{
+			name:    "broadcast",
+			builder: NewSelector[ShardOrder](db).Where(Col("Amount").GT(10)),
+			wantErr: errs.ErrCrossShard,
+		},
+		{
+			name:    "insert across shards",
+			builder: NewInserter[ShardOrder](db).Values(&ShardOrder{UserId: 1}, &ShardOrder{UserId: 2}),
+			wantErr: errs.ErrCrossShard,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err == nil {
+				assert.Equal(t, tc.wantQuery, q)
+			}
+		})
+	}
+}
+
+func TestShardingAlgorithms(t *testing.T) {
+	hash := HashSharding{ShardLayout{Shards: 8, TableFormat: "user_%d"}}
+	dst, err := hash.Sharding("tom")
+	require.NoError(t, err)
+	again, err := hash.Sharding([]byte("tom"))
+	require.NoError(t, err)
+	assert.Equal(t, dst, again)
+	assert.Len(t, hash.Broadcast(), 8)
+
+	rg := RangeSharding{Ranges: []ShardRange{
+		{Upper: 1000, Dst: Dst{Table: "order_0"}},
+		{Upper: 2000, Dst: Dst{Table: "order_1"}},
+	}}
+	dst, err = rg.Sharding(int64(1500))
+	require.NoError(t, err)
+	assert.Equal(t, Dst{Table: "order_1"}, dst)
+	_, err = rg.Sharding(2000)
+	assert.Equal(t, errs.NewErrShardNotFound(2000), err)
+
+	mod := ModSharding{ShardLayout{Shards: 4, TableFormat: "order_%d"}}
+	dst, err = mod.Sharding(-1)
+	require.NoError(t, err)
+	assert.Equal(t, Dst{Table: "order_3"}, dst)
+	_, err = mod.Sharding("abc")
+	assert.Equal(t, errs.NewErrShardNotFound("abc"), err)
+}
+
+func TestSharding_UnknownKey(t *testing.T) {
+	mockDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	_, err = OpenDB(mockDB, DBWithSharding(&ShardOrder{}, "Invalid", ModSharding{}))
+	assert.Equal(t, errs.NewErrUnknownField("Invalid"), err)
+}
+
+func TestSharding_InvalidLayout(t *testing.T) {
+	testCases := []struct {
+		name      string
+		algorithm ShardingAlgorithm
+		wantErr   error
+	}{
+		{
+			name:      "mod zero shards",
+			algorithm: ModSharding{ShardLayout{TableFormat: "order_%d"}},
+			wantErr:   errs.NewErrInvalidShardLayout(0, 0),
+		},
+		{
+			name:      "hash negative db shards",
+			algorithm: &HashSharding{ShardLayout{Shards: 2, TableFormat: "order_%d", DBFormat: "db_%d", DBShards: -1}},
+			wantErr:   errs.NewErrInvalidShardLayout(2, -1),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, _, err := sqlmock.New()
+			require.NoError(t, err)
+			_, err = OpenDB(mockDB, DBWithSharding(&ShardOrder{}, "UserId", tc.algorithm))
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestSharding_ScatterGetMulti(t *testing.T) {
+	db, mock := newShardingDB(t, ModSharding{ShardLayout{Shards: 2, TableFormat: "order_%d"}})
+	// 各分片并发查询，顺序不确定
+	mock.MatchExpectationsInOrder(false)
+	mock.ExpectQuery("SELECT * FROM `order_0` WHERE `amount` > ? ORDER BY `amount` DESC,`id` ASC LIMIT ?;").
+		WithArgs(10, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "amount"}).
+			AddRow(1, 2, 50).AddRow(2, 4, 30).AddRow(3, 6, 20))
+	mock.ExpectQuery("SELECT * FROM `order_1` WHERE `amount` > ? ORDER BY `amount` DESC,`id` ASC LIMIT ?;").
+		WithArgs(10, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "amount"}).
+			AddRow(4, 1, 40).AddRow(5, 3, 30).AddRow(6, 5, 11))
+
+	res, err := NewSelector[ShardOrder](db).Where(Col("Amount").GT(10)).
+		OrderBy(Desc("Amount"), Asc("Id")).Limit(2).Offset(1).GetMulti(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []*ShardOrder{
+		{Id: 4, UserId: 1, Amount: 40},
+		{Id: 2, UserId: 4, Amount: 30},
+	}, res)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSharding_ScatterInTx(t *testing.T) {
+	db, mock := newShardingDB(t, ModSharding{ShardLayout{Shards: 2, TableFormat: "order_%d"}})
+	// 事务中依次查询各分片
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT * FROM `order_0`;").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "amount"}).AddRow(1, 2, 50))
+	mock.ExpectQuery("SELECT * FROM `order_1`;").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "amount"}).AddRow(2, 1, 40))
+	mock.ExpectCommit()
+
+	err := db.DoTx(context.Background(), func(ctx context.Context, tx *Tx) error {
+		res, err := NewSelector[ShardOrder](db).GetMulti(ctx)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, []*ShardOrder{{Id: 1, UserId: 2, Amount: 50}, {Id: 2, UserId: 1, Amount: 40}}, res)
+		return nil
+	}, nil)
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSharding_ScatterGet(t *testing.T) {
+	db, mock := newShardingDB(t, ModSharding{ShardLayout{Shards: 2, TableFormat: "order_%d"}})
+	mock.MatchExpectationsInOrder(false)
+	mock.ExpectQuery("SELECT * FROM `order_0` WHERE `id` = ?;").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "amount"}))
+	mock.ExpectQuery("SELECT * FROM `order_1` WHERE `id` = ?;").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "amount"}).AddRow(5, 3, 30))
+
+	res, err := NewSelector[ShardOrder](db).Where(Col("Id").EQ(5)).Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &ShardOrder{Id: 5, UserId: 3, Amount: 30}, res)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = NewSelector[ShardOrder](db).Select(Count("Id")).Where(Col("Id").EQ(5)).GetMulti(context.Background())
+	assert.ErrorIs(t, err, errs.ErrUnsupportedFeature)
+}
+
+func TestSharding_ScatterExec(t *testing.T) {
+	db, mock := newShardingDB(t, ModSharding{ShardLayout{Shards: 2, TableFormat: "order_%d"}})
+	ctx := context.Background()
+
+	// 按分片分组插入，分片按值第一次出现的顺序执行
+	mock.ExpectExec("INSERT INTO `order_1`(`id`,`user_id`,`amount`) VALUES (?,?,?),(?,?,?);").
+		WithArgs(int64(1), int64(1), int64(0), int64(3), int64(3), int64(0)).
+		WillReturnResult(sqlmock.NewResult(3, 2))
+	mock.ExpectExec("INSERT INTO `order_0`(`id`,`user_id`,`amount`) VALUES (?,?,?);").
+		WithArgs(int64(2), int64(2), int64(0)).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	res := NewInserter[ShardOrder](db).Values(
+		&ShardOrder{Id: 1, UserId: 1}, &ShardOrder{Id: 2, UserId: 2}, &ShardOrder{Id: 3, UserId: 3}).Exec(ctx)
+	affected, err := res.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), affected)
+
+	// 无法确定分片时广播
+	mock.ExpectExec("UPDATE `order_0` SET `amount`=? WHERE `amount` < ?;").
+		WithArgs(0, 0).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE `order_1` SET `amount`=? WHERE `amount` < ?;").
+		WithArgs(0, 0).WillReturnResult(sqlmock.NewResult(0, 1))
+	affected, err = NewUpdater[ShardOrder](db).Set(Assign("Amount", 0)).
+		Where(Col("Amount").LT(0)).Exec(ctx).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), affected)
+
+	mock.ExpectExec("DELETE FROM `order_0` WHERE (`user_id` = ?) OR (`user_id` = ?);").
+		WithArgs(2, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM `order_1` WHERE (`user_id` = ?) OR (`user_id` = ?);").
+		WithArgs(2, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	affected, err = NewDeleter[ShardOrder](db).Where(Col("UserId").EQ(2).Or(Col("UserId").EQ(1))).
+		Exec(ctx).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), affected)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
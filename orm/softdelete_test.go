@@ -1,6 +1,7 @@
 package orm
 
 import (
+	"Soil/orm/internal/errs"
 	"context"
 	"testing"
 	"time"
@@ -413,6 +414,90 @@ func TestDeleter_Exec_SoftDeleteRewrite(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+// ---- Unscoped / WithTrashed / OnlyTrashed / Restore 测试 ----
+
+func TestSoftDelete_Scopes(t *testing.T) {
+	mockDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		builder   QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "unscoped delete",
+			builder: NewDeleter[SoftDeleteModel](db).Unscoped().Where(Col("Id").EQ(int64(1))),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `soft_delete_model` WHERE `id` = ?;",
+				Args: []any{int64(1)},
+			},
+		},
+		{
+			name:    "unscoped delete vals",
+			builder: NewDeleter[SoftDeleteModel](db).Unscoped().Delete(&SoftDeleteModel{Id: 1}, &SoftDeleteModel{Id: 2}),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `soft_delete_model` WHERE `id` IN (?,?);",
+				Args: []any{int64(1), int64(2)},
+			},
+		},
+		{
+			name:    "with trashed",
+			builder: NewSelector[SoftDeleteModel](db).WithTrashed().Where(Col("Id").EQ(int64(1))),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `soft_delete_model` WHERE `id` = ?;",
+				Args: []any{int64(1)},
+			},
+		},
+		{
+			name:    "only trashed",
+			builder: NewSelector[SoftDeleteModel](db).OnlyTrashed(),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `soft_delete_model` WHERE `deleted_at` IS NOT NULL;",
+			},
+		},
+		{
+			name:    "restore",
+			builder: NewUpdater[SoftDeleteModel](db).Restore().Where(Col("Id").EQ(int64(1))),
+			wantQuery: &Query{
+				SQL:  "UPDATE `soft_delete_model` SET `deleted_at`=? WHERE `deleted_at` IS NOT NULL AND `id` = ?;",
+				Args: []any{nil, int64(1)},
+			},
+		},
+		{
+			name:    "restore without soft delete field",
+			builder: NewUpdater[TestModel](db).Restore(),
+			wantErr: errs.NewErrUnsupportedFeature("没有软删除字段的模型不支持 Restore"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err == nil {
+				assert.Equal(t, tc.wantQuery, q)
+			}
+		})
+	}
+}
+
+func TestUpdater_Exec_RestoreFillsUpdatedAt(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	mock.ExpectExec("UPDATE `soft_delete_model` SET `deleted_at`=?,`updated_at`=? WHERE `deleted_at` IS NOT NULL AND `id` = ?;").
+		WithArgs(nil, sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	res := NewUpdater[SoftDeleteModel](db).Restore().Where(Col("Id").EQ(int64(1))).Exec(context.Background())
+	require.NoError(t, res.Err())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 // ---- tag 标记识别测试（registry 层面） ----
 
 func TestRegistry_RecognizesTimestampAndSoftDeleteFields(t *testing.T) {
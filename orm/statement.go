@@ -0,0 +1,80 @@
+package orm
+
+// StatementInfo 语句的结构信息，中间件可以据此在执行之前检查语句，e.g. 拒绝没有 WHERE 的 DELETE
+type StatementInfo struct {
+	// Where 是否有调用方指定的条件，包括 ByPK 和 Delete 传入实例时的主键条件。
+	// 软删除、乐观锁等自动追加的条件不计算在内
+	Where bool
+	Limit bool
+	// MaxInValues WHERE 中最长的 IN 列表的值的个数，子查询和 Raw 不计算在内
+	MaxInValues int
+}
+
+// Inspector Selector、Updater 和 Deleter 实现了该接口，
+// 中间件可以对 QueryContext.QueryBuilder 做类型断言获得语句的结构信息
+type Inspector interface {
+	Inspect() (StatementInfo, error)
+}
+
+var (
+	_ Inspector = &Selector[any]{}
+	_ Inspector = &Updater[any]{}
+	_ Inspector = &Deleter[any]{}
+)
+
+func (s *Selector[T]) Inspect() (StatementInfo, error) {
+	var err error
+	s.model, err = s.r.Get(new(T))
+	if err != nil {
+		return StatementInfo{}, err
+	}
+	where, err := s.predicates()
+	if err != nil {
+		return StatementInfo{}, err
+	}
+	return newStatementInfo(where, s.limit), nil
+}
+
+func (u *Updater[T]) Inspect() (StatementInfo, error) {
+	return newStatementInfo(u.where, u.limit), nil
+}
+
+func (d *Deleter[T]) Inspect() (StatementInfo, error) {
+	var err error
+	d.model, err = d.r.Get(new(T))
+	if err != nil {
+		return StatementInfo{}, err
+	}
+	where, err := d.predicates()
+	if err != nil {
+		return StatementInfo{}, err
+	}
+	return newStatementInfo(where, d.limit), nil
+}
+
+func newStatementInfo(where []Predicate, limit int) StatementInfo {
+	info := StatementInfo{Where: len(where) > 0, Limit: limit > 0}
+	for _, p := range where {
+		info.MaxInValues = max(info.MaxInValues, inValues(p))
+	}
+	return info
+}
+
+// inValues 表达式中最长的 IN 列表的值的个数
+func inValues(e Expression) int {
+	var left, right Expression
+	switch exp := e.(type) {
+	case Predicate:
+		if exp.op == opIn || exp.op == opNotIn {
+			if vals, ok := exp.right.(valuesExpression); ok {
+				return len(vals.vals)
+			}
+		}
+		left, right = exp.left, exp.right
+	case binaryExpression:
+		left, right = exp.left, exp.right
+	default:
+		return 0
+	}
+	return max(inValues(left), inValues(right))
+}
@@ -0,0 +1,73 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	mockDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		inspector Inspector
+		wantInfo  StatementInfo
+		wantErr   error
+	}{
+		{
+			name:      "select no where",
+			inspector: NewSelector[TestModel](db),
+		},
+		{
+			name:      "select by pk",
+			inspector: NewSelector[TestModel](db).ByPK(1, 2, 3).Limit(3),
+			wantInfo:  StatementInfo{Where: true, Limit: true, MaxInValues: 3},
+		},
+		{
+			name: "select nested in",
+			inspector: NewSelector[TestModel](db).Where(
+				Not(Col("Age").GT(18).Or(Col("Id").NotIn([]int{1, 2}))), Col("Id").In(1)),
+			wantInfo: StatementInfo{Where: true, MaxInValues: 2},
+		},
+		{
+			name:      "select in subquery",
+			inspector: NewSelector[TestModel](db).Where(Col("Id").In(NewSelector[TestModel](db).Select(Col("Id")))),
+			wantInfo:  StatementInfo{Where: true},
+		},
+		{
+			name:      "update",
+			inspector: NewUpdater[TestModel](db).Update(&TestModel{Id: 1}).Limit(1),
+			wantInfo:  StatementInfo{Limit: true},
+		},
+		{
+			name:      "delete instances",
+			inspector: NewDeleter[TestModel](db).Delete(&TestModel{Id: 1}, &TestModel{Id: 2}),
+			wantInfo:  StatementInfo{Where: true, MaxInValues: 2},
+		},
+		{
+			name:      "delete where",
+			inspector: NewDeleter[TestModel](db).Where(Col("Age").LT(18)),
+			wantInfo:  StatementInfo{Where: true},
+		},
+		{
+			name:      "no primary key",
+			inspector: NewSelector[NoPKModel](db).ByPK(1),
+			wantErr:   errs.ErrNoPrimaryKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, err := tc.inspector.Inspect()
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantInfo, info)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+)
+
+// Subquery 子查询，既可以作为 FROM/JOIN 中的派生表，也可以作为表达式用于 IN、EXISTS 以及比较运算，
+// e.g. sub := NewSelector[Order](db).Select(Col("UserId")).AsSubquery("sub")
+type Subquery struct {
+	q QueryBuilder
+	// from 子查询 FROM 的表，子查询 SELECT * 时用于解析列
+	from TableReference
+	// columns 子查询 SELECT 的列，外层只能引用这些列
+	columns []Selectable
+	alias   string
+}
+
+func (Subquery) expr()  {}
+func (Subquery) table() {}
+
+// AsSubquery 将 Selector 转换为子查询，作为派生表使用时 alias 是派生表的别名
+func (s *Selector[T]) AsSubquery(alias string) Subquery {
+	tbl := s.table
+	if tbl == nil {
+		tbl = TableOf(new(T))
+	}
+	return Subquery{
+		q:       s,
+		from:    tbl,
+		columns: s.columns,
+		alias:   alias,
+	}
+}
+
+// Col 子查询中的列，name 是子查询 SELECT 的列的别名或者字段名，e.g. sub.Col("UserId") --> `sub`.`user_id`
+func (s Subquery) Col(name string) Column {
+	return Column{
+		name:  name,
+		table: s,
+	}
+}
+
+func (s Subquery) Join(right TableReference) *JoinBuilder {
+	return &JoinBuilder{
+		left:  s,
+		right: right,
+		typ:   "JOIN",
+	}
+}
+
+func (s Subquery) LeftJoin(right TableReference) *JoinBuilder {
+	return &JoinBuilder{
+		left:  s,
+		right: right,
+		typ:   "LEFT JOIN",
+	}
+}
+
+func (s Subquery) RightJoin(right TableReference) *JoinBuilder {
+	return &JoinBuilder{
+		left:  s,
+		right: right,
+		typ:   "RIGHT JOIN",
+	}
+}
+
+// Exists e.g. Exists(NewSelector[Order](db).Where(...).AsSubquery("")) --> EXISTS (SELECT ...)
+func Exists(sub Subquery) Predicate {
+	return Predicate{
+		op:    opExists,
+		right: sub,
+	}
+}
+
+// NotExists e.g. NotExists(sub) --> NOT EXISTS (SELECT ...)
+func NotExists(sub Subquery) Predicate {
+	return Predicate{
+		op:    opNotExists,
+		right: sub,
+	}
+}
+
+// subqueryColumnName 解析子查询中的列名：按子查询 SELECT 的列的别名或者字段名匹配，
+// 匹配别名时使用别名，子查询 SELECT * 时按子查询 FROM 的表解析
+func (b *builder) subqueryColumnName(sub Subquery, name string) (string, error) {
+	if len(sub.columns) == 0 {
+		return b.tableColumnName(sub.from, name)
+	}
+	for _, c := range sub.columns {
+		switch c := c.(type) {
+		case Column:
+			if c.alias == name {
+				return name, nil
+			}
+			if c.alias == "" && c.name == name {
+				tbl := c.table
+				if tbl == nil {
+					tbl = sub.from
+				}
+				return b.tableColumnName(tbl, name)
+			}
+		case Aggregate:
+			if c.alias == name {
+				return name, nil
+			}
+		}
+	}
+	return "", errs.NewErrUnknownField(name)
+}
+
+// tableColumnName 在 TableReference 中查找字段对应的列名，联表时从左到右查找
+func (b *builder) tableColumnName(table TableReference, name string) (string, error) {
+	switch t := table.(type) {
+	case Table:
+		meta, err := b.r.Get(t.entity)
+		if err != nil {
+			return "", err
+		}
+		if field, ok := meta.FieldMap[name]; ok {
+			return field.ColName, nil
+		}
+	case Join:
+		if col, err := b.tableColumnName(t.left, name); err == nil {
+			return col, nil
+		}
+		return b.tableColumnName(t.right, name)
+	case Subquery:
+		return b.subqueryColumnName(t, name)
+	}
+	return "", errs.NewErrUnknownField(name)
+}
@@ -0,0 +1,138 @@
+package orm
+
+import (
+	"Soil/orm/internal/errs"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubquery_Build(t *testing.T) {
+	type Order struct {
+		Id     int64
+		UserId int64
+		Amount int64
+	}
+
+	mockDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		builder   QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "derived table",
+			builder: func() QueryBuilder {
+				sub := NewSelector[Order](db).Select(Col("UserId"), Sum("Amount").As("total")).
+					Where(Col("Amount").GT(10)).GroupBy(Col("UserId")).AsSubquery("sub")
+				return NewSelector[Order](db).From(sub).
+					Select(sub.Col("UserId"), sub.Col("total")).Where(sub.Col("total").GT(100))
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT `sub`.`user_id`,`sub`.`total` FROM (SELECT `user_id`,SUM(`amount`) AS `total` FROM `order`" +
+					" WHERE `amount` > ? GROUP BY `user_id`) AS `sub` WHERE `sub`.`total` > ?;",
+				Args: []any{10, 100},
+			},
+		},
+		{
+			name: "join subquery",
+			builder: func() QueryBuilder {
+				sub := NewSelector[Order](db).AsSubquery("sub")
+				t1 := TableOf(&TestModel{})
+				return NewSelector[TestModel](db).Select(t1.Col("Id"), sub.Col("Amount")).
+					From(t1.Join(sub).On(t1.Col("Id").EQ(sub.Col("UserId"))))
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT `test_model`.`id`,`sub`.`amount` FROM (`test_model` JOIN (SELECT * FROM `order`) AS `sub`" +
+					" ON `test_model`.`id` = `sub`.`user_id`);",
+			},
+		},
+		{
+			name: "unknown subquery column",
+			builder: func() QueryBuilder {
+				sub := NewSelector[Order](db).Select(Col("UserId")).AsSubquery("sub")
+				return NewSelector[Order](db).From(sub).Select(sub.Col("Amount"))
+			}(),
+			wantErr: errs.NewErrUnknownField("Amount"),
+		},
+		{
+			name: "in subquery",
+			builder: NewSelector[TestModel](db).Where(Col("Id").In(
+				NewSelector[Order](db).Select(Col("UserId")).Where(Col("Amount").GT(10)).AsSubquery(""))),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` IN (SELECT `user_id` FROM `order` WHERE `amount` > ?);",
+				Args: []any{10},
+			},
+		},
+		{
+			name: "exists",
+			builder: NewSelector[TestModel](db).Where(Col("Age").GT(18), Exists(NewSelector[Order](db).
+				Where(TableOf(&Order{}).Col("UserId").EQ(TableOf(&TestModel{}).Col("Id"))).AsSubquery(""))),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` WHERE (`age` > ?) AND (EXISTS (SELECT * FROM `order`" +
+					" WHERE `order`.`user_id` = `test_model`.`id`));",
+				Args: []any{18},
+			},
+		},
+		{
+			name:    "not exists",
+			builder: NewSelector[TestModel](db).Where(NotExists(NewSelector[Order](db).Where(Col("Amount").LT(0)).AsSubquery(""))),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE NOT EXISTS (SELECT * FROM `order` WHERE `amount` < ?);",
+				Args: []any{0},
+			},
+		},
+		{
+			name:    "distinct",
+			builder: NewSelector[TestModel](db).Distinct().Select(Col("FirstName")),
+			wantQuery: &Query{
+				SQL: "SELECT DISTINCT `first_name` FROM `test_model`;",
+			},
+		},
+		{
+			name: "union keeps args order",
+			builder: NewSelector[TestModel](db).Where(Col("Age").LT(18)).
+				Union(NewSelector[TestModel](db).Where(Col("Age").GT(60))).
+				UnionAll(NewSelector[TestModel](db).Where(Col("FirstName").EQ("Tom"))).
+				OrderBy(Asc("Id")).Limit(10),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` WHERE `age` < ? UNION SELECT * FROM `test_model` WHERE `age` > ?" +
+					" UNION ALL SELECT * FROM `test_model` WHERE `first_name` = ? ORDER BY `id` ASC LIMIT ?;",
+				Args: []any{18, 60, "Tom", 10},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err == nil {
+				assert.Equal(t, tc.wantQuery, q)
+			}
+		})
+	}
+}
+
+func TestSubquery_PostgresPlaceholders(t *testing.T) {
+	mockDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(Postgres))
+	require.NoError(t, err)
+
+	// 子查询和 UNION 的占位符由外层语句统一按顺序改写
+	sub := NewSelector[TestModel](db).Where(Col("Age").GT(18)).AsSubquery("sub")
+	q, err := NewSelector[TestModel](db).From(sub).Where(sub.Col("FirstName").EQ("Tom")).
+		Union(NewSelector[TestModel](db).Where(Col("Age").LT(10))).Build()
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT * FROM (SELECT * FROM "test_model" WHERE "age" > $1) AS "sub" WHERE "sub"."first_name" = $2`+
+		` UNION SELECT * FROM "test_model" WHERE "age" < $3;`, q.SQL)
+	assert.Equal(t, []any{18, "Tom", 10}, q.Args)
+}
@@ -1,8 +1,10 @@
 package orm
 
 import (
+	"Soil/orm/internal/errs"
 	"context"
 	"database/sql"
+	"strconv"
 )
 
 var (
@@ -14,22 +16,102 @@ var (
 type Session interface {
 	getCore() core
 	queryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	// readQueryContext 执行只读查询，DB 可能将其发往只读副本
+	readQueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
 	execContext(ctx context.Context, query string, args ...any) (sql.Result, error)
 }
 
+// Propagation 事务的传播行为，决定 DoTxWithPropagation 在 ctx 中已经有事务时如何处理
+type Propagation int
+
+const (
+	// PropagationRequired 加入已有事务，没有事务时开启新事务。
+	// 加入已有事务时 fn 返回 error 或者 panic 会将整个事务标记为只能回滚
+	PropagationRequired Propagation = iota
+	// PropagationRequiresNew 总是开启一个新的独立事务，与已有事务互不影响
+	PropagationRequiresNew
+	// PropagationNested 已有事务时使用保存点开启嵌套事务，fn 失败只回滚到保存点；没有事务时开启新事务
+	PropagationNested
+)
+
 type Tx struct {
 	tx *sql.Tx
 	db *DB // 记录调用事务的DB
+
+	// savepoints 已经创建的保存点个数，用于生成保存点的名字
+	savepoints int
+	// rollbackOnly 加入事务的操作失败之后，事务不能再提交
+	rollbackOnly bool
+	// done 事务已经提交或者回滚
+	done bool
+	// afterCommit 事务提交成功之后调用
+	afterCommit []func()
 }
 
 func (tx *Tx) Commit() error {
-	return tx.tx.Commit()
+	tx.done = true
+	if err := tx.tx.Commit(); err != nil {
+		return err
+	}
+	for _, fn := range tx.afterCommit {
+		fn()
+	}
+	return nil
+}
+
+// OnCommit fn 在事务提交成功之后调用，事务回滚时不会调用。
+// 保存点回滚时其中注册的 fn 不会被撤销，因此 fn 应该是可以重复执行的操作，e.g. 让缓存失效
+func (tx *Tx) OnCommit(fn func()) {
+	tx.afterCommit = append(tx.afterCommit, fn)
 }
 
 func (tx *Tx) Rollback() error {
+	tx.done = true
 	return tx.tx.Rollback()
 }
 
+// DoTx 使用保存点在当前事务中开启嵌套事务：fn 返回 error 或者 panic 时 ROLLBACK TO SAVEPOINT，
+// 只撤销 fn 中的修改，外层事务可以继续执行；否则 RELEASE SAVEPOINT
+func (tx *Tx) DoTx(ctx context.Context, fn func(ctx context.Context, tx *Tx) error) (err error) {
+	tx.savepoints++
+	name := "sp_" + strconv.Itoa(tx.savepoints)
+	if _, err = tx.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+	rollbackOnly := tx.rollbackOnly
+
+	panicked := true
+	defer func() {
+		if panicked || err != nil {
+			if _, e := tx.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); e != nil {
+				err = errs.NewErrFailToRollbackTx(err, e, panicked)
+				return
+			}
+			// 保存点之后的失败已经被撤销，不再影响外层事务的提交
+			tx.rollbackOnly = rollbackOnly
+			return
+		}
+		_, err = tx.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	}()
+
+	err = fn(withTx(ctx, tx), tx)
+	panicked = false
+	return err
+}
+
+// join 在当前事务中执行 fn，失败时将事务标记为只能回滚
+func (tx *Tx) join(ctx context.Context, fn func(ctx context.Context, tx *Tx) error) (err error) {
+	panicked := true
+	defer func() {
+		if panicked || err != nil {
+			tx.rollbackOnly = true
+		}
+	}()
+	err = fn(ctx, tx)
+	panicked = false
+	return err
+}
+
 func (tx *Tx) getCore() core {
 	return tx.db.core
 }
@@ -38,6 +120,38 @@ func (tx *Tx) queryContext(ctx context.Context, query string, args ...any) (*sql
 	return tx.tx.QueryContext(ctx, query, args...)
 }
 
+func (tx *Tx) readQueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return tx.tx.QueryContext(ctx, query, args...)
+}
+
 func (tx *Tx) execContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
 	return tx.tx.ExecContext(ctx, query, args...)
 }
+
+type txKey struct{}
+
+func withTx(ctx context.Context, tx *Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// sessionTx 返回语句执行时所在的事务：session 本身是事务，或者 ctx 中有 session 开启的事务
+func sessionTx(ctx context.Context, session Session) *Tx {
+	switch s := session.(type) {
+	case *Tx:
+		return s
+	case *DB:
+		if tx, ok := txFromContext(ctx, s); ok {
+			return tx
+		}
+	}
+	return nil
+}
+
+// txFromContext 返回 ctx 中由 db 开启且尚未结束的事务
+func txFromContext(ctx context.Context, db *DB) (*Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*Tx)
+	if !ok || tx.db != db || tx.done {
+		return nil, false
+	}
+	return tx, true
+}
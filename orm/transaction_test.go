@@ -0,0 +1,202 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDB_DoTxPropagation(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mock    func(mock sqlmock.Sqlmock)
+		fn      func(db *DB) error
+		wantErr error
+	}{
+		{
+			name: "selector built from db joins tx in ctx",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT * FROM `test_model` WHERE `id` = ?;").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			},
+			fn: func(db *DB) error {
+				// 只有一个连接，查询没有使用事务的连接时会因为拿不到连接而超时
+				db.SetMaxOpenConns(1)
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+				return db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+					_, err := NewSelector[TestModel](db).Where(Col("Id").EQ(1)).Get(ctx)
+					return err
+				}, nil)
+			},
+		},
+		{
+			name: "required joins outer tx",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM `test_model`;").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			fn: func(db *DB) error {
+				return db.DoTx(context.Background(), func(ctx context.Context, outer *Tx) error {
+					return db.DoTx(ctx, func(ctx context.Context, inner *Tx) error {
+						if inner != outer {
+							return errors.New("inner tx is not outer tx")
+						}
+						return NewDeleter[TestModel](db).Exec(ctx).Err()
+					}, nil)
+				}, nil)
+			},
+		},
+		{
+			name: "required failure marks rollback only",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+			},
+			fn: func(db *DB) error {
+				return db.DoTx(context.Background(), func(ctx context.Context, tx *Tx) error {
+					// 外层忽略了内层的错误，但是事务仍然不能提交
+					_ = db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+						return errors.New("mock error")
+					}, nil)
+					return nil
+				}, nil)
+			},
+			wantErr: ErrTxRollbackOnly,
+		},
+		{
+			name: "nested rolls back to savepoint",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("DELETE FROM `test_model`;").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("ROLLBACK TO SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("SAVEPOINT sp_2").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("RELEASE SAVEPOINT sp_2").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			fn: func(db *DB) error {
+				return db.DoTx(context.Background(), func(ctx context.Context, tx *Tx) error {
+					err := db.DoTxWithPropagation(ctx, PropagationNested, func(ctx context.Context, tx *Tx) error {
+						if err := NewDeleter[TestModel](db).Exec(ctx).Err(); err != nil {
+							return err
+						}
+						return errors.New("mock error")
+					}, nil)
+					if err == nil {
+						return errors.New("want error")
+					}
+					return tx.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+						return nil
+					})
+				}, nil)
+			},
+		},
+		{
+			name: "requires new starts independent tx",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				mock.ExpectCommit()
+			},
+			fn: func(db *DB) error {
+				return db.DoTx(context.Background(), func(ctx context.Context, outer *Tx) error {
+					err := db.DoTxWithPropagation(ctx, PropagationRequiresNew, func(ctx context.Context, inner *Tx) error {
+						if inner == outer {
+							return nil
+						}
+						return errors.New("mock error")
+					}, nil)
+					if err == nil {
+						return errors.New("want error")
+					}
+					return nil
+				}, nil)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+			db, err := OpenDB(mockDB)
+			require.NoError(t, err)
+			tc.mock(mock)
+
+			err = tc.fn(db)
+			assert.Equal(t, tc.wantErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTxFromContext(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+	other, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	tx, err := db.BeginTx(context.Background(), nil)
+	require.NoError(t, err)
+	ctx := withTx(context.Background(), tx)
+
+	res, ok := txFromContext(ctx, db)
+	assert.True(t, ok)
+	assert.Equal(t, tx, res)
+	// 其它 DB 不会使用该事务
+	_, ok = txFromContext(ctx, other)
+	assert.False(t, ok)
+
+	require.NoError(t, tx.Commit())
+	_, ok = txFromContext(ctx, db)
+	assert.False(t, ok)
+}
+
+func TestTx_OnCommit(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	var calls []string
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	err = db.DoTx(context.Background(), func(ctx context.Context, tx *Tx) error {
+		tx.OnCommit(func() { calls = append(calls, "outer") })
+		// 加入的事务注册的 fn 在外层事务提交之后调用
+		return db.DoTx(ctx, func(ctx context.Context, inner *Tx) error {
+			inner.OnCommit(func() { calls = append(calls, "inner") })
+			assert.Empty(t, calls)
+			return nil
+		}, nil)
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"outer", "inner"}, calls)
+
+	// 回滚时不调用
+	calls = nil
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	err = db.DoTx(context.Background(), func(ctx context.Context, tx *Tx) error {
+		tx.OnCommit(func() { calls = append(calls, "rollback") })
+		return errors.New("mock error")
+	}, nil)
+	require.Error(t, err)
+	assert.Empty(t, calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,69 @@
+package orm
+
+// TypedColumn 带类型的列，比较运算的参数类型在编译期检查，通常由 orm-gen 生成，
+// e.g. UserName := TypedCol[string]("Name"); UserName.EQ("Tom") --> `name` = ?
+type TypedColumn[V any] struct {
+	Column
+}
+
+// TypedCol name 是结构体字段名，与 Col 相同
+func TypedCol[V any](name string) TypedColumn[V] {
+	return TypedColumn[V]{Column: Col(name)}
+}
+
+func (c TypedColumn[V]) EQ(val V) Predicate {
+	return c.Column.EQ(val)
+}
+
+func (c TypedColumn[V]) NE(val V) Predicate {
+	return c.Column.NE(val)
+}
+
+func (c TypedColumn[V]) GT(val V) Predicate {
+	return c.Column.GT(val)
+}
+
+func (c TypedColumn[V]) GE(val V) Predicate {
+	return c.Column.GE(val)
+}
+
+func (c TypedColumn[V]) LT(val V) Predicate {
+	return c.Column.LT(val)
+}
+
+func (c TypedColumn[V]) LE(val V) Predicate {
+	return c.Column.LE(val)
+}
+
+func (c TypedColumn[V]) In(vals ...V) Predicate {
+	return Predicate{
+		left:  c.Column,
+		op:    opIn,
+		right: typedValues(vals),
+	}
+}
+
+func (c TypedColumn[V]) NotIn(vals ...V) Predicate {
+	return Predicate{
+		left:  c.Column,
+		op:    opNotIn,
+		right: typedValues(vals),
+	}
+}
+
+func (c TypedColumn[V]) Between(low, high V) Predicate {
+	return c.Column.Between(low, high)
+}
+
+func (c TypedColumn[V]) NotBetween(low, high V) Predicate {
+	return c.Column.NotBetween(low, high)
+}
+
+// typedValues 每个值对应一个占位符，V 是切片（例如 []byte）时也不会被展开
+func typedValues[V any](vals []V) valuesExpression {
+	res := make([]any, 0, len(vals))
+	for _, val := range vals {
+		res = append(res, val)
+	}
+	return valuesExpression{vals: res}
+}
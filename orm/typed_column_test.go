@@ -0,0 +1,31 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedColumn(t *testing.T) {
+	mockDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	var (
+		id        = TypedCol[int64]("Id")
+		firstName = TypedCol[string]("FirstName")
+		age       = TypedCol[uint8]("Age")
+	)
+	q, err := NewSelector[TestModel](db).Where(id.In(1, 2), firstName.EQ("Tom"), age.Between(18, 30)).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM `test_model` WHERE ((`id` IN (?,?)) AND (`first_name` = ?)) AND (`age` BETWEEN ? AND ?);", q.SQL)
+	assert.Equal(t, []any{int64(1), int64(2), "Tom", uint8(18), uint8(30)}, q.Args)
+
+	// 只有一个切片类型的值时不会被展开
+	q, err = NewSelector[TestModel](db).Where(TypedCol[[]byte]("LastName").In([]byte("a"))).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM `test_model` WHERE `last_name` IN (?);", q.SQL)
+}
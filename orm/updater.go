@@ -16,6 +16,12 @@ type Updater[T any] struct {
 	assigns []Assignable
 	val     *T
 	where   []Predicate
+	// table 联表更新时的 TableReference，nil 表示只更新模型对应的表
+	table   TableReference
+	orderBy []OrderBy
+	limit   int
+	// restore 恢复软删除的行，将软删除列置为 NULL
+	restore bool
 
 	session Session
 }
@@ -40,30 +46,60 @@ func (u *Updater[T]) Build() (*Query, error) {
 	if u.model, err = u.r.Get(&t); err != nil {
 		return nil, err
 	}
+	u.reset()
+	if err = u.resolveShard(u.where); err != nil {
+		return nil, err
+	}
+
+	m := &mutation{table: u.table, orderBy: u.orderBy, limit: u.limit}
+	if err = u.prepareMutation(m); err != nil {
+		return nil, err
+	}
 
 	u.sqlStrBuilder.WriteString("UPDATE ")
-	u.quote(u.model.TableName)
+	if err = u.buildMutationTable(m, ""); err != nil {
+		return nil, err
+	}
 
 	// 处理set
+	assigns := u.assigns
+	if u.restore {
+		if u.model.DeletedAtField == nil {
+			return nil, errs.NewErrUnsupportedFeature("没有软删除字段的模型不支持 Restore")
+		}
+		assigns = append([]Assignable{Assign(u.model.DeletedAtField.GoName, nil)}, assigns...)
+	}
 	valDealer := u.valCreator(u.val, u.model)
-	if len(u.assigns) > 0 {
+	if len(assigns) > 0 {
 		u.sqlStrBuilder.WriteString(" SET ")
-		for idx, assign := range u.assigns {
+		for idx, assign := range assigns {
 			if idx > 0 {
 				u.sqlStrBuilder.WriteString(",")
 			}
 			switch assign := assign.(type) {
 			case Assignment:
-				if err = u.buildAssignment(assign); err != nil {
+				if err = u.buildColumn(m.setColumn(assign.column)); err != nil {
+					return nil, err
+				}
+				u.sqlStrBuilder.WriteByte('=')
+				var val Expression
+				if val, err = u.assignValue(assign); err != nil {
+					return nil, err
+				}
+				if err = u.buildExpression(val); err != nil {
 					return nil, err
 				}
 			case Column:
+				fd, ok := u.model.FieldMap[assign.name]
+				if !ok {
+					return nil, errs.NewErrUnknownField(assign.name)
+				}
 				var res any
-				res, err = valDealer.GetFieldValue(assign.name)
+				res, err = fieldValue(valDealer, fd)
 				if err != nil {
 					return nil, err
 				}
-				err = u.buildColumn(Col(assign.name))
+				err = u.buildColumn(m.setColumn(assign.name))
 				if err != nil {
 					return nil, err
 				}
@@ -76,10 +112,9 @@ func (u *Updater[T]) Build() (*Query, error) {
 		// 批量更新（u.val == nil）场景显式 opt-out，不追加。
 		if u.model.VersionField != nil && u.val != nil && !u.versionColumnExplicitlySet() {
 			u.sqlStrBuilder.WriteString(",")
-			u.quote(u.model.VersionField.ColName)
-			u.sqlStrBuilder.WriteString("=")
-			u.quote(u.model.VersionField.ColName)
-			u.sqlStrBuilder.WriteString("+1")
+			if err = u.buildVersionIncr(m); err != nil {
+				return nil, err
+			}
 		}
 	} else {
 		if u.val == nil {
@@ -99,12 +134,12 @@ func (u *Updater[T]) Build() (*Query, error) {
 				u.sqlStrBuilder.WriteString(",")
 			}
 			first = false
-			if err = u.buildColumn(Col(field.GoName)); err != nil {
+			if err = u.buildColumn(m.setColumn(field.GoName)); err != nil {
 				return nil, err
 			}
 			u.sqlStrBuilder.WriteString("=?")
 			var v any
-			v, err = valDealer.GetFieldValue(field.GoName)
+			v, err = fieldValue(valDealer, field)
 			if err != nil {
 				return nil, err
 			}
@@ -116,30 +151,35 @@ func (u *Updater[T]) Build() (*Query, error) {
 			if !first {
 				u.sqlStrBuilder.WriteString(",")
 			}
-			u.quote(u.model.VersionField.ColName)
-			u.sqlStrBuilder.WriteString("=")
-			u.quote(u.model.VersionField.ColName)
-			u.sqlStrBuilder.WriteString("+1")
+			if err = u.buildVersionIncr(m); err != nil {
+				return nil, err
+			}
 		}
 	}
 
+	if err = u.buildMutationFrom(m, "FROM"); err != nil {
+		return nil, err
+	}
+
 	// 处理where（含软删除过滤）
-	if err = u.buildWhereWithSoftDelete(u.where); err != nil {
+	hasWhere, err := u.buildMutationWhere(m, u.where)
+	if err != nil {
 		return nil, err
 	}
 
 	// 乐观锁：在 WHERE 末尾追加 AND <version_col>=?，参数为通过反射从 u.val 读取的当前版本值。
 	// 仅当模型定义了 VersionField 且持有模型实例（Update(val)）时启用。
-	// buildWhereWithSoftDelete 仅在存在软删除字段或用户 WHERE 时输出 " WHERE "，
+	// buildMutationWhere 仅在存在软删除字段、用户 WHERE 或者改写为子查询时输出 " WHERE "，
 	// 这里据此决定追加 " AND " 还是 " WHERE "。
 	if u.model.VersionField != nil && u.val != nil {
-		hasWhere := u.model.DeletedAtField != nil || len(u.where) > 0
 		if hasWhere {
 			u.sqlStrBuilder.WriteString(" AND ")
 		} else {
 			u.sqlStrBuilder.WriteString(" WHERE ")
 		}
-		u.quote(u.model.VersionField.ColName)
+		if err = u.buildColumn(Column{name: u.model.VersionField.GoName, table: u.target}); err != nil {
+			return nil, err
+		}
 		u.sqlStrBuilder.WriteString("=?")
 		var verVal any
 		verVal, err = readVersionFromVal(u.val, u.model.VersionField)
@@ -149,11 +189,11 @@ func (u *Updater[T]) Build() (*Query, error) {
 		u.args = append(u.args, verVal)
 	}
 
+	if err = u.buildMutationLimit(m, false); err != nil {
+		return nil, err
+	}
 	u.sqlStrBuilder.WriteByte(';')
-	return &Query{
-		SQL:  u.sqlStrBuilder.String(),
-		Args: u.args,
-	}, nil
+	return u.buildQuery(), nil
 }
 
 func (u *Updater[T]) Set(assigns ...Assignable) *Updater[T] {
@@ -167,6 +207,35 @@ func (u *Updater[T]) Update(val *T) *Updater[T] {
 	return u
 }
 
+// Restore 恢复已经软删除的行：SET deleted_at=NULL，并且只匹配 deleted_at IS NOT NULL 的行，
+// e.g. NewUpdater[User](db).Restore().Where(Col("Id").EQ(1)).Exec(ctx)。
+// 可以与 Set 一起使用，此时不再根据 Update 传入的实例生成 SET
+func (u *Updater[T]) Restore() *Updater[T] {
+	u.restore = true
+	u.scope = scopeOnlyTrashed
+	return u
+}
+
+// From 联表更新，tbl 中模型对应的表是被更新的表，e.g.
+// NewUpdater[Order](db).From(TableOf(&Order{}).Join(TableOf(&User{})).On(...)).Set(...)。
+// MySQL 生成 UPDATE ... JOIN，Postgres 生成 UPDATE ... FROM，SQLite 改写为按 rowid 匹配子查询
+func (u *Updater[T]) From(tbl TableReference) *Updater[T] {
+	u.table = tbl
+	return u
+}
+
+// OrderBy 与 Limit 一起使用，只更新排序后的前若干行。Postgres 以及 MySQL 联表更新时不支持
+func (u *Updater[T]) OrderBy(orderBys ...OrderBy) *Updater[T] {
+	u.orderBy = orderBys
+	return u
+}
+
+// Limit 最多更新 limit 行
+func (u *Updater[T]) Limit(limit int) *Updater[T] {
+	u.limit = limit
+	return u
+}
+
 func (u *Updater[T]) Where(where ...Predicate) *Updater[T] {
 	u.where = where
 	return u
@@ -201,7 +270,7 @@ func (u *Updater[T]) Exec(ctx context.Context) Result {
 				return Result{err: e}
 			}
 		}
-		if len(u.assigns) > 0 {
+		if len(u.assigns) > 0 || u.restore {
 			alreadyHas := false
 			for _, a := range u.assigns {
 				switch a := a.(type) {
@@ -221,11 +290,26 @@ func (u *Updater[T]) Exec(ctx context.Context) Result {
 		}
 	}
 
-	res := exec(ctx, u.core, u.session, &QueryContext{
-		Type:         "UPDATE",
-		QueryBuilder: u,
-		Model:        u.model,
-	})
+	dsts, err := u.shardingDsts(u.where)
+	if err != nil {
+		return Result{err: err}
+	}
+	var res *QueryResult
+	if dsts != nil && len(dsts) != 1 {
+		res = execShards(dsts, func(idx int, dst *Dst) *QueryResult {
+			return exec(ctx, u.core, u.session, &QueryContext{
+				Type:         "UPDATE",
+				QueryBuilder: u.shard(dst),
+				Model:        u.model,
+			})
+		})
+	} else {
+		res = exec(ctx, u.core, u.session, &QueryContext{
+			Type:         "UPDATE",
+			QueryBuilder: u,
+			Model:        u.model,
+		})
+	}
 
 	var sqlRes sql.Result
 	if res.Result != nil {
@@ -259,6 +343,20 @@ func (u *Updater[T]) Exec(ctx context.Context) Result {
 	}
 }
 
+// buildVersionIncr 写入 version=version+1（DB 端原子自增）
+func (u *Updater[T]) buildVersionIncr(m *mutation) error {
+	col := m.setColumn(u.model.VersionField.GoName)
+	if err := u.buildColumn(col); err != nil {
+		return err
+	}
+	u.sqlStrBuilder.WriteString("=")
+	if err := u.buildColumn(col); err != nil {
+		return err
+	}
+	u.sqlStrBuilder.WriteString("+1")
+	return nil
+}
+
 // versionColumnExplicitlySet 检查 u.assigns 是否已显式包含对 version 字段的赋值
 // （无论是 Assignment 还是 Column 形式）。若是，则 Build 跳过自动追加 version=version+1，
 // 与 UpdatedAtField 的检测模式保持一致。
@@ -286,10 +384,10 @@ func (u *Updater[T]) versionColumnExplicitlySet() bool {
 // registry 已保证 VersionField 为整数族类型（int/int8-64、uint/uint8-64），
 // 这里仍对 Kind 做分支处理以兼容 int 与 uint 各宽度。
 func readVersionFromVal(val any, field *model.Field) (int64, error) {
-	v := reflect.ValueOf(val).Elem()
-	fv := v.FieldByName(field.GoName)
-	if !fv.IsValid() {
-		return 0, errs.NewErrUnknownField(field.GoName)
+	fv, ok := field.FieldValue(reflect.ValueOf(val).Elem(), false)
+	if !ok {
+		// 版本字段所在的结构体指针为 nil，与零值一样
+		return 0, nil
 	}
 	switch fv.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
@@ -300,3 +398,40 @@ func readVersionFromVal(val any, field *model.Field) (int64, error) {
 		return 0, fmt.Errorf("orm: 字段 %s 类型 %s 不是整数族，无法作为版本字段", field.GoName, fv.Type())
 	}
 }
+
+// shard 复制一个只更新 dst 分片的 Updater
+func (u *Updater[T]) shard(dst *Dst) *Updater[T] {
+	return &Updater[T]{
+		builder: builder{
+			core:     u.core,
+			quoter:   u.quoter,
+			shardDst: dst,
+			scope:    u.scope,
+		},
+		assigns: u.assigns,
+		val:     u.val,
+		where:   u.where,
+		table:   u.table,
+		orderBy: u.orderBy,
+		limit:   u.limit,
+		restore: u.restore,
+		session: u.session,
+	}
+}
+
+// assignValue Assign 的值是普通的值并且列指定了 Converter 时，返回转换之后的值
+func (u *Updater[T]) assignValue(assign Assignment) (Expression, error) {
+	v, ok := assign.val.(value)
+	if !ok || v.val == nil {
+		return assign.val, nil
+	}
+	fd, ok := u.model.FieldMap[assign.column]
+	if !ok || fd.Converter == nil {
+		return assign.val, nil
+	}
+	res, err := fd.Converter.Value(v.val)
+	if err != nil {
+		return nil, err
+	}
+	return valueOf(res), nil
+}